@@ -0,0 +1,270 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/dynconfx"
+	v "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	h "github.com/theplant/htmlgo"
+)
+
+const (
+	// EventSave is the event saving the posted form
+	EventSave = "dynconfx_admin_save"
+
+	formPortal  = "dynconfxAdminForm"
+	reasonField = "__reason"
+)
+
+// Builder builds an admin page editing a config, with a form generated from the Schema of T.
+// The form is saved through ConfigAPI.Save, the flow behind ConfigAPI.UpdateConfig,
+// so the same validator, change info and cache invalidation apply.
+// The fields encrypted by the store of provider are secrets, unless dynconfx.WithSecretFields overrides them.
+//
+// Example:
+//
+//	b, err := admin.New(ctx, provider, configAPI,
+//	    admin.WithTitle[*AppConfig]("App Settings"),
+//	)
+//	mux.Handle("/admin/config", b.Page().Wrap(layout))
+type Builder[T any] struct {
+	provider      *dynconfx.ConfigProvider[T]
+	api           *dynconfx.ConfigAPI[T]
+	title         string
+	schemaOptions []dynconfx.SchemaOption
+	schema        *dynconfx.Schema
+}
+
+// Option is a function that configures a Builder.
+type Option[T any] func(*Builder[T])
+
+// WithTitle sets the title of the page.
+func WithTitle[T any](title string) Option[T] {
+	return func(b *Builder[T]) {
+		b.title = title
+	}
+}
+
+// WithSchemaOptions sets how the Schema of the form is derived, e.g. which fields are secrets.
+func WithSchemaOptions[T any](opts ...dynconfx.SchemaOption) Option[T] {
+	return func(b *Builder[T]) {
+		b.schemaOptions = append(b.schemaOptions, opts...)
+	}
+}
+
+// New creates a Builder for the config of provider, saved through api.
+// ctx is used to get the secret fields of the store.
+func New[T any](ctx context.Context, provider *dynconfx.ConfigProvider[T], api *dynconfx.ConfigAPI[T], opts ...Option[T]) (*Builder[T], error) {
+	b := &Builder[T]{
+		provider: provider,
+		api:      api,
+		title:    "Settings",
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	schemaOptions := append([]dynconfx.SchemaOption{
+		dynconfx.WithStoreSecretFields(ctx, provider.Store()),
+	}, b.schemaOptions...)
+	schema, err := dynconfx.NewSchema[T](schemaOptions...)
+	if err != nil {
+		return nil, err
+	}
+	b.schema = schema
+	return b, nil
+}
+
+// Schema returns the Schema the form is generated from.
+func (b *Builder[T]) Schema() *dynconfx.Schema {
+	return b.schema
+}
+
+// Page returns the page rendering the form and handling its events.
+func (b *Builder[T]) Page() *web.PageBuilder {
+	return web.Page(b.page).EventFunc(EventSave, b.save)
+}
+
+func (b *Builder[T]) page(ctx *web.EventContext) (r web.PageResponse, err error) {
+	config, err := b.provider.Get(ctx.R.Context())
+	if err != nil {
+		return r, errors.Wrap(err, "failed to get config")
+	}
+	values, err := formValues(b.schema, config)
+	if err != nil {
+		return r, err
+	}
+
+	r.PageTitle = b.title
+	r.Body = v.VContainer(
+		h.H1(b.title).Class("text-h5 mb-4"),
+		web.Portal(b.form(values, &web.ValidationErrors{}, "")).Name(formPortal),
+	)
+	return r, nil
+}
+
+func (b *Builder[T]) save(ctx *web.EventContext) (r web.EventResponse, err error) {
+	if err := ctx.R.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return r, errors.Wrap(err, "failed to parse form")
+	}
+
+	verrs := &web.ValidationErrors{}
+	render := func(values map[string]any, success string) (web.EventResponse, error) {
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: formPortal,
+			Body: b.form(values, verrs, success),
+		})
+		return r, nil
+	}
+
+	current, err := b.provider.Get(ctx.R.Context())
+	if err != nil {
+		return r, errors.Wrap(err, "failed to get config")
+	}
+	config, fieldErrors, err := applyForm(b.schema, current, ctx.R.Form)
+	if err != nil {
+		return r, err
+	}
+	if len(fieldErrors) > 0 {
+		for path, message := range fieldErrors {
+			verrs.FieldError(path, message)
+		}
+		return render(postedValues(b.schema, ctx.R.Form), "")
+	}
+
+	err = b.api.Save(b.api.ChangeContext(ctx.R, ctx.R.FormValue(reasonField)), config)
+	if err != nil {
+		var validationErr *dynconfx.ValidationError
+		if !errors.As(err, &validationErr) {
+			verrs.GlobalError(err.Error())
+			return render(postedValues(b.schema, ctx.R.Form), "")
+		}
+		b.addValidationErrors(verrs, validationErr.Err)
+		return render(postedValues(b.schema, ctx.R.Form), "")
+	}
+
+	saved, err := b.provider.Get(ctx.R.Context())
+	if err != nil {
+		return r, errors.Wrap(err, "failed to get config")
+	}
+	values, err := formValues(b.schema, saved)
+	if err != nil {
+		return r, err
+	}
+	return render(values, "Saved successfully")
+}
+
+// addValidationErrors reports the errors of the Validator on their fields,
+// other errors and errors of fields not in the form are reported as global errors.
+func (b *Builder[T]) addValidationErrors(verrs *web.ValidationErrors, err error) {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		verrs.GlobalError(err.Error())
+		return
+	}
+
+	for _, fe := range fieldErrors {
+		// The namespace starts with the name of the config struct
+		_, goPath, _ := strings.Cut(fe.StructNamespace(), ".")
+		message := fmt.Sprintf("failed on the %q rule", strings.TrimSuffix(fe.Tag()+"="+fe.Param(), "="))
+		if field := b.schema.FieldByGoPath(goPath); field != nil {
+			verrs.FieldError(field.Path, message)
+			continue
+		}
+		verrs.GlobalError(fmt.Sprintf("%s %s", goPath, message))
+	}
+}
+
+func (b *Builder[T]) form(values map[string]any, verrs *web.ValidationErrors, success string) h.HTMLComponent {
+	var alerts []h.HTMLComponent
+	if success != "" {
+		alerts = append(alerts, v.VAlert().Type("success").Text(success).Class("mb-4"))
+	}
+	for _, message := range verrs.GetGlobalErrors() {
+		alerts = append(alerts, v.VAlert().Type("error").Text(message).Class("mb-4"))
+	}
+
+	return h.Div(
+		h.Components(alerts...),
+		b.fields(b.schema.Fields, values, verrs),
+		vx.VXField().Label("Reason of the change").Attr(web.VField(reasonField, "")...),
+		h.Div(
+			vx.VXBtn("Save").Color("primary").OnClick(EventSave),
+		).Class("d-flex justify-end mt-4"),
+	)
+}
+
+func (b *Builder[T]) fields(fields []*dynconfx.SchemaField, values map[string]any, verrs *web.ValidationErrors) h.HTMLComponent {
+	var comps []h.HTMLComponent
+	for _, field := range fields {
+		if field.Type == dynconfx.FieldTypeObject {
+			comps = append(comps, h.Div(
+				h.Div(h.Text(field.Name)).Class("text-subtitle-1 mb-2"),
+				b.fields(field.Fields, values, verrs),
+			).Class("pl-4 mb-4"))
+			continue
+		}
+		comps = append(comps, b.input(field, values[field.Path], verrs.GetFieldErrors(field.Path)))
+	}
+	return h.Components(comps...)
+}
+
+// input renders the input of a field by its type.
+func (b *Builder[T]) input(field *dynconfx.SchemaField, value any, errorMessages []string) h.HTMLComponent {
+	hint := hintOf(field)
+
+	switch {
+	case field.Secret:
+		return vx.VXField().Label(field.Name).Type("password").
+			Placeholder("Unchanged, enter a new value to replace it").
+			Tips(hint).ErrorMessages(errorMessages...).
+			Attr(web.VField(field.Path, "")...)
+	case field.Type == dynconfx.FieldTypeBool:
+		return vx.VXCheckbox().Label(field.Name).Hint(hint).PersistentHint(hint != "").
+			ErrorMessages(errorMessages...).
+			Attr(web.VField(field.Path, value)...)
+	case len(field.Options) > 0:
+		return vx.VXSelect().Label(field.Name).Items(field.Options).Attr("tips", hint).
+			Required(field.Required).ErrorMessages(errorMessages...).
+			Attr(web.VField(field.Path, value)...)
+	case field.Type == dynconfx.FieldTypeStrings:
+		return v.VCombobox().Label(field.Name).Multiple(true).Chips(true).ClosableChips(true).
+			Hint(hint).PersistentHint(hint != "").ErrorMessages(errorMessages).
+			Attr(web.VField(field.Path, value)...)
+	}
+
+	input := vx.VXField().Label(field.Name).Tips(hint).Required(field.Required).ErrorMessages(errorMessages...)
+	switch field.Type {
+	case dynconfx.FieldTypeInt, dynconfx.FieldTypeFloat:
+		input.Type("number")
+	case dynconfx.FieldTypeJSON:
+		input.Type("textarea")
+	case dynconfx.FieldTypeDuration:
+		input.Placeholder("e.g. 1h30m")
+	case dynconfx.FieldTypeTime:
+		input.Placeholder("e.g. 2006-01-02T15:04:05Z")
+	}
+	return input.Attr(web.VField(field.Path, value)...)
+}
+
+// hintOf describes a field by its usage and validation bounds.
+func hintOf(field *dynconfx.SchemaField) string {
+	var parts []string
+	if field.Usage != "" {
+		parts = append(parts, field.Usage)
+	}
+	if field.Min != "" {
+		parts = append(parts, "min: "+field.Min)
+	}
+	if field.Max != "" {
+		parts = append(parts, "max: "+field.Max)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/qor5/web/v3/multipartestutils"
+	"github.com/qor5/x/v3/dynconfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type appConfig struct {
+	Name   string `json:"name" validate:"required" usage:"Name of the app"`
+	Port   int    `json:"port" validate:"gte=1024"`
+	Secret string `json:"secret"`
+}
+
+// memoryStore keeps the saved config and the info of the last change.
+type memoryStore struct {
+	config appConfig
+	info   dynconfx.ChangeInfo
+}
+
+func (s *memoryStore) Load(ctx context.Context) (appConfig, error) {
+	return s.config, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, config appConfig) error {
+	s.config = config
+	s.info = dynconfx.ChangeInfoFromContext(ctx)
+	return nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, updateFunc func(appConfig) appConfig) error {
+	return s.Save(ctx, updateFunc(s.config))
+}
+
+func TestBuilder(t *testing.T) {
+	store := &memoryStore{config: appConfig{Name: "app", Port: 8080, Secret: "s3cret"}}
+	provider := dynconfx.NewConfigProvider[appConfig](store)
+	api := dynconfx.NewConfigAPI(provider, store,
+		dynconfx.WithAPIValidator[appConfig](validator.New()),
+		dynconfx.WithAPIAuthor[appConfig](func(r *http.Request) string { return "alice" }),
+	)
+	b, err := New(context.Background(), provider, api,
+		WithTitle[appConfig]("App Settings"),
+		WithSchemaOptions[appConfig](dynconfx.WithSecretFields("Secret")),
+	)
+	require.NoError(t, err)
+	page := b.Page()
+
+	t.Run("render", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		page.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "App Settings")
+		assert.Contains(t, w.Body.String(), "Name of the app")
+		assert.NotContains(t, w.Body.String(), "s3cret")
+	})
+
+	cases := []multipartestutils.TestCase{
+		{
+			Name: "validation errors",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(EventSave).
+					AddField("/name", "app").
+					AddField("/port", "80").
+					AddField("/secret", "").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{`failed on the \"gte=1024\" rule`},
+		},
+		{
+			Name: "invalid input",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(EventSave).
+					AddField("/port", "many").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"must be an integer"},
+		},
+		{
+			Name: "save",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(EventSave).
+					AddField("/name", "renamed").
+					AddField("/port", "9090").
+					AddField("/secret", "").
+					AddField(reasonField, "move port").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"Saved successfully"},
+			ExpectPortalUpdate0NotContains:     []string{"s3cret"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			multipartestutils.RunCase(t, c, page)
+		})
+	}
+
+	assert.Equal(t, appConfig{Name: "renamed", Port: 9090, Secret: "s3cret"}, store.config)
+	assert.Equal(t, dynconfx.ChangeInfo{Author: "alice", Reason: "move port"}, store.info)
+}
+
+// encryptingStore encrypts the Secret field like an EncryptedConfigStore registered with MustRegisterSecretFields("Secret").
+type encryptingStore struct {
+	memoryStore
+}
+
+func (s *encryptingStore) SecretFields(ctx context.Context) ([]string, error) {
+	return []string{"Secret"}, nil
+}
+
+func TestBuilder_StoreSecretFields(t *testing.T) {
+	store := &encryptingStore{memoryStore{config: appConfig{Name: "app", Port: 8080, Secret: "s3cret"}}}
+	provider := dynconfx.NewConfigProvider[appConfig](store)
+	api := dynconfx.NewConfigAPI(provider, store)
+
+	b, err := New(context.Background(), provider, api)
+	require.NoError(t, err)
+	assert.True(t, b.Schema().FieldByGoPath("Secret").Secret)
+	w := httptest.NewRecorder()
+	b.Page().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "s3cret")
+
+	b, err = New(context.Background(), provider, api, WithSchemaOptions[appConfig](dynconfx.WithSecretFields("Name")))
+	require.NoError(t, err)
+	assert.False(t, b.Schema().FieldByGoPath("Secret").Secret)
+	assert.True(t, b.Schema().FieldByGoPath("Name").Secret)
+}
@@ -0,0 +1,267 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/x/v3/dynconfx"
+)
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// pointerKeys splits a JSON Pointer into its keys.
+func pointerKeys(path string) []string {
+	keys := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, key := range keys {
+		keys[i] = pointerUnescaper.Replace(key)
+	}
+	return keys
+}
+
+// toJSONMap encodes config as a JSON object, keeping numbers exact.
+func toJSONMap(config any) (map[string]any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal config")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	m := map[string]any{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config")
+	}
+	return m, nil
+}
+
+func getValue(m map[string]any, keys []string) (any, bool) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		m = child
+	}
+	v, ok := m[keys[len(keys)-1]]
+	return v, ok
+}
+
+func setValue(m map[string]any, keys []string, value any) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			m[key] = child
+		}
+		m = child
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+// formValues returns the values of the form inputs for config, keyed by field path.
+// Secrets are left empty, so they are never sent to the browser.
+func formValues(schema *dynconfx.Schema, config any) (map[string]any, error) {
+	m, err := toJSONMap(config)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	for _, field := range schema.Leaves() {
+		if field.Secret {
+			values[field.Path] = ""
+			continue
+		}
+		v, _ := getValue(m, pointerKeys(field.Path))
+		values[field.Path] = formValue(field, v)
+	}
+	return values, nil
+}
+
+func formValue(field *dynconfx.SchemaField, v any) any {
+	switch field.Type {
+	case dynconfx.FieldTypeBool:
+		b, _ := v.(bool)
+		return b
+	case dynconfx.FieldTypeInt, dynconfx.FieldTypeFloat:
+		n, _ := v.(json.Number)
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+		return 0
+	case dynconfx.FieldTypeDuration:
+		n, _ := v.(json.Number)
+		d, _ := n.Int64()
+		return time.Duration(d).String()
+	case dynconfx.FieldTypeStrings:
+		items, _ := v.([]any)
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case dynconfx.FieldTypeJSON:
+		if v == nil {
+			return ""
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// postedValues returns the values of the form inputs as posted, to render them again with errors.
+func postedValues(schema *dynconfx.Schema, form url.Values) map[string]any {
+	values := map[string]any{}
+	for _, field := range schema.Leaves() {
+		raw := form.Get(field.Path)
+		switch {
+		case field.Secret:
+			values[field.Path] = ""
+		case field.Type == dynconfx.FieldTypeBool:
+			values[field.Path], _ = strconv.ParseBool(raw)
+		case field.Type == dynconfx.FieldTypeStrings:
+			values[field.Path] = nonEmpty(form[field.Path])
+		case field.Type == dynconfx.FieldTypeInt || field.Type == dynconfx.FieldTypeFloat:
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				values[field.Path] = f
+			} else {
+				values[field.Path] = raw
+			}
+		default:
+			values[field.Path] = raw
+		}
+	}
+	return values
+}
+
+// applyForm applies the posted form to config, returning the invalid inputs by field path.
+// Every field is rendered in the form, so the fields missing from it are empty, e.g. a combobox emptied
+// or a checkbox unchecked posts nothing. Secrets left empty keep their current values.
+func applyForm[T any](schema *dynconfx.Schema, config T, form url.Values) (T, map[string]string, error) {
+	var zero T
+	m, err := toJSONMap(config)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	fieldErrors := map[string]string{}
+	for _, field := range schema.Leaves() {
+		raw := form[field.Path]
+		if field.Secret && strings.TrimSpace(form.Get(field.Path)) == "" {
+			continue
+		}
+		value, err := parseValue(field, raw)
+		if err != nil {
+			fieldErrors[field.Path] = err.Error()
+			continue
+		}
+		setValue(m, pointerKeys(field.Path), value)
+	}
+	if len(fieldErrors) > 0 {
+		return zero, fieldErrors, nil
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return zero, nil, errors.Wrap(err, "failed to marshal config")
+	}
+	var updated T
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return zero, nil, errors.Wrap(err, "failed to unmarshal config")
+	}
+	return updated, nil, nil
+}
+
+func parseValue(field *dynconfx.SchemaField, raw []string) (any, error) {
+	var s string
+	if len(raw) > 0 {
+		s = strings.TrimSpace(raw[0])
+	}
+
+	switch field.Type {
+	case dynconfx.FieldTypeBool:
+		if s == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	case dynconfx.FieldTypeInt:
+		if s == "" {
+			return 0, nil
+		}
+		// Numbers may be posted as floats by number inputs
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == float64(int64(f)) {
+			return int64(f), nil
+		}
+		return nil, errors.New("must be an integer")
+	case dynconfx.FieldTypeFloat:
+		if s == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return f, nil
+	case dynconfx.FieldTypeDuration:
+		if s == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.New("must be a duration like 1h30m or 500ms")
+		}
+		return int64(d), nil
+	case dynconfx.FieldTypeTime:
+		if s == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, errors.New("must be a time like 2006-01-02T15:04:05Z")
+		}
+		return t, nil
+	case dynconfx.FieldTypeStrings:
+		return nonEmpty(raw), nil
+	case dynconfx.FieldTypeJSON:
+		if s == "" {
+			return nil, nil
+		}
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, errors.New("must be valid JSON")
+		}
+		return v, nil
+	default:
+		if len(raw) == 0 {
+			return "", nil
+		}
+		return raw[0], nil
+	}
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/dynconfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formConfig struct {
+	Name     string         `json:"name"`
+	Port     int            `json:"port"`
+	Debug    bool           `json:"debug"`
+	Timeout  time.Duration  `json:"timeout"`
+	Hosts    []string       `json:"hosts"`
+	Limits   map[string]int `json:"limits"`
+	Database struct {
+		Password string `json:"password"`
+	} `json:"database"`
+}
+
+func TestForm(t *testing.T) {
+	schema, err := dynconfx.NewSchema[formConfig](dynconfx.WithSecretFields("Database.Password"))
+	require.NoError(t, err)
+
+	current := formConfig{Name: "app", Port: 8080, Timeout: 5 * time.Second, Hosts: []string{"a"}, Limits: map[string]int{"api": 10}}
+	current.Database.Password = "s3cret"
+
+	t.Run("values", func(t *testing.T) {
+		values, err := formValues(schema, current)
+		require.NoError(t, err)
+		assert.Equal(t, "app", values["/name"])
+		assert.Equal(t, float64(8080), values["/port"])
+		assert.Equal(t, "5s", values["/timeout"])
+		assert.Equal(t, []string{"a"}, values["/hosts"])
+		assert.JSONEq(t, `{"api": 10}`, values["/limits"].(string))
+		assert.Equal(t, "", values["/database/password"], "secrets are never rendered")
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		config, fieldErrors, err := applyForm(schema, current, url.Values{
+			"/name":              {"app"},
+			"/port":              {"9090"},
+			"/debug":             {"true"},
+			"/timeout":           {"1m30s"},
+			"/hosts":             {"b", "", "c"},
+			"/limits":            {`{"api": 20}`},
+			"/database/password": {""},
+		})
+		require.NoError(t, err)
+		require.Empty(t, fieldErrors)
+		assert.Equal(t, "app", config.Name)
+		assert.Equal(t, 9090, config.Port)
+		assert.True(t, config.Debug)
+		assert.Equal(t, 90*time.Second, config.Timeout)
+		assert.Equal(t, []string{"b", "c"}, config.Hosts)
+		assert.Equal(t, map[string]int{"api": 20}, config.Limits)
+		assert.Equal(t, "s3cret", config.Database.Password, "empty secrets are kept")
+
+		config, _, err = applyForm(schema, current, url.Values{"/database/password": {"n3w"}})
+		require.NoError(t, err)
+		assert.Equal(t, "n3w", config.Database.Password)
+	})
+
+	t.Run("apply emptied fields", func(t *testing.T) {
+		// An emptied combobox and an unchecked checkbox post nothing
+		debug := current
+		debug.Debug = true
+		config, fieldErrors, err := applyForm(schema, debug, url.Values{
+			"/name":    {"app"},
+			"/port":    {"8080"},
+			"/timeout": {"5s"},
+			"/limits":  {`{"api": 10}`},
+		})
+		require.NoError(t, err)
+		require.Empty(t, fieldErrors)
+		assert.Empty(t, config.Hosts)
+		assert.False(t, config.Debug)
+		assert.Equal(t, "app", config.Name)
+		assert.Equal(t, map[string]int{"api": 10}, config.Limits)
+		assert.Equal(t, "s3cret", config.Database.Password, "secrets not posted are kept")
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		_, fieldErrors, err := applyForm(schema, current, url.Values{
+			"/port":    {"80.5"},
+			"/timeout": {"soon"},
+			"/limits":  {"{"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"/port":    "must be an integer",
+			"/timeout": "must be a duration like 1h30m or 500ms",
+			"/limits":  "must be valid JSON",
+		}, fieldErrors)
+	})
+}
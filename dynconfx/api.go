@@ -1,17 +1,24 @@
 package dynconfx
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
+// ChangeReasonHeader is the request header carrying the reason of a config change.
+const ChangeReasonHeader = "X-Change-Reason"
+
 // ConfigAPI provides HTTP handlers for config management operations.
 type ConfigAPI[T any] struct {
 	dynamicConfig *ConfigProvider[T]
 	store         ConfigStore[T]
 	validator     Validator
+	author        func(r *http.Request) string
 }
 
 // NewConfigAPI creates a new ConfigAPI instance.
@@ -36,6 +43,40 @@ func WithAPIValidator[T any](v Validator) ConfigAPIOption[T] {
 	}
 }
 
+// WithAPIAuthor sets how the author of a config change is resolved from the request, e.g. from the session.
+// The author and the ChangeReasonHeader are saved with the change by stores with history.
+func WithAPIAuthor[T any](author func(r *http.Request) string) ConfigAPIOption[T] {
+	return func(api *ConfigAPI[T]) {
+		api.author = author
+	}
+}
+
+// ValidationError is returned by Save when the config fails validation.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ChangeContext returns the request context carrying the author and reason of the change.
+// The reason defaults to the ChangeReasonHeader.
+func (api *ConfigAPI[T]) ChangeContext(r *http.Request, reason string) context.Context {
+	var author string
+	if api.author != nil {
+		author = api.author(r)
+	}
+	if reason == "" {
+		reason = r.Header.Get(ChangeReasonHeader)
+	}
+	return WithChangeInfo(r.Context(), author, reason)
+}
+
 // GetConfig handles GET requests to retrieve the current config.
 // It returns the cached config if available.
 //
@@ -61,7 +102,7 @@ func (api *ConfigAPI[T]) GetConfig(w http.ResponseWriter, r *http.Request) {
 //
 //	r.Put("/api/config", configAPI.UpdateConfig)
 func (api *ConfigAPI[T]) UpdateConfig(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := api.ChangeContext(r, "")
 
 	// Decode request body
 	var config T
@@ -70,28 +111,47 @@ func (api *ConfigAPI[T]) UpdateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := api.Save(ctx, config); err != nil {
+		respondSaveError(w, err)
+		return
+	}
+
+	// Return success response
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Config updated successfully",
+	})
+}
+
+// Save validates the config, saves it to storage and invalidates the cache.
+// It's the flow behind UpdateConfig, for other editors like the admin page.
+// It returns a *ValidationError if the config fails validation.
+func (api *ConfigAPI[T]) Save(ctx context.Context, config T) error {
 	// Validate config if validator is set
 	if api.validator != nil {
 		if err := api.validator.StructCtx(ctx, config); err != nil {
-			respondError(w, http.StatusBadRequest, errors.Wrap(err, "validation failed"))
-			return
+			return &ValidationError{Err: err}
 		}
 	}
 
 	// Save config to storage
 	if err := api.store.Save(ctx, config); err != nil {
-		respondError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to save config"))
-		return
+		return errors.Wrap(err, "failed to save config")
 	}
 
 	// Invalidate cache so next Get loads the new config
 	api.dynamicConfig.Invalidate()
+	return nil
+}
 
-	// Return success response
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "Config updated successfully",
-	})
+// respondSaveError writes the error of Save, validation failures are reported as 400.
+func respondSaveError(w http.ResponseWriter, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err)
 }
 
 // ReloadConfig handles POST requests to force reload the config.
@@ -126,7 +186,7 @@ func (api *ConfigAPI[T]) ReloadConfig(w http.ResponseWriter, r *http.Request) {
 //
 //	r.Patch("/api/config", configAPI.PartialUpdateConfig)
 func (api *ConfigAPI[T]) PartialUpdateConfig(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := api.ChangeContext(r, "")
 
 	// Decode request body to map[string]any (partial update)
 	var updates map[string]any
@@ -173,27 +233,145 @@ func (api *ConfigAPI[T]) PartialUpdateConfig(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Validate config if validator is set
+	if err := api.Save(ctx, updatedConfig); err != nil {
+		respondSaveError(w, err)
+		return
+	}
+
+	// Return success response
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Config updated successfully",
+	})
+}
+
+// historyStore returns the store as a HistoryStore, responding an error if it keeps no history.
+func (api *ConfigAPI[T]) historyStore(w http.ResponseWriter) (HistoryStore[T], bool) {
+	store, ok := api.store.(HistoryStore[T])
+	if !ok {
+		respondError(w, http.StatusNotImplemented, ErrHistoryDisabled)
+	}
+	return store, ok
+}
+
+// respondHistoryError writes the error of a history operation, missing versions are reported as 404.
+func respondHistoryError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		respondError(w, http.StatusNotFound, errors.Wrap(err, message))
+	case errors.Is(err, ErrHistoryDisabled):
+		respondError(w, http.StatusNotImplemented, err)
+	default:
+		respondError(w, http.StatusInternalServerError, errors.Wrap(err, message))
+	}
+}
+
+// ListVersions handles GET requests to list the saved versions of the config, newest first.
+// The store must implement HistoryStore.
+//
+// Example:
+//
+//	r.Get("/api/config/versions", configAPI.ListVersions)
+func (api *ConfigAPI[T]) ListVersions(w http.ResponseWriter, r *http.Request) {
+	store, ok := api.historyStore(w)
+	if !ok {
+		return
+	}
+
+	versions, err := store.ListVersions(r.Context())
+	if err != nil {
+		respondHistoryError(w, err, "failed to list versions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, versions)
+}
+
+// DiffVersions handles GET requests to diff the versions given by the from and to query parameters.
+// Sensitive fields are not part of the diff.
+//
+// Example:
+//
+//	r.Get("/api/config/diff", configAPI.DiffVersions) // GET /api/config/diff?from=3&to=5
+func (api *ConfigAPI[T]) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	store, ok := api.historyStore(w)
+	if !ok {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, errors.Wrap(err, "invalid from version"))
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, errors.Wrap(err, "invalid to version"))
+		return
+	}
+
+	diffs, err := store.DiffVersions(r.Context(), from, to)
+	if err != nil {
+		respondHistoryError(w, err, "failed to diff versions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"changes": diffs,
+	})
+}
+
+// RollbackRequest is the request body of RollbackConfig.
+type RollbackRequest struct {
+	Version int    `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+// RollbackConfig handles POST requests to roll back to a previous version.
+// The config of the version is validated and saved as a new version.
+//
+// Example:
+//
+//	r.Post("/api/config/rollback", configAPI.RollbackConfig) // {"version": 3, "reason": "revert the broken toggle"}
+func (api *ConfigAPI[T]) RollbackConfig(w http.ResponseWriter, r *http.Request) {
+	store, ok := api.historyStore(w)
+	if !ok {
+		return
+	}
+
+	var req RollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, errors.Wrap(err, "failed to decode request body"))
+		return
+	}
+	ctx := api.ChangeContext(r, req.Reason)
+
+	// Validate config if validator is set, it may have changed since the version was saved
 	if api.validator != nil {
-		if err := api.validator.StructCtx(ctx, updatedConfig); err != nil {
+		config, err := store.LoadVersion(ctx, req.Version)
+		if err != nil {
+			respondHistoryError(w, err, "failed to load version")
+			return
+		}
+		if err := api.validator.StructCtx(ctx, config); err != nil {
 			respondError(w, http.StatusBadRequest, errors.Wrap(err, "validation failed"))
 			return
 		}
 	}
 
-	// Save updated config
-	if err := api.store.Save(ctx, updatedConfig); err != nil {
-		respondError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to save config"))
+	if err := store.Rollback(ctx, req.Version); err != nil {
+		respondHistoryError(w, err, "failed to roll back config")
 		return
 	}
 
 	// Invalidate cache so next Get loads the new config
 	api.dynamicConfig.Invalidate()
 
-	// Return success response
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "Config updated successfully",
+		"message": "Config rolled back successfully",
 	})
 }
 
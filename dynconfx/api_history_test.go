@@ -0,0 +1,162 @@
+package dynconfx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/jsonx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type historyConfig struct {
+	Port int  `json:"port"`
+	Beta bool `json:"beta"`
+}
+
+// mockHistoryStore is a mock implementation of HistoryStore keeping the versions in memory.
+type mockHistoryStore struct {
+	mockStore[historyConfig]
+	versions []historyConfig
+	infos    []ChangeInfo
+}
+
+func newMockHistoryStore(versions ...historyConfig) *mockHistoryStore {
+	s := &mockHistoryStore{}
+	s.loadFunc = func(ctx context.Context) (historyConfig, error) {
+		return s.versions[len(s.versions)-1], nil
+	}
+	s.saveFunc = func(ctx context.Context, config historyConfig) error {
+		s.versions = append(s.versions, config)
+		s.infos = append(s.infos, ChangeInfoFromContext(ctx))
+		return nil
+	}
+	for _, v := range versions {
+		_ = s.Save(context.Background(), v)
+	}
+	return s
+}
+
+func (s *mockHistoryStore) ListVersions(ctx context.Context) ([]*ConfigVersion, error) {
+	var versions []*ConfigVersion
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		versions = append(versions, &ConfigVersion{Version: i + 1, Author: s.infos[i].Author, Reason: s.infos[i].Reason, CreatedAt: time.Unix(int64(i), 0).UTC()})
+	}
+	return versions, nil
+}
+
+func (s *mockHistoryStore) LoadVersion(ctx context.Context, version int) (historyConfig, error) {
+	if version < 1 || version > len(s.versions) {
+		return historyConfig{}, gorm.ErrRecordNotFound
+	}
+	return s.versions[version-1], nil
+}
+
+func (s *mockHistoryStore) DiffVersions(ctx context.Context, from, to int) ([]jsonx.Difference, error) {
+	fromConfig, err := s.LoadVersion(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toConfig, err := s.LoadVersion(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	return jsonx.Diff(fromConfig, toConfig)
+}
+
+func (s *mockHistoryStore) Rollback(ctx context.Context, version int) error {
+	config, err := s.LoadVersion(ctx, version)
+	if err != nil {
+		return err
+	}
+	return s.Save(ctx, config)
+}
+
+func TestConfigAPI_History(t *testing.T) {
+	store := newMockHistoryStore(historyConfig{Port: 80}, historyConfig{Port: 8080, Beta: true})
+	cp := NewConfigProvider[historyConfig](store)
+	api := NewConfigAPI[historyConfig](cp, store,
+		WithAPIAuthor[historyConfig](func(r *http.Request) string { return r.Header.Get("X-User") }),
+		WithAPIValidator[historyConfig](&mockValidator{validateFunc: func(ctx context.Context, v any) error {
+			if v.(historyConfig).Port < 1024 {
+				return errors.New("port must not be privileged")
+			}
+			return nil
+		}}),
+	)
+
+	t.Run("update with author and reason", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(`{"port": 9090}`))
+		req.Header.Set("X-User", "alice")
+		req.Header.Set(ChangeReasonHeader, "move port")
+		w := httptest.NewRecorder()
+		api.UpdateConfig(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ChangeInfo{Author: "alice", Reason: "move port"}, store.infos[2])
+	})
+
+	t.Run("list versions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		api.ListVersions(w, httptest.NewRequest(http.MethodGet, "/api/config/versions", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var versions []*ConfigVersion
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &versions))
+		require.Len(t, versions, 3)
+		assert.Equal(t, 3, versions[0].Version)
+		assert.Equal(t, "alice", versions[0].Author)
+	})
+
+	t.Run("diff versions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		api.DiffVersions(w, httptest.NewRequest(http.MethodGet, "/api/config/diff?from=1&to=2", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"from":1,"to":2,"changes":[
+			{"op":"replace","path":"/beta","old":false,"new":true},
+			{"op":"replace","path":"/port","old":80,"new":8080}
+		]}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		api.DiffVersions(w, httptest.NewRequest(http.MethodGet, "/api/config/diff?from=1", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = httptest.NewRecorder()
+		api.DiffVersions(w, httptest.NewRequest(http.MethodGet, "/api/config/diff?from=1&to=9", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		// Version 1 doesn't pass the current validation
+		w := httptest.NewRecorder()
+		api.RollbackConfig(w, httptest.NewRequest(http.MethodPost, "/api/config/rollback", strings.NewReader(`{"version": 1}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Len(t, store.versions, 3)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/config/rollback", strings.NewReader(`{"version": 2, "reason": "port 9090 is blocked"}`))
+		req.Header.Set("X-User", "bob")
+		w = httptest.NewRecorder()
+		api.RollbackConfig(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, historyConfig{Port: 8080, Beta: true}, store.versions[3])
+		assert.Equal(t, ChangeInfo{Author: "bob", Reason: "port 9090 is blocked"}, store.infos[3])
+
+		config, err := cp.Get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 8080, config.Port)
+	})
+
+	t.Run("store without history", func(t *testing.T) {
+		plain := &mockStore[historyConfig]{}
+		api := NewConfigAPI[historyConfig](NewConfigProvider[historyConfig](plain), plain)
+		w := httptest.NewRecorder()
+		api.ListVersions(w, httptest.NewRequest(http.MethodGet, "/api/config/versions", nil))
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+}
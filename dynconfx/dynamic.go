@@ -2,6 +2,7 @@ package dynconfx
 
 import (
 	"context"
+	"io/fs"
 	"sync"
 	"time"
 
@@ -23,6 +24,19 @@ type ConfigProvider[T any] struct {
 	validator    Validator
 	defaultValue *T
 	sf           singleflight.Group
+
+	// pollInterval and version are used by Run to propagate changes
+	pollInterval time.Duration
+	version      string
+
+	// subMu guards the subscriptions, the last loaded value and the changes pending delivery,
+	// it is locked after mu when both are held
+	subMu         sync.Mutex
+	subscriptions []*subscription[T]
+	last          T
+	hasLast       bool
+	pending       []Change[T]
+	delivering    bool
 }
 
 // ConfigProviderOption is a function that configures a ConfigProvider.
@@ -57,8 +71,9 @@ func WithDefault[T any](defaultValue T) ConfigProviderOption[T] {
 // NewConfigProvider creates a new ConfigProvider instance with the provided store and options.
 func NewConfigProvider[T any](store ConfigStore[T], opts ...ConfigProviderOption[T]) *ConfigProvider[T] {
 	cp := &ConfigProvider[T]{
-		store: store,
-		ttl:   5 * time.Minute, // Default TTL
+		store:        store,
+		ttl:          5 * time.Minute, // Default TTL
+		pollInterval: DefaultPollInterval,
 	}
 	for _, opt := range opts {
 		opt(cp)
@@ -126,16 +141,19 @@ func (c *ConfigProvider[T]) Reload(ctx context.Context) (T, error) {
 
 	// Update cache
 	c.mu.Lock()
-	c.cache = config
-	c.cacheSet = true
-	if c.ttl > 0 {
-		c.expiresAt = time.Now().Add(c.ttl)
-	}
+	c.setCache(config)
+	c.enqueue(config)
 	c.mu.Unlock()
 
+	c.deliver(ctx)
 	return config, nil
 }
 
+// Store returns the store the config is loaded from and saved to.
+func (c *ConfigProvider[T]) Store() ConfigStore[T] {
+	return c.store
+}
+
 // Invalidate marks the cache as invalid. Next Get will reload from storage.
 func (c *ConfigProvider[T]) Invalidate() {
 	c.mu.Lock()
@@ -147,9 +165,14 @@ func (c *ConfigProvider[T]) Invalidate() {
 // then updates the cache with the new value.
 func (c *ConfigProvider[T]) Update(ctx context.Context, updateFunc func(T) T) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	config, loaded, err := c.updateWithStore(ctx, c.store, updateFunc)
+	if loaded {
+		c.enqueue(config)
+	}
+	c.mu.Unlock()
 
-	return c.updateWithStore(ctx, c.store, updateFunc)
+	c.deliver(ctx)
+	return err
 }
 
 // TxUpdater is an interface for stores that support transactions.
@@ -161,6 +184,10 @@ type TxUpdater[T any] interface {
 // The underlying store must implement TxUpdater interface (e.g., EncryptedConfigStore).
 // This allows the config update to participate in an external transaction.
 //
+// The update may still be rolled back, so it is neither cached nor delivered to the subscribers:
+// the cache is invalidated, and the subscribers are notified once the committed config is loaded,
+// e.g. by Run when the store notifies the change on commit, or by the next Get or Reload.
+//
 // Example:
 //
 //	err := db.Transaction(func(tx *gorm.DB) error {
@@ -171,23 +198,26 @@ type TxUpdater[T any] interface {
 //	    return nil
 //	})
 func (c *ConfigProvider[T]) UpdateWithTx(ctx context.Context, tx *gorm.DB, updateFunc func(T) T) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	// Check if store supports transactions
 	txStore, ok := c.store.(TxUpdater[T])
 	if !ok {
 		return errors.New("underlying store does not support transactions")
 	}
 
-	return c.updateWithStore(ctx, txStore.WithTx(tx), updateFunc)
+	if err := txStore.WithTx(tx).Update(ctx, updateFunc); err != nil {
+		return err
+	}
+	c.Invalidate()
+	return nil
 }
 
-// updateWithStore is the common implementation for Update and UpdateWithTx.
+// updateWithStore applies the update of Update and reloads the config.
+// It returns the updated config and whether it was loaded.
 // Caller must hold c.mu lock.
-func (c *ConfigProvider[T]) updateWithStore(ctx context.Context, store ConfigStore[T], updateFunc func(T) T) error {
+func (c *ConfigProvider[T]) updateWithStore(ctx context.Context, store ConfigStore[T], updateFunc func(T) T) (T, bool, error) {
+	var zero T
 	if err := store.Update(ctx, updateFunc); err != nil {
-		return err
+		return zero, false, err
 	}
 
 	// Reload from store to get the updated config
@@ -195,17 +225,23 @@ func (c *ConfigProvider[T]) updateWithStore(ctx context.Context, store ConfigSto
 	if err != nil {
 		// Invalidate cache on error so next Get will reload
 		c.cacheSet = false
-		return nil
+		return zero, false, nil
 	}
 
 	// Update cache
+	c.setCache(config)
+
+	return config, true, nil
+}
+
+// setCache caches config until the TTL expires.
+// Caller must hold c.mu lock.
+func (c *ConfigProvider[T]) setCache(config T) {
 	c.cache = config
 	c.cacheSet = true
 	if c.ttl > 0 {
 		c.expiresAt = time.Now().Add(c.ttl)
 	}
-
-	return nil
 }
 
 // getCached returns cached config if it's valid (set and not expired).
@@ -259,13 +295,11 @@ func (c *ConfigProvider[T]) load(ctx context.Context) (T, error) {
 
 		// Update cache
 		c.mu.Lock()
-		c.cache = config
-		c.cacheSet = true
-		if c.ttl > 0 {
-			c.expiresAt = time.Now().Add(c.ttl)
-		}
+		c.setCache(config)
+		c.enqueue(config)
 		c.mu.Unlock()
 
+		c.deliver(ctx)
 		return config, nil
 	})
 
@@ -277,11 +311,7 @@ func (c *ConfigProvider[T]) load(ctx context.Context) (T, error) {
 	if shared {
 		// Another goroutine already loaded, update our cache from the result
 		c.mu.Lock()
-		c.cache = result.(T)
-		c.cacheSet = true
-		if c.ttl > 0 {
-			c.expiresAt = time.Now().Add(c.ttl)
-		}
+		c.setCache(result.(T))
 		c.mu.Unlock()
 	}
 
@@ -309,6 +339,7 @@ func (c *ConfigProvider[T]) loadWithoutCache(ctx context.Context) (T, error) {
 		}
 	}
 
+	c.observe(ctx, config)
 	return config, nil
 }
 
@@ -329,7 +360,7 @@ func isNotFoundError(err error) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, gorm.ErrRecordNotFound) {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, fs.ErrNotExist) {
 		return true
 	}
 	// Check for common not found error patterns
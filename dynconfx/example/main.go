@@ -30,9 +30,7 @@ func main() {
 	//    import "github.com/qor5/kx"
 	//
 	//    registry, _ := kx.NewRegistry()
-	//    registry.MustRegisterStruct(&AppConfig{},
-	//        kx.WithRegularField("OAuth2ClientSecret", false),
-	//    )
+	//    dynconfx.MustRegisterSecretFields[*AppConfig](registry, "OAuth2ClientSecret")
 	//
 	// 2. Create kx Manager:
 	//    kxManager, _ := kx.NewManagerByConfig(&kx.Config{
@@ -65,6 +63,12 @@ func main() {
 
 	log.Printf("Loaded config: %+v", config)
 
+	// Propagate the changes made by other instances and log them
+	go dynamicConfig.Run(ctx)
+	dynamicConfig.Subscribe(func(ctx context.Context, change dynconfx.Change[*AppConfig]) {
+		log.Printf("Config changed from %+v to %+v", change.Old, change.New)
+	})
+
 	// Create HTTP API
 	configAPI := dynconfx.NewConfigAPI[*AppConfig](dynamicConfig, store)
 
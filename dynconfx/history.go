@@ -0,0 +1,197 @@
+package dynconfx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/x/v3/jsonx"
+	"gorm.io/gorm"
+)
+
+// ErrHistoryDisabled is returned by the history methods of a store created without WithHistory.
+var ErrHistoryDisabled = errors.New("config history is not enabled")
+
+// HistoryStore is implemented by stores that keep every saved version of the config.
+type HistoryStore[T any] interface {
+	// ListVersions lists the saved versions of the config, newest first.
+	ListVersions(ctx context.Context) ([]*ConfigVersion, error)
+
+	// LoadVersion loads the config of a saved version.
+	LoadVersion(ctx context.Context, version int) (T, error)
+
+	// DiffVersions returns the differences between two saved versions.
+	DiffVersions(ctx context.Context, from, to int) ([]jsonx.Difference, error)
+
+	// Rollback saves the config of a previous version as the new version.
+	Rollback(ctx context.Context, version int) error
+}
+
+// ConfigVersion describes a saved version of a config.
+type ConfigVersion struct {
+	Version   int       `json:"version"`
+	Author    string    `json:"author"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ConfigHistory is the database model for the saved versions of configs.
+type ConfigHistory struct {
+	ID         uint      `gorm:"primaryKey"`
+	Key        string    `gorm:"uniqueIndex:idx_config_histories_key_version;not null"`
+	Version    int       `gorm:"uniqueIndex:idx_config_histories_key_version;not null"`
+	Data       []byte    `gorm:"type:jsonb"` // Config with sensitive fields removed
+	Ciphertext string    `gorm:"type:text"`  // Encrypted sensitive fields
+	Author     string    `gorm:"not null;default:''"`
+	Reason     string    `gorm:"type:text;not null;default:''"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for ConfigHistory.
+func (h *ConfigHistory) TableName() string {
+	return "config_histories"
+}
+
+// ChangeInfo describes who changed a config and why, it is saved with the version of the change.
+type ChangeInfo struct {
+	Author string
+	Reason string
+}
+
+type changeInfoKey struct{}
+
+// WithChangeInfo returns a context carrying the author and reason of the config changes made with it.
+//
+// Example:
+//
+//	ctx = dynconfx.WithChangeInfo(ctx, "alice@example.com", "enable the new checkout")
+//	err := provider.Update(ctx, updateFunc)
+func WithChangeInfo(ctx context.Context, author, reason string) context.Context {
+	return context.WithValue(ctx, changeInfoKey{}, ChangeInfo{Author: author, Reason: reason})
+}
+
+// ChangeInfoFromContext returns the ChangeInfo set by WithChangeInfo.
+func ChangeInfoFromContext(ctx context.Context) ChangeInfo {
+	info, _ := ctx.Value(changeInfoKey{}).(ChangeInfo)
+	return info
+}
+
+// ListVersions lists the saved versions of the config, newest first.
+func (s *EncryptedConfigStore[T]) ListVersions(ctx context.Context) ([]*ConfigVersion, error) {
+	if !s.history {
+		return nil, ErrHistoryDisabled
+	}
+
+	var records []*ConfigHistory
+	err := s.db.WithContext(ctx).
+		Select("version", "author", "reason", "created_at").
+		Where("key = ?", s.configKey).
+		Order("version DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list config versions")
+	}
+
+	versions := make([]*ConfigVersion, 0, len(records))
+	for _, record := range records {
+		versions = append(versions, &ConfigVersion{
+			Version:   record.Version,
+			Author:    record.Author,
+			Reason:    record.Reason,
+			CreatedAt: record.CreatedAt,
+		})
+	}
+	return versions, nil
+}
+
+// LoadVersion loads and decrypts the config of a saved version.
+func (s *EncryptedConfigStore[T]) LoadVersion(ctx context.Context, version int) (T, error) {
+	var zero T
+	record, err := s.loadHistory(ctx, s.db, version)
+	if err != nil {
+		return zero, err
+	}
+	return s.decode(ctx, record.Data, record.Ciphertext)
+}
+
+// DiffVersions returns the differences between two saved versions.
+// Sensitive fields are encrypted and not part of the diff.
+func (s *EncryptedConfigStore[T]) DiffVersions(ctx context.Context, from, to int) ([]jsonx.Difference, error) {
+	fromRecord, err := s.loadHistory(ctx, s.db, from)
+	if err != nil {
+		return nil, err
+	}
+	toRecord, err := s.loadHistory(ctx, s.db, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := jsonx.Diff(json.RawMessage(fromRecord.Data), json.RawMessage(toRecord.Data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff config versions")
+	}
+	return diffs, nil
+}
+
+// Rollback saves the config of a previous version as the new version.
+// The reason defaults to "rollback to version N" if the context has none.
+func (s *EncryptedConfigStore[T]) Rollback(ctx context.Context, version int) error {
+	if !s.history {
+		return ErrHistoryDisabled
+	}
+
+	info := ChangeInfoFromContext(ctx)
+	if info.Reason == "" {
+		ctx = WithChangeInfo(ctx, info.Author, fmt.Sprintf("rollback to version %d", version))
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := s.loadHistory(ctx, tx, version)
+		if err != nil {
+			return err
+		}
+		// The ciphertext is bound to the same key and encryption context, so it is saved as is
+		return s.write(ctx, tx, record.Data, record.Ciphertext)
+	})
+}
+
+func (s *EncryptedConfigStore[T]) loadHistory(ctx context.Context, db *gorm.DB, version int) (*ConfigHistory, error) {
+	if !s.history {
+		return nil, ErrHistoryDisabled
+	}
+
+	var record ConfigHistory
+	err := db.WithContext(ctx).Where("key = ? AND version = ?", s.configKey, version).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(err, "config version %d not found for key: %s", version, s.configKey)
+		}
+		return nil, errors.Wrap(err, "failed to load config version")
+	}
+	return &record, nil
+}
+
+// addHistory saves the version of a change, the caller must have locked the config row.
+func (s *EncryptedConfigStore[T]) addHistory(ctx context.Context, tx *gorm.DB, data []byte, ciphertext string) error {
+	var latest int
+	err := tx.Model(&ConfigHistory{}).
+		Where("key = ?", s.configKey).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to load the latest config version")
+	}
+
+	info := ChangeInfoFromContext(ctx)
+	err = tx.Create(&ConfigHistory{
+		Key:        s.configKey,
+		Version:    latest + 1,
+		Data:       data,
+		Ciphertext: ciphertext,
+		Author:     info.Author,
+		Reason:     info.Reason,
+	}).Error
+	return errors.Wrap(err, "failed to save config version")
+}
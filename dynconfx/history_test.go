@@ -0,0 +1,100 @@
+package dynconfx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/qor5/x/v3/gormx"
+)
+
+var suite *gormx.TestSuite
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	suite = gormx.MustStartTestSuite(ctx)
+	defer func() {
+		if err := suite.Stop(context.Background()); err != nil {
+			fmt.Printf("Error during teardown: %v\n", err)
+		}
+	}()
+
+	os.Exit(m.Run())
+}
+
+// newPlainStore creates an EncryptedConfigStore on the test database,
+// without sensitive fields so that no KMS is needed.
+func newPlainStore(t *testing.T, configKey string) *EncryptedConfigStore[*historyConfig] {
+	ctx := context.Background()
+	db := suite.DB()
+	require.NoError(t, db.Migrator().DropTable(&ConfigRecord{}, &ConfigHistory{}))
+	require.NoError(t, AutoMigrate(ctx, db))
+
+	return newEncryptedConfigStore[*historyConfig](db, plainCrypter[*historyConfig]{}, configKey, WithHistory[*historyConfig]())
+}
+
+// plainCrypter encrypts no field.
+type plainCrypter[T any] struct{}
+
+func (plainCrypter[T]) encrypt(ctx context.Context, config T) (T, string, error) {
+	return config, "", nil
+}
+
+func (plainCrypter[T]) decrypt(ctx context.Context, config T, ciphertext string) (T, error) {
+	return config, nil
+}
+
+func TestEncryptedConfigStore_History(t *testing.T) {
+	ctx := context.Background()
+	store := newPlainStore(t, "app_config")
+
+	require.NoError(t, store.Save(WithChangeInfo(ctx, "alice", "initial"), &historyConfig{Port: 8080}))
+	require.NoError(t, store.Update(WithChangeInfo(ctx, "bob", "enable beta"), func(cfg *historyConfig) *historyConfig {
+		cfg.Beta = true
+		return cfg
+	}))
+
+	versions, err := store.ListVersions(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, "bob", versions[0].Author)
+	assert.Equal(t, "enable beta", versions[0].Reason)
+	assert.Equal(t, 1, versions[1].Version)
+	assert.Equal(t, "alice", versions[1].Author)
+	assert.False(t, versions[0].CreatedAt.IsZero())
+
+	v1, err := store.LoadVersion(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &historyConfig{Port: 8080}, v1)
+
+	diffs, err := store.DiffVersions(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Len(t, diffs, 1)
+
+	require.NoError(t, store.Rollback(WithChangeInfo(ctx, "carol", ""), 1))
+	current, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &historyConfig{Port: 8080}, current)
+
+	versions, err = store.ListVersions(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, "carol", versions[0].Author)
+	assert.Equal(t, "rollback to version 1", versions[0].Reason)
+
+	_, err = store.LoadVersion(ctx, 4)
+	assert.Error(t, err)
+
+	// versions are kept per key
+	other := NewEncryptedConfigStore[*historyConfig](suite.DB(), nil, "other_config", nil, WithHistory[*historyConfig]())
+	versions, err = other.ListVersions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
@@ -0,0 +1,241 @@
+package dynconfx
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FieldType is the kind of value of a config field, it decides how the field is edited.
+type FieldType string
+
+const (
+	FieldTypeString   FieldType = "string"
+	FieldTypeInt      FieldType = "int"
+	FieldTypeFloat    FieldType = "float"
+	FieldTypeBool     FieldType = "bool"
+	FieldTypeDuration FieldType = "duration"
+	FieldTypeTime     FieldType = "time"
+	FieldTypeStrings  FieldType = "strings"
+	FieldTypeObject   FieldType = "object"
+	// FieldTypeJSON is used for maps and other values that are edited as JSON
+	FieldTypeJSON FieldType = "json"
+)
+
+// SchemaField describes a field of a config struct.
+type SchemaField struct {
+	// Name is the Go name of the field
+	Name string `json:"name"`
+	// Path is the JSON Pointer (RFC6901) of the field in the JSON encoded config
+	Path string    `json:"path"`
+	Type FieldType `json:"type"`
+	// Usage is read from the usage tag, like confx does
+	Usage    string `json:"usage,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// Secret fields are encrypted by the store and must not be displayed
+	Secret bool `json:"secret,omitempty"`
+	// Options are the allowed values from the oneof validation
+	Options []string `json:"options,omitempty"`
+	// Min and Max are the bounds from the min, max, gte and lte validations
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+	// Fields are the fields of an object
+	Fields []*SchemaField `json:"fields,omitempty"`
+
+	goPath string
+}
+
+// Schema describes the fields of a config struct, derived from its field types, validate and usage tags.
+type Schema struct {
+	Fields []*SchemaField `json:"fields"`
+}
+
+// SchemaOption is a function that configures how a Schema is derived.
+type SchemaOption func(*schemaOptions)
+
+type schemaOptions struct {
+	secrets    []string
+	secretsSet bool
+	ctx        context.Context
+	fielder    SecretFielder
+}
+
+// WithSecretFields marks fields as secrets by their Go names, dotted for nested fields, e.g. "Database.Password".
+// It overrides the secret fields derived by WithStoreSecretFields.
+func WithSecretFields(names ...string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.secrets = append(o.secrets, names...)
+		o.secretsSet = true
+	}
+}
+
+// WithStoreSecretFields marks the fields encrypted by store as secrets, if it implements SecretFielder.
+// For EncryptedConfigStore these are the fields registered with MustRegisterSecretFields.
+func WithStoreSecretFields(ctx context.Context, store any) SchemaOption {
+	return func(o *schemaOptions) {
+		o.ctx = ctx
+		o.fielder, _ = store.(SecretFielder)
+	}
+}
+
+var (
+	typeDuration   = reflect.TypeOf(time.Duration(0))
+	typeTime       = reflect.TypeOf(time.Time{})
+	pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+// NewSchema derives the Schema of the config struct T, which may be a pointer to a struct.
+func NewSchema[T any](opts ...SchemaOption) (*Schema, error) {
+	o := &schemaOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	typ := structType[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.Errorf("config must be a struct, got %s", typ)
+	}
+
+	if !o.secretsSet && o.fielder != nil {
+		secrets, err := o.fielder.SecretFields(o.ctx)
+		if err != nil {
+			return nil, err
+		}
+		o.secrets = secrets
+	}
+	return &Schema{Fields: schemaFields(typ, "", "", false, o)}, nil
+}
+
+// Leaves returns the fields that are not objects, depth first.
+func (s *Schema) Leaves() []*SchemaField {
+	var leaves []*SchemaField
+	var walk func(fields []*SchemaField)
+	walk = func(fields []*SchemaField) {
+		for _, f := range fields {
+			if f.Type == FieldTypeObject {
+				walk(f.Fields)
+				continue
+			}
+			leaves = append(leaves, f)
+		}
+	}
+	walk(s.Fields)
+	return leaves
+}
+
+// FieldByGoPath returns the field with the dotted Go path, e.g. "Server.Port", as reported by validation errors.
+func (s *Schema) FieldByGoPath(goPath string) *SchemaField {
+	var find func(fields []*SchemaField) *SchemaField
+	find = func(fields []*SchemaField) *SchemaField {
+		for _, f := range fields {
+			if f.goPath == goPath {
+				return f
+			}
+			if found := find(f.Fields); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(s.Fields)
+}
+
+func schemaFields(typ reflect.Type, parentPath, parentGoPath string, secret bool, o *schemaOptions) []*SchemaField {
+	var fields []*SchemaField
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := sf.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		// Embedded structs without a JSON name are flattened like encoding/json does
+		if sf.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, schemaFields(fieldType, parentPath, parentGoPath, secret, o)...)
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		goPath := sf.Name
+		if parentGoPath != "" {
+			goPath = parentGoPath + "." + sf.Name
+		}
+
+		field := &SchemaField{
+			Name:   sf.Name,
+			Path:   parentPath + "/" + pointerEscaper.Replace(name),
+			Type:   fieldTypeOf(fieldType),
+			Usage:  strings.TrimSpace(sf.Tag.Get("usage")),
+			Secret: secret || slices.Contains(o.secrets, goPath),
+			goPath: goPath,
+		}
+		parseValidateTag(field, sf.Tag.Get("validate"))
+		if field.Type == FieldTypeObject {
+			field.Fields = schemaFields(fieldType, field.Path, goPath, field.Secret, o)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func fieldTypeOf(typ reflect.Type) FieldType {
+	switch typ {
+	case typeDuration:
+		return FieldTypeDuration
+	case typeTime:
+		return FieldTypeTime
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return FieldTypeString
+	case reflect.Bool:
+		return FieldTypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldTypeInt
+	case reflect.Float32, reflect.Float64:
+		return FieldTypeFloat
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.String {
+			return FieldTypeStrings
+		}
+	case reflect.Struct:
+		return FieldTypeObject
+	}
+	return FieldTypeJSON
+}
+
+// parseValidateTag reads the rules of the validate tag that apply to the field itself.
+func parseValidateTag(field *SchemaField, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "dive":
+			// The following rules apply to the elements
+			return
+		case "required":
+			field.Required = true
+		case "oneof":
+			field.Options = strings.Fields(param)
+		case "min", "gte":
+			field.Min = param
+		case "max", "lte":
+			field.Max = param
+		}
+	}
+}
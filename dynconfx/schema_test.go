@@ -0,0 +1,118 @@
+package dynconfx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qor5/kx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaConfig struct {
+	Name     string            `json:"name" validate:"required" usage:"Name of the app"`
+	Mode     string            `json:"mode" validate:"oneof=dev prod"`
+	Port     int               `json:"port" validate:"gte=1024,lte=65535"`
+	Ratio    float64           `json:"ratio"`
+	Debug    bool              `json:"debug"`
+	Timeout  time.Duration     `json:"timeout"`
+	Hosts    []string          `json:"hosts" validate:"dive,hostname"`
+	Labels   map[string]string `json:"labels"`
+	Database struct {
+		DSN      string `json:"dsn"`
+		Password string `json:"password"`
+	} `json:"database"`
+	Ignored string `json:"-"`
+}
+
+func TestNewSchema(t *testing.T) {
+	schema, err := NewSchema[*schemaConfig](WithSecretFields("Database.Password"))
+	require.NoError(t, err)
+
+	var paths []string
+	types := map[string]FieldType{}
+	for _, f := range schema.Leaves() {
+		paths = append(paths, f.Path)
+		types[f.Path] = f.Type
+	}
+	assert.Equal(t, []string{
+		"/name", "/mode", "/port", "/ratio", "/debug", "/timeout", "/hosts", "/labels",
+		"/database/dsn", "/database/password",
+	}, paths)
+	assert.Equal(t, FieldTypeInt, types["/port"])
+	assert.Equal(t, FieldTypeDuration, types["/timeout"])
+	assert.Equal(t, FieldTypeStrings, types["/hosts"])
+	assert.Equal(t, FieldTypeJSON, types["/labels"])
+
+	name := schema.Fields[0]
+	assert.True(t, name.Required)
+	assert.Equal(t, "Name of the app", name.Usage)
+	assert.Equal(t, []string{"dev", "prod"}, schema.Fields[1].Options)
+	assert.Equal(t, "1024", schema.Fields[2].Min)
+	assert.Equal(t, "65535", schema.Fields[2].Max)
+	assert.False(t, schema.Fields[6].Required, "rules after dive apply to the elements")
+
+	password := schema.FieldByGoPath("Database.Password")
+	require.NotNil(t, password)
+	assert.True(t, password.Secret)
+	assert.False(t, schema.FieldByGoPath("Database.DSN").Secret)
+
+	_, err = NewSchema[string]()
+	assert.Error(t, err)
+}
+
+type secretConfig struct {
+	Name         string `json:"name"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// failingCrypter fails like a KMS being unavailable.
+type failingCrypter[T any] struct{}
+
+func (failingCrypter[T]) encrypt(ctx context.Context, config T) (T, string, error) {
+	return config, "", errors.New("kms unavailable")
+}
+
+func (failingCrypter[T]) decrypt(ctx context.Context, config T, ciphertext string) (T, error) {
+	return config, errors.New("kms unavailable")
+}
+
+func TestNewSchema_StoreSecretFields(t *testing.T) {
+	ctx := context.Background()
+
+	// The secret fields are the registered ones, found without encrypting
+	store := newEncryptedConfigStore[*secretConfig](nil, failingCrypter[*secretConfig]{}, "app_config")
+	fields, err := store.SecretFields(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	registry, err := kx.NewRegistry()
+	require.NoError(t, err)
+	MustRegisterSecretFields[*secretConfig](registry, "ClientSecret")
+	fields, err = store.SecretFields(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ClientSecret"}, fields)
+
+	layered := NewLayeredStore[*secretConfig](NewStaticStore(&secretConfig{}), store)
+	fields, err = layered.SecretFields(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ClientSecret"}, fields)
+
+	schema, err := NewSchema[*secretConfig](WithStoreSecretFields(ctx, layered))
+	require.NoError(t, err)
+	assert.True(t, schema.FieldByGoPath("ClientSecret").Secret)
+	assert.False(t, schema.FieldByGoPath("Name").Secret)
+
+	// The explicit fields override the fields of the store
+	schema, err = NewSchema[*secretConfig](WithStoreSecretFields(ctx, layered), WithSecretFields("Name"))
+	require.NoError(t, err)
+	assert.True(t, schema.FieldByGoPath("Name").Secret)
+	assert.False(t, schema.FieldByGoPath("ClientSecret").Secret)
+
+	// Stores encrypting nothing have no secret fields
+	schema, err = NewSchema[*secretConfig](WithStoreSecretFields(ctx, NewStaticStore(&secretConfig{})))
+	require.NoError(t, err)
+	assert.False(t, schema.FieldByGoPath("ClientSecret").Secret)
+}
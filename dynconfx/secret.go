@@ -0,0 +1,64 @@
+package dynconfx
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/qor5/kx"
+)
+
+// SecretFielder is implemented by stores that know which fields of the config they encrypt.
+type SecretFielder interface {
+	// SecretFields returns the dotted Go names of the encrypted fields, e.g. "Database.Password".
+	SecretFields(ctx context.Context) ([]string, error)
+}
+
+var (
+	_ SecretFielder = (*EncryptedConfigStore[any])(nil)
+	_ SecretFielder = (*LayeredStore[any])(nil)
+)
+
+// secretFields holds the fields registered by MustRegisterSecretFields, by config struct type.
+var secretFields sync.Map
+
+// MustRegisterSecretFields registers the config struct T, which may be a pointer to a struct, with registry,
+// encrypting fields with kx.WithRegularField without hashing. EncryptedConfigStore[T].SecretFields returns them.
+//
+// Example:
+//
+//	registry, _ := kx.NewRegistry()
+//	dynconfx.MustRegisterSecretFields[*AppConfig](registry, "OAuth2ClientSecret")
+func MustRegisterSecretFields[T any](registry *kx.Registry, fields ...string) {
+	typ := structType[T]()
+	registry.MustRegisterStruct(reflect.New(typ).Interface(), regularFields(kx.WithRegularField, fields)...)
+	secretFields.Store(typ, slices.Clone(fields))
+}
+
+// regularFields returns the kx options encrypting fields, whatever the option type of kx is.
+func regularFields[O any](regularField func(name string, hash bool) O, fields []string) []O {
+	opts := make([]O, 0, len(fields))
+	for _, name := range fields {
+		opts = append(opts, regularField(name, false))
+	}
+	return opts
+}
+
+// registeredSecretFields returns the fields of T registered by MustRegisterSecretFields.
+func registeredSecretFields[T any]() []string {
+	fields, ok := secretFields.Load(structType[T]())
+	if !ok {
+		return nil
+	}
+	return slices.Clone(fields.([]string))
+}
+
+// structType returns the type of T, dereferenced.
+func structType[T any]() reflect.Type {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
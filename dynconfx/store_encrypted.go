@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/qor5/kx"
+	"github.com/qor5/x/v3/gormx/postgresx"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -15,12 +16,53 @@ import (
 // EncryptedConfigStore implements ConfigStore with encryption support using kx library.
 // It stores configs in a database with sensitive fields encrypted.
 type EncryptedConfigStore[T any] struct {
-	db                *gorm.DB
+	db        *gorm.DB
+	crypter   structCrypter[T]
+	configKey string
+	history   bool
+}
+
+// structCrypter encrypts the sensitive fields of a config into a ciphertext, removing them from the config,
+// and decrypts them back.
+type structCrypter[T any] interface {
+	encrypt(ctx context.Context, config T) (T, string, error)
+	decrypt(ctx context.Context, config T, ciphertext string) (T, error)
+}
+
+// kxCrypter encrypts the fields registered with kx.
+type kxCrypter[T any] struct {
 	kxManager         *kx.Manager
-	configKey         string
 	encryptionContext map[string]string
 }
 
+func (c kxCrypter[T]) encrypt(ctx context.Context, config T) (T, string, error) {
+	return kx.EncryptStruct(ctx, c.kxManager, config, c.encryptionContext)
+}
+
+func (c kxCrypter[T]) decrypt(ctx context.Context, config T, ciphertext string) (T, error) {
+	return kx.DecryptStruct(ctx, c.kxManager, config, ciphertext, c.encryptionContext)
+}
+
+// EncryptedConfigStoreOption is a function that configures an EncryptedConfigStore.
+type EncryptedConfigStoreOption[T any] func(*EncryptedConfigStore[T])
+
+// WithHistory keeps every saved version of the config in the config_histories table,
+// with the author and reason of the change from WithChangeInfo.
+func WithHistory[T any]() EncryptedConfigStoreOption[T] {
+	return func(s *EncryptedConfigStore[T]) {
+		s.history = true
+	}
+}
+
+var (
+	_ ChangeWatcher     = (*EncryptedConfigStore[any])(nil)
+	_ Versioner         = (*EncryptedConfigStore[any])(nil)
+	_ HistoryStore[any] = (*EncryptedConfigStore[any])(nil)
+)
+
+// NotifyChannel is the Postgres channel notified with the config key whenever a config is saved.
+const NotifyChannel = "dynconfx_configs"
+
 // ConfigRecord is the database model for storing encrypted configs.
 type ConfigRecord struct {
 	Key        string    `gorm:"primaryKey"`
@@ -49,6 +91,12 @@ func (cr ConfigRecord) Value() (driver.Value, error) {
 	return json.Marshal(cr)
 }
 
+// AutoMigrate creates or updates the tables used by EncryptedConfigStore,
+// the configs table and the config_histories table of WithHistory.
+func AutoMigrate(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).AutoMigrate(&ConfigRecord{}, &ConfigHistory{})
+}
+
 // NewEncryptedConfigStore creates a new EncryptedConfigStore instance.
 //
 // Parameters:
@@ -57,15 +105,14 @@ func (cr ConfigRecord) Value() (driver.Value, error) {
 //   - configKey: Unique identifier for this config (used as primary key in DB)
 //   - encryptionContext: Additional context for encryption (e.g., {"app": "myapp"})
 //
-// Note: You need to register your config struct with kx.Registry before using this store.
+// Note: You need to register your config struct with kx.Registry before using this store,
+// with MustRegisterSecretFields so that SecretFields knows the encrypted fields.
 // Example:
 //
 //	import "github.com/qor5/kx"
 //
 //	registry, _ := kx.NewRegistry()
-//	registry.MustRegisterStruct(&AppConfig{},
-//	    kx.WithRegularField("OAuth2ClientSecret", false),
-//	)
+//	dynamic.MustRegisterSecretFields[*AppConfig](registry, "OAuth2ClientSecret")
 //
 //	kxManager, _ := kx.NewManagerByConfig(&kx.Config{
 //	    KMSKeyID: "arn:aws:kms:...",
@@ -77,19 +124,34 @@ func (cr ConfigRecord) Value() (driver.Value, error) {
 //	    kxManager,
 //	    "app_config",
 //	    map[string]string{"app": "myapp"},
+//	    dynamic.WithHistory[*AppConfig](),
 //	)
 func NewEncryptedConfigStore[T any](
 	db *gorm.DB,
 	kxManager *kx.Manager,
 	configKey string,
 	encryptionContext map[string]string,
+	opts ...EncryptedConfigStoreOption[T],
 ) *EncryptedConfigStore[T] {
-	return &EncryptedConfigStore[T]{
-		db:                db,
-		kxManager:         kxManager,
-		configKey:         configKey,
-		encryptionContext: encryptionContext,
+	crypter := kxCrypter[T]{kxManager: kxManager, encryptionContext: encryptionContext}
+	return newEncryptedConfigStore(db, crypter, configKey, opts...)
+}
+
+func newEncryptedConfigStore[T any](
+	db *gorm.DB,
+	crypter structCrypter[T],
+	configKey string,
+	opts ...EncryptedConfigStoreOption[T],
+) *EncryptedConfigStore[T] {
+	s := &EncryptedConfigStore[T]{
+		db:        db,
+		crypter:   crypter,
+		configKey: configKey,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Load loads and decrypts config from the database.
@@ -106,15 +168,22 @@ func (s *EncryptedConfigStore[T]) Load(ctx context.Context) (T, error) {
 		return zero, errors.Wrap(result.Error, "failed to load config from database")
 	}
 
+	return s.decode(ctx, record.Data, record.Ciphertext)
+}
+
+// decode unmarshals the stored data and decrypts its sensitive fields.
+func (s *EncryptedConfigStore[T]) decode(ctx context.Context, data []byte, ciphertext string) (T, error) {
+	var zero T
+
 	// Unmarshal data to config struct
 	var config T
-	if err := json.Unmarshal(record.Data, &config); err != nil {
+	if err := json.Unmarshal(data, &config); err != nil {
 		return zero, errors.Wrap(err, "failed to unmarshal config data")
 	}
 
 	// Decrypt sensitive fields if ciphertext exists
-	if record.Ciphertext != "" {
-		decrypted, err := kx.DecryptStruct(ctx, s.kxManager, config, record.Ciphertext, s.encryptionContext)
+	if ciphertext != "" {
+		decrypted, err := s.crypter.decrypt(ctx, config, ciphertext)
 		if err != nil {
 			return zero, errors.Wrap(err, "failed to decrypt config")
 		}
@@ -128,7 +197,7 @@ func (s *EncryptedConfigStore[T]) Load(ctx context.Context) (T, error) {
 // This replaces the entire config.
 func (s *EncryptedConfigStore[T]) Save(ctx context.Context, config T) error {
 	// Encrypt sensitive fields
-	encryptedObj, ciphertext, err := kx.EncryptStruct(ctx, s.kxManager, config, s.encryptionContext)
+	encryptedObj, ciphertext, err := s.crypter.encrypt(ctx, config)
 	if err != nil {
 		return errors.Wrap(err, "failed to encrypt config")
 	}
@@ -139,6 +208,17 @@ func (s *EncryptedConfigStore[T]) Save(ctx context.Context, config T) error {
 		return errors.Wrap(err, "failed to marshal config to JSON")
 	}
 
+	if !s.history {
+		return s.write(ctx, s.db, data, ciphertext)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.write(ctx, tx, data, ciphertext)
+	})
+}
+
+// write saves the encrypted config and its version, then notifies the other instances.
+// With history enabled, db must be a transaction.
+func (s *EncryptedConfigStore[T]) write(ctx context.Context, db *gorm.DB, data []byte, ciphertext string) error {
 	// Prepare database record
 	record := ConfigRecord{
 		Key:        s.configKey,
@@ -146,8 +226,8 @@ func (s *EncryptedConfigStore[T]) Save(ctx context.Context, config T) error {
 		Ciphertext: ciphertext,
 	}
 
-	// Save or update record
-	result := s.db.WithContext(ctx).
+	// Save or update record, which also locks it until the transaction ends
+	result := db.WithContext(ctx).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "key"}},
 			DoUpdates: clause.AssignmentColumns([]string{"data", "ciphertext", "updated_at"}),
@@ -158,9 +238,46 @@ func (s *EncryptedConfigStore[T]) Save(ctx context.Context, config T) error {
 		return errors.Wrap(result.Error, "failed to save config to database")
 	}
 
+	if s.history {
+		if err := s.addHistory(ctx, db.WithContext(ctx), data, ciphertext); err != nil {
+			return err
+		}
+	}
+
+	// Notify the other instances, within a transaction it is delivered on commit
+	if postgresx.IsPostgres(db) {
+		if err := postgresx.Notify(ctx, db, NotifyChannel, s.configKey); err != nil {
+			return errors.Wrap(err, "failed to notify config change")
+		}
+	}
+
 	return nil
 }
 
+// WatchChanges listens to the changes of the config with Postgres LISTEN/NOTIFY.
+// It returns ErrWatchNotSupported for other databases.
+func (s *EncryptedConfigStore[T]) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	if !postgresx.IsPostgres(s.db) {
+		return ErrWatchNotSupported
+	}
+	err := postgresx.Listen(ctx, s.db, NotifyChannel, onReady, func(payload string) {
+		if payload == s.configKey {
+			onChange()
+		}
+	})
+	return errors.Wrap(err, "failed to listen for config changes")
+}
+
+// Version returns the update time of the config, so polling doesn't decrypt unchanged configs.
+func (s *EncryptedConfigStore[T]) Version(ctx context.Context) (string, error) {
+	var record ConfigRecord
+	err := s.db.WithContext(ctx).Select("updated_at").Where("key = ?", s.configKey).First(&record).Error
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load config version for key: %s", s.configKey)
+	}
+	return record.UpdatedAt.Format(time.RFC3339Nano), nil
+}
+
 // WithTx returns a new EncryptedConfigStore that uses the given transaction.
 // This allows the store to participate in an external transaction.
 // Returns ConfigStore[T] to satisfy the TxUpdater interface.
@@ -177,10 +294,10 @@ func (s *EncryptedConfigStore[T]) Save(ctx context.Context, config T) error {
 //	})
 func (s *EncryptedConfigStore[T]) WithTx(tx *gorm.DB) ConfigStore[T] {
 	return &EncryptedConfigStore[T]{
-		db:                tx,
-		kxManager:         s.kxManager,
-		configKey:         s.configKey,
-		encryptionContext: s.encryptionContext,
+		db:        tx,
+		crypter:   s.crypter,
+		configKey: s.configKey,
+		history:   s.history,
 	}
 }
 
@@ -213,3 +330,9 @@ func (s *EncryptedConfigStore[T]) Update(ctx context.Context, updateFunc func(T)
 	// Save updated config
 	return s.Save(ctx, updatedConfig)
 }
+
+// SecretFields returns the fields of T registered with MustRegisterSecretFields.
+// They are known without encrypting anything, so no KMS is called.
+func (s *EncryptedConfigStore[T]) SecretFields(ctx context.Context) ([]string, error) {
+	return registeredSecretFields[T](), nil
+}
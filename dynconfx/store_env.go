@@ -0,0 +1,146 @@
+package dynconfx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/confx"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvStore implements ConfigStore for environment variables.
+// The variables are named like confx.Initialize names them, e.g. APP_SERVER_PORT for the field
+// tagged `confx:"port"` of the field tagged `confx:"server"` with the prefix "APP_".
+// Save and Update operations return an error since the environment is read-only.
+//
+// Example:
+//
+//	store, err := dynconfx.NewEnvStore[*AppConfig]("APP_")
+type EnvStore[T any] struct {
+	tagName string
+	fields  []envField
+}
+
+// envField is a config field read from an environment variable.
+type envField struct {
+	path []string // Keys of the field, lowercase like viper's
+	env  string
+}
+
+// EnvStoreOption is a function that configures an EnvStore.
+type EnvStoreOption[T any] func(*EnvStore[T])
+
+// WithEnvTagName sets the struct tag used to name the environment variables of the config fields.
+func WithEnvTagName[T any](tagName string) EnvStoreOption[T] {
+	return func(s *EnvStore[T]) {
+		s.tagName = tagName
+	}
+}
+
+// NewEnvStore creates a new EnvStore reading the environment variables starting with prefix.
+// It returns an error if T has fields confx can't read from environment variables.
+func NewEnvStore[T any](prefix string, opts ...EnvStoreOption[T]) (*EnvStore[T], error) {
+	s := &EnvStore[T]{
+		tagName: confx.DefaultTagName,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// The loader is never called, initializing only collects the variable names
+	options := []confx.Option{
+		confx.WithTagName(s.tagName),
+		confx.WithFlagSet(pflag.NewFlagSet("dynconfx", pflag.ContinueOnError)),
+		confx.WithViper(viper.New()),
+		confx.WithFieldHook(func(f *confx.Field) (*confx.Field, error) {
+			s.fields = append(s.fields, envField{
+				path: strings.Split(strings.ToLower(f.ViperKey), "."),
+				env:  f.EnvKey,
+			})
+			return f, nil
+		}),
+	}
+	if prefix != "" {
+		options = append(options, confx.WithEnvPrefix(prefix))
+	}
+	var def T
+	if _, err := confx.Initialize(def, options...); err != nil {
+		return nil, errors.Wrap(err, "failed to collect environment variables")
+	}
+	return s, nil
+}
+
+// Load reads the config from the environment variables, fields without variables are left zero.
+func (s *EnvStore[T]) Load(ctx context.Context) (T, error) {
+	var config T
+	if err := s.loadInto(ctx, &config); err != nil {
+		var zero T
+		return zero, err
+	}
+	return config, nil
+}
+
+// Save returns an error because the environment is read-only.
+func (s *EnvStore[T]) Save(ctx context.Context, config T) error {
+	return errors.New("env config store is read-only")
+}
+
+// Update returns an error because the environment is read-only.
+func (s *EnvStore[T]) Update(ctx context.Context, updateFunc func(T) T) error {
+	return errors.New("env config store is read-only")
+}
+
+// loadInto decodes the fields whose environment variables are set into config.
+func (s *EnvStore[T]) loadInto(ctx context.Context, config *T) error {
+	settings := map[string]any{}
+	for _, field := range s.fields {
+		value, ok := os.LookupEnv(field.env)
+		if !ok {
+			continue
+		}
+		m := settings
+		for _, key := range field.path[:len(field.path)-1] {
+			child, ok := m[key].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				m[key] = child
+			}
+			m = child
+		}
+		m[field.path[len(field.path)-1]] = value
+	}
+
+	if err := decodeSettings(s.tagName, settings, config); err != nil {
+		return errors.Wrap(err, "failed to decode environment variables")
+	}
+	return nil
+}
+
+// WatchChanges only reports it is ready, the environment of a process doesn't change.
+func (s *EnvStore[T]) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	onReady()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Version returns a hash of the environment variables of the config.
+func (s *EnvStore[T]) Version(ctx context.Context) (string, error) {
+	h := sha256.New()
+	for _, field := range s.fields {
+		if value, ok := os.LookupEnv(field.env); ok {
+			h.Write([]byte(field.env + "=" + value + "\x00"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var (
+	_ ConfigStore[any] = (*EnvStore[any])(nil)
+	_ ChangeWatcher    = (*EnvStore[any])(nil)
+	_ Versioner        = (*EnvStore[any])(nil)
+)
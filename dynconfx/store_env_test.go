@@ -0,0 +1,40 @@
+package dynconfx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvStore_Load(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("APP_NAME", "app")
+	t.Setenv("APP_TIMEOUT", "3s")
+	t.Setenv("APP_HOSTS", "a,b")
+	t.Setenv("APP_LABELS", "env=prod,team=core")
+	t.Setenv("APP_SERVER_PORT", "9090")
+
+	store, err := NewEnvStore[*layeredConfig]("APP_")
+	require.NoError(t, err)
+
+	config, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.False(t, config.Debug)
+	assert.Equal(t, 3*time.Second, config.Timeout)
+	assert.Equal(t, []string{"a", "b"}, config.Hosts)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, config.Labels)
+	assert.Equal(t, 9090, config.Server.Port)
+
+	version, err := store.Version(ctx)
+	require.NoError(t, err)
+	t.Setenv("APP_DEBUG", "true")
+	changed, err := store.Version(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, version, changed)
+
+	assert.ErrorContains(t, store.Save(ctx, config), "read-only")
+}
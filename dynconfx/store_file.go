@@ -0,0 +1,153 @@
+package dynconfx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/qor5/confx"
+	"github.com/spf13/viper"
+)
+
+// configMapDataLink is the symlink Kubernetes swaps atomically when a mounted ConfigMap changes.
+const configMapDataLink = "..data"
+
+// FileStore implements ConfigStore for a YAML or JSON file, the format is detected from the file extension.
+// Fields are decoded by their confx tags like confx.Initialize does, or by the tag set with WithFileTagName.
+// Save and Update operations return an error since the file is managed outside of the application.
+//
+// FileStore implements ChangeWatcher with fsnotify, so ConfigProvider.Run reloads the config as soon as
+// the file is edited or the mounted Kubernetes ConfigMap is updated.
+//
+// Example:
+//
+//	store := dynconfx.NewFileStore[*AppConfig]("/etc/app/config.yaml")
+//	provider := dynconfx.NewConfigProvider(store)
+//	go provider.Run(ctx)
+type FileStore[T any] struct {
+	path    string
+	tagName string
+}
+
+// FileStoreOption is a function that configures a FileStore.
+type FileStoreOption[T any] func(*FileStore[T])
+
+// WithFileTagName sets the struct tag used to map the keys of the file to the config fields.
+func WithFileTagName[T any](tagName string) FileStoreOption[T] {
+	return func(s *FileStore[T]) {
+		s.tagName = tagName
+	}
+}
+
+// NewFileStore creates a new FileStore reading the config from the file at path.
+func NewFileStore[T any](path string, opts ...FileStoreOption[T]) *FileStore[T] {
+	s := &FileStore[T]{
+		path:    path,
+		tagName: confx.DefaultTagName,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Load reads the config from the file, fields missing from the file are left zero.
+// It returns an error wrapping fs.ErrNotExist if the file doesn't exist.
+func (s *FileStore[T]) Load(ctx context.Context) (T, error) {
+	var config T
+	if err := s.loadInto(ctx, &config); err != nil {
+		var zero T
+		return zero, err
+	}
+	return config, nil
+}
+
+// Save returns an error because the file is read-only.
+func (s *FileStore[T]) Save(ctx context.Context, config T) error {
+	return errors.New("file config store is read-only")
+}
+
+// Update returns an error because the file is read-only.
+func (s *FileStore[T]) Update(ctx context.Context, updateFunc func(T) T) error {
+	return errors.New("file config store is read-only")
+}
+
+// loadInto decodes the keys set in the file into config.
+func (s *FileStore[T]) loadInto(ctx context.Context, config *T) error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read config file %q", s.path)
+	}
+
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(s.path), "."))
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return errors.Wrapf(err, "failed to parse config file %q", s.path)
+	}
+	if err := decodeSettings(s.tagName, v.AllSettings(), config); err != nil {
+		return errors.Wrapf(err, "failed to decode config file %q", s.path)
+	}
+	return nil
+}
+
+// WatchChanges watches the directory of the file, so changes are seen whether the file is written in
+// place, replaced by an editor or swapped with the ConfigMap symlink.
+func (s *FileStore[T]) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+		return errors.Wrapf(err, "failed to watch config file %q", s.path)
+	}
+	onReady()
+
+	name := filepath.Base(s.path)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if base := filepath.Base(event.Name); base == name || base == configMapDataLink {
+				onChange()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+			return errors.Wrapf(err, "failed to watch config file %q", s.path)
+		}
+	}
+}
+
+// Version returns the modification time and size of the file, following symlinks.
+func (s *FileStore[T]) Version(ctx context.Context) (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Reload so the missing file is noticed
+			return "missing", nil
+		}
+		return "", errors.Wrapf(err, "failed to stat config file %q", s.path)
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+var (
+	_ ConfigStore[any] = (*FileStore[any])(nil)
+	_ ChangeWatcher    = (*FileStore[any])(nil)
+	_ Versioner        = (*FileStore[any])(nil)
+)
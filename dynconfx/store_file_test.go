@@ -0,0 +1,80 @@
+package dynconfx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type layeredConfig struct {
+	Name    string            `confx:"name"`
+	Debug   bool              `confx:"debug"`
+	Timeout time.Duration     `confx:"timeout"`
+	Hosts   []string          `confx:"hosts"`
+	Labels  map[string]string `confx:"labels"`
+	Server  struct {
+		Host string `confx:"host"`
+		Port int    `confx:"port"`
+	} `confx:"server"`
+}
+
+func TestFileStore_Load(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("name: app\ntimeout: 5s\nhosts: [a, b]\nserver:\n  port: 8080\n"), 0o600))
+	config, err := NewFileStore[layeredConfig](yamlPath).Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, []string{"a", "b"}, config.Hosts)
+	assert.Equal(t, 8080, config.Server.Port)
+
+	jsonPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"debug": true, "server": {"host": "localhost"}}`), 0o600))
+	config, err = NewFileStore[layeredConfig](jsonPath).Load(ctx)
+	require.NoError(t, err)
+	assert.True(t, config.Debug)
+	assert.Equal(t, "localhost", config.Server.Host)
+
+	_, err = NewFileStore[layeredConfig](filepath.Join(dir, "missing.yaml")).Load(ctx)
+	assert.True(t, isNotFoundError(err))
+
+	assert.ErrorContains(t, NewFileStore[layeredConfig](yamlPath).Save(ctx, layeredConfig{}), "read-only")
+}
+
+func TestFileStore_HotReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: v1\n"), 0o600))
+
+	provider := NewConfigProvider[layeredConfig](NewFileStore[layeredConfig](path), WithPollInterval[layeredConfig](time.Hour))
+	config, err := provider.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "v1", config.Name)
+
+	changes := provider.Watch(ctx)
+	go provider.Run(ctx)
+
+	// Replaced like editors and ConfigMap updates do
+	require.Eventually(t, func() bool {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte("name: v2\n"), 0o600))
+		require.NoError(t, os.Rename(tmp, path))
+		select {
+		case change := <-changes:
+			assert.Equal(t, "v2", change.New.Name)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,250 @@
+package dynconfx
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	clone "github.com/huandu/go-clone/generic"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/qor5/confx"
+)
+
+// partialStore is implemented by stores holding only some of the fields of the config,
+// LayeredStore merges them field by field instead of replacing the whole config.
+type partialStore[T any] interface {
+	// loadInto decodes the fields set in the store into config, leaving the other fields untouched.
+	loadInto(ctx context.Context, config *T) error
+}
+
+// decodeSettings decodes the nested settings into config like confx does.
+// Slices and maps are replaced as a whole, structs are merged field by field,
+// also through pointers so a pointer config keeps the fields the settings don't set.
+func decodeSettings[T any](tagName string, settings map[string]any, config *T) error {
+	decoderConfig := &mapstructure.DecoderConfig{
+		Result:           config,
+		WeaklyTypedInput: true,
+	}
+	confx.DecoderConfigOption(tagName)(decoderConfig)
+	decoderConfig.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+		mapstructure.DecodeHookFuncValue(replaceCollections),
+		decoderConfig.DecodeHook,
+	)
+
+	decoder, err := mapstructure.NewDecoder(decoderConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(decoder.Decode(settings))
+}
+
+// replaceCollections empties the slices and maps before the settings are decoded into them,
+// instead of ZeroFields which would also replace the structs behind pointers.
+func replaceCollections(from, to reflect.Value) (any, error) {
+	if to.CanSet() && (to.Kind() == reflect.Slice || to.Kind() == reflect.Map) {
+		to.Set(reflect.Zero(to.Type()))
+	}
+	return from.Interface(), nil
+}
+
+// LayeredStore implements ConfigStore by merging the configs of several stores.
+//
+// Layers are applied in order, later layers take precedence:
+//   - A partial layer (FileStore, EnvStore) overrides only the fields it sets.
+//   - Any other layer (StaticStore, EncryptedConfigStore, ...) holds a whole config and replaces
+//     everything merged from the layers before it.
+//   - A layer whose config is not found is skipped, Load returns a not found error only if no layer has a config.
+//
+// Save and Update are delegated to the last layer holding a whole config, usually the database.
+// Since partial layers above it still take precedence, the values they set can't be changed at runtime.
+//
+// Example:
+//
+//	// Defaults, overridden by the database, overridden by the file and the environment variables
+//	store := dynconfx.NewLayeredStore[*AppConfig](
+//	    dynconfx.NewStaticStore(defaultConfig),
+//	    dbStore,
+//	    dynconfx.NewFileStore[*AppConfig]("/etc/app/config.yaml"),
+//	    envStore,
+//	)
+type LayeredStore[T any] struct {
+	layers []ConfigStore[T]
+}
+
+// NewLayeredStore creates a new LayeredStore with the layers ordered from the lowest to the highest precedence.
+func NewLayeredStore[T any](layers ...ConfigStore[T]) *LayeredStore[T] {
+	return &LayeredStore[T]{
+		layers: layers,
+	}
+}
+
+// Load loads and merges the configs of all layers.
+func (s *LayeredStore[T]) Load(ctx context.Context) (T, error) {
+	var (
+		zero   T
+		merged T
+		found  bool
+		errNF  error
+	)
+	for i, layer := range s.layers {
+		if partial, ok := layer.(partialStore[T]); ok {
+			err := partial.loadInto(ctx, &merged)
+			if err != nil {
+				if isNotFoundError(err) {
+					errNF = err
+					continue
+				}
+				return zero, errors.Wrapf(err, "failed to load config layer %d", i)
+			}
+			found = true
+			continue
+		}
+
+		config, err := layer.Load(ctx)
+		if err != nil {
+			if isNotFoundError(err) {
+				errNF = err
+				continue
+			}
+			return zero, errors.Wrapf(err, "failed to load config layer %d", i)
+		}
+		// Cloned so merging the layers above doesn't modify the value held by the layer
+		merged = clone.Clone(config)
+		found = true
+	}
+
+	if !found {
+		if errNF == nil {
+			return zero, errors.New("layered config store has no layers")
+		}
+		return zero, errNF
+	}
+	return merged, nil
+}
+
+// Save saves config to the last layer holding a whole config.
+func (s *LayeredStore[T]) Save(ctx context.Context, config T) error {
+	layer, err := s.writableLayer()
+	if err != nil {
+		return err
+	}
+	return layer.Save(ctx, config)
+}
+
+// Update updates the config of the last layer holding a whole config.
+// The update function receives the config of that layer, not the merged one.
+func (s *LayeredStore[T]) Update(ctx context.Context, updateFunc func(T) T) error {
+	layer, err := s.writableLayer()
+	if err != nil {
+		return err
+	}
+	return layer.Update(ctx, updateFunc)
+}
+
+// SecretFields returns the secret fields of the layers implementing SecretFielder.
+func (s *LayeredStore[T]) SecretFields(ctx context.Context) ([]string, error) {
+	var names []string
+	for i, layer := range s.layers {
+		fielder, ok := layer.(SecretFielder)
+		if !ok {
+			continue
+		}
+		fields, err := fielder.SecretFields(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the secret fields of config layer %d", i)
+		}
+		for _, name := range fields {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (s *LayeredStore[T]) writableLayer() (ConfigStore[T], error) {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if _, ok := s.layers[i].(partialStore[T]); !ok {
+			return s.layers[i], nil
+		}
+	}
+	return nil, errors.New("layered config store has no layer holding a whole config")
+}
+
+// WatchChanges watches the changes of all layers, onReady is called once all of them are watched.
+// It returns ErrWatchNotSupported if any layer can't push its changes.
+func (s *LayeredStore[T]) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	watchers := make([]ChangeWatcher, 0, len(s.layers))
+	for _, layer := range s.layers {
+		watcher, ok := layer.(ChangeWatcher)
+		if !ok {
+			return ErrWatchNotSupported
+		}
+		watchers = append(watchers, watcher)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		pending = len(watchers)
+	)
+	for _, watcher := range watchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ready := false
+			err := watcher.WatchChanges(ctx, func() {
+				if ready {
+					return
+				}
+				ready = true
+				mu.Lock()
+				pending--
+				allReady := pending == 0
+				mu.Unlock()
+				if allReady {
+					onReady()
+				}
+			}, onChange)
+			// Stop the other layers, the first failure is returned
+			cancel(err)
+		}()
+	}
+	wg.Wait()
+	return context.Cause(ctx)
+}
+
+// Version joins the versions of all layers.
+// A layer without versions makes every version unique, so the config is always reloaded.
+func (s *LayeredStore[T]) Version(ctx context.Context) (string, error) {
+	versions := make([]string, 0, len(s.layers))
+	for i, layer := range s.layers {
+		versioner, ok := layer.(Versioner)
+		if !ok {
+			return strconv.FormatInt(time.Now().UnixNano(), 10), nil
+		}
+		version, err := versioner.Version(ctx)
+		if err != nil {
+			if !isNotFoundError(err) {
+				return "", errors.Wrapf(err, "failed to load version of config layer %d", i)
+			}
+			version = "missing"
+		}
+		versions = append(versions, version)
+	}
+	return strings.Join(versions, "|"), nil
+}
+
+var (
+	_ ConfigStore[any] = (*LayeredStore[any])(nil)
+	_ ChangeWatcher    = (*LayeredStore[any])(nil)
+	_ Versioner        = (*LayeredStore[any])(nil)
+)
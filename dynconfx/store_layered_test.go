@@ -0,0 +1,153 @@
+package dynconfx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLayeredStore(t *testing.T) {
+	ctx := context.Background()
+
+	defaults := layeredConfig{Name: "default", Debug: true, Hosts: []string{"a", "b", "c"}}
+	defaults.Server.Host = "0.0.0.0"
+	defaults.Server.Port = 80
+
+	var saved *layeredConfig
+	db := &mockStore[layeredConfig]{
+		loadFunc: func(ctx context.Context) (layeredConfig, error) {
+			if saved == nil {
+				return layeredConfig{}, gorm.ErrRecordNotFound
+			}
+			return *saved, nil
+		},
+		saveFunc: func(ctx context.Context, config layeredConfig) error {
+			saved = &config
+			return nil
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("debug: false\nhosts: [x]\nserver:\n  port: 8080\n"), 0o600))
+	t.Setenv("LAYERED_SERVER_HOST", "127.0.0.1")
+	env, err := NewEnvStore[layeredConfig]("LAYERED_")
+	require.NoError(t, err)
+
+	store := NewLayeredStore[layeredConfig](NewStaticStore(defaults), db, NewFileStore[layeredConfig](path), env)
+
+	t.Run("file and env override defaults", func(t *testing.T) {
+		config, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "default", config.Name)
+		assert.False(t, config.Debug, "false in the file overrides true")
+		assert.Equal(t, []string{"x"}, config.Hosts, "slices are replaced")
+		assert.Equal(t, 8080, config.Server.Port)
+		assert.Equal(t, "127.0.0.1", config.Server.Host)
+
+		// The default value is not modified by the merge
+		assert.Equal(t, []string{"a", "b", "c"}, defaults.Hosts)
+	})
+
+	t.Run("database replaces defaults", func(t *testing.T) {
+		require.NoError(t, store.Save(ctx, layeredConfig{Name: "db", Debug: true}))
+		require.NotNil(t, saved)
+
+		config, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "db", config.Name)
+		assert.False(t, config.Debug)
+		assert.Equal(t, 8080, config.Server.Port)
+		assert.Equal(t, "127.0.0.1", config.Server.Host)
+	})
+
+	t.Run("version changes with a layer", func(t *testing.T) {
+		store := NewLayeredStore[layeredConfig](NewStaticStore(defaults), NewFileStore[layeredConfig](path), env)
+		version, err := store.Version(ctx)
+		require.NoError(t, err)
+
+		t.Setenv("LAYERED_NAME", "env")
+		changed, err := store.Version(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, version, changed)
+	})
+
+	t.Run("not found in any layer", func(t *testing.T) {
+		store := NewLayeredStore[layeredConfig](NewFileStore[layeredConfig](filepath.Join(t.TempDir(), "missing.yaml")))
+		_, err := store.Load(ctx)
+		assert.True(t, isNotFoundError(err))
+
+		_, err = NewConfigProvider[layeredConfig](store, WithDefault(defaults)).Get(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("hot reload", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		store := NewLayeredStore[layeredConfig](NewStaticStore(defaults), NewFileStore[layeredConfig](path), env)
+		provider := NewConfigProvider[layeredConfig](store, WithPollInterval[layeredConfig](time.Hour))
+		_, err := provider.Get(ctx)
+		require.NoError(t, err)
+
+		changes := provider.Watch(ctx)
+		go provider.Run(ctx)
+
+		require.Eventually(t, func() bool {
+			require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9090\n"), 0o600))
+			select {
+			case change := <-changes:
+				assert.Equal(t, 9090, change.New.Server.Port)
+				assert.True(t, change.New.Debug, "the default is no longer overridden")
+				return true
+			case <-time.After(100 * time.Millisecond):
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("read-only layers", func(t *testing.T) {
+		store := NewLayeredStore[layeredConfig](NewFileStore[layeredConfig](path))
+		assert.Error(t, store.Save(ctx, layeredConfig{}))
+	})
+}
+
+func TestLayeredStore_Pointer(t *testing.T) {
+	ctx := context.Background()
+
+	defaults := &layeredConfig{Name: "default", Debug: true, Hosts: []string{"a", "b", "c"}, Labels: map[string]string{"a": "1"}}
+	defaults.Server.Host = "0.0.0.0"
+	defaults.Server.Port = 80
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("hosts: [x]\nlabels:\n  b: \"2\"\nserver:\n  port: 8080\n"), 0o600))
+	t.Setenv("LAYERED_DEBUG", "false")
+	env, err := NewEnvStore[*layeredConfig]("LAYERED_")
+	require.NoError(t, err)
+
+	store := NewLayeredStore[*layeredConfig](NewStaticStore(defaults), NewFileStore[*layeredConfig](path), env)
+	config, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", config.Name, "fields not set by the file are kept")
+	assert.False(t, config.Debug)
+	assert.Equal(t, "0.0.0.0", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+	assert.Equal(t, []string{"x"}, config.Hosts, "slices are replaced")
+	assert.Equal(t, map[string]string{"b": "2"}, config.Labels, "maps are replaced")
+
+	// The default value is not modified by the merge
+	assert.Equal(t, 80, defaults.Server.Port)
+	assert.Equal(t, []string{"a", "b", "c"}, defaults.Hosts)
+	assert.True(t, defaults.Debug)
+
+	// Without a whole config below, the partial layers start from zero
+	config, err = NewLayeredStore[*layeredConfig](NewFileStore[*layeredConfig](path)).Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, config.Name)
+	assert.Equal(t, 8080, config.Server.Port)
+}
@@ -50,3 +50,15 @@ func (s *StaticStore[T]) Save(ctx context.Context, config T) error {
 func (s *StaticStore[T]) Update(ctx context.Context, updateFunc func(T) T) error {
 	return errors.New("static config store is read-only")
 }
+
+// WatchChanges only reports it is ready, static config never changes.
+func (s *StaticStore[T]) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	onReady()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Version returns a constant version, static config never changes.
+func (s *StaticStore[T]) Version(ctx context.Context) (string, error) {
+	return "static", nil
+}
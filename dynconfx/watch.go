@@ -0,0 +1,266 @@
+package dynconfx
+
+import (
+	"cmp"
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPollInterval is the default interval Run polls the store at while changes can't be watched.
+const DefaultPollInterval = time.Second
+
+// ErrWatchNotSupported is returned by ChangeWatcher when the store's backend can't push changes.
+var ErrWatchNotSupported = errors.New("watching changes is not supported")
+
+// Change is a change of the config, delivered to subscribers.
+type Change[T any] struct {
+	Old T
+	New T
+}
+
+// ChangeWatcher is implemented by stores that can push the changes made by any instance.
+type ChangeWatcher interface {
+	// WatchChanges calls onChange for each change of the config until ctx is done or watching fails.
+	// onReady is called once watching started, changes made before it may have been missed.
+	// It returns ErrWatchNotSupported if the backend can't push changes.
+	WatchChanges(ctx context.Context, onReady func(), onChange func()) error
+}
+
+// Versioner is implemented by stores that can cheaply report the version of the config,
+// polling only reloads the config when its version changed.
+type Versioner interface {
+	Version(ctx context.Context) (string, error)
+}
+
+// WithPollInterval sets the interval Run polls the store at while changes can't be watched.
+func WithPollInterval[T any](interval time.Duration) ConfigProviderOption[T] {
+	return func(cp *ConfigProvider[T]) {
+		cp.pollInterval = interval
+	}
+}
+
+// subscription is a subscriber of the changes of a ConfigProvider.
+type subscription[T any] struct {
+	fn func(ctx context.Context, change Change[T])
+}
+
+// Subscribe registers fn to be called with the old and new values whenever a loaded config
+// differs from the previously loaded one. Calls are made in order by the goroutine that loaded
+// the config, so fn should return quickly.
+// Changes made by other instances are only loaded while Run is running.
+//
+// The returned function unsubscribes fn.
+func (c *ConfigProvider[T]) Subscribe(fn func(ctx context.Context, change Change[T])) (unsubscribe func()) {
+	sub := &subscription[T]{fn: fn}
+
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.subscriptions = append(c.subscriptions, sub)
+
+	return func() {
+		c.subMu.Lock()
+		defer c.subMu.Unlock()
+		for i, s := range c.subscriptions {
+			if s == sub {
+				c.subscriptions = append(c.subscriptions[:i:i], c.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Watch returns a channel receiving the changes of the config until ctx is done.
+// Changes are queued, so a slow receiver never blocks the loading of the config.
+//
+// Example:
+//
+//	go provider.Run(ctx)
+//	for change := range provider.Watch(ctx) {
+//	    log.Printf("feature toggled from %v to %v", change.Old.Enabled, change.New.Enabled)
+//	}
+func (c *ConfigProvider[T]) Watch(ctx context.Context) <-chan Change[T] {
+	var (
+		mu      sync.Mutex
+		pending []Change[T]
+		signal  = make(chan struct{}, 1)
+		out     = make(chan Change[T])
+	)
+
+	unsubscribe := c.Subscribe(func(_ context.Context, change Change[T]) {
+		mu.Lock()
+		pending = append(pending, change)
+		mu.Unlock()
+		select {
+		case signal <- struct{}{}:
+		default:
+		}
+	})
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		for {
+			mu.Lock()
+			if len(pending) == 0 {
+				mu.Unlock()
+				select {
+				case <-ctx.Done():
+					return
+				case <-signal:
+				}
+				continue
+			}
+			change := pending[0]
+			pending = pending[1:]
+			mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- change:
+			}
+		}
+	}()
+	return out
+}
+
+// Run propagates the changes of the config made by any instance until ctx is done.
+// If the store implements ChangeWatcher, changes are pushed to the provider and the store is
+// only polled while watching is unavailable, otherwise the store is polled every poll interval.
+// It's intended to be started in its own goroutine.
+func (c *ConfigProvider[T]) Run(ctx context.Context) error {
+	if c.store == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	var watching atomic.Bool
+	if watcher, ok := c.store.(ChangeWatcher); ok {
+		go c.watch(ctx, watcher, &watching)
+	}
+
+	ticker := time.NewTicker(cmp.Or(c.pollInterval, DefaultPollInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !watching.Load() {
+				c.refresh(ctx)
+			}
+		}
+	}
+}
+
+// watch watches the changes of the store, reconnecting after failures until ctx is done.
+func (c *ConfigProvider[T]) watch(ctx context.Context, watcher ChangeWatcher, watching *atomic.Bool) {
+	for ctx.Err() == nil {
+		err := watcher.WatchChanges(ctx, func() {
+			watching.Store(true)
+			// Changes may have been missed while not watching
+			c.refresh(ctx)
+		}, func() {
+			c.refresh(ctx)
+		})
+		watching.Store(false)
+		if errors.Is(err, ErrWatchNotSupported) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cmp.Or(c.pollInterval, DefaultPollInterval)):
+		}
+	}
+}
+
+// refresh reloads the config if its version changed, or always if the store has no versions.
+// Failures are ignored, the config is refreshed again on the next change or poll.
+func (c *ConfigProvider[T]) refresh(ctx context.Context) {
+	var version string
+	if versioner, ok := c.store.(Versioner); ok {
+		var err error
+		if version, err = versioner.Version(ctx); err != nil {
+			return
+		}
+		c.mu.RLock()
+		unchanged := c.version == version
+		c.mu.RUnlock()
+		if unchanged {
+			return
+		}
+	}
+
+	config, err := c.store.Load(ctx)
+	if err != nil {
+		return
+	}
+	if c.validator != nil {
+		if err := c.validator.StructCtx(ctx, config); err != nil {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.version = version
+	if c.ttl > 0 {
+		c.setCache(config)
+	}
+	c.enqueue(config)
+	c.mu.Unlock()
+
+	c.deliver(ctx)
+}
+
+// observe records config as the latest loaded value and notifies the subscribers if it changed.
+func (c *ConfigProvider[T]) observe(ctx context.Context, config T) {
+	c.enqueue(config)
+	c.deliver(ctx)
+}
+
+// enqueue records config as the latest loaded value and queues the change if it changed.
+// Callers updating the cache call it before releasing c.mu, so the changes are queued
+// in the order of the cache updates, then call deliver.
+func (c *ConfigProvider[T]) enqueue(config T) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	old, hadLast := c.last, c.hasLast
+	c.last, c.hasLast = config, true
+	if !hadLast || reflect.DeepEqual(old, config) {
+		return
+	}
+	c.pending = append(c.pending, Change[T]{Old: old, New: config})
+}
+
+// deliver notifies the subscribers of the queued changes.
+// Changes are delivered in order by a single goroutine at a time, so subscribers
+// loading the config themselves don't deadlock.
+func (c *ConfigProvider[T]) deliver(ctx context.Context) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.delivering {
+		return
+	}
+	c.delivering = true
+	for len(c.pending) > 0 {
+		change := c.pending[0]
+		c.pending = c.pending[1:]
+		subscriptions := c.subscriptions
+
+		c.subMu.Unlock()
+		for _, sub := range subscriptions {
+			sub.fn(ctx, change)
+		}
+		c.subMu.Lock()
+	}
+	c.delivering = false
+}
@@ -0,0 +1,304 @@
+package dynconfx
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type watchConfig struct {
+	Enabled bool
+	Limit   int
+}
+
+// changingStore is a store whose config can be changed concurrently, optionally pushing the changes.
+type changingStore struct {
+	mu        sync.Mutex
+	config    watchConfig
+	version   int
+	loadCount atomic.Int32
+	onChange  chan func()
+}
+
+func (s *changingStore) Load(ctx context.Context) (watchConfig, error) {
+	s.loadCount.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config, nil
+}
+
+func (s *changingStore) Save(ctx context.Context, config watchConfig) error {
+	s.mu.Lock()
+	s.config = config
+	s.version++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *changingStore) Update(ctx context.Context, updateFunc func(watchConfig) watchConfig) error {
+	config, _ := s.Load(ctx)
+	return s.Save(ctx, updateFunc(config))
+}
+
+// watchingStore pushes the changes and reports versions.
+type watchingStore struct {
+	*changingStore
+	changes chan struct{}
+}
+
+func (s *watchingStore) Save(ctx context.Context, config watchConfig) error {
+	if err := s.changingStore.Save(ctx, config); err != nil {
+		return err
+	}
+	s.changes <- struct{}{}
+	return nil
+}
+
+func (s *watchingStore) WatchChanges(ctx context.Context, onReady func(), onChange func()) error {
+	onReady()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.changes:
+			onChange()
+		}
+	}
+}
+
+func (s *watchingStore) Version(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strconv.Itoa(s.version), nil
+}
+
+func TestConfigProvider_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	store := &changingStore{config: watchConfig{Limit: 1}}
+	provider := NewConfigProvider[watchConfig](store)
+
+	var changes []Change[watchConfig]
+	unsubscribe := provider.Subscribe(func(ctx context.Context, change Change[watchConfig]) {
+		changes = append(changes, change)
+	})
+
+	// The first load is not a change
+	_, err := provider.Get(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// Reloading an unchanged config is not a change
+	_, err = provider.Reload(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	require.NoError(t, store.Save(ctx, watchConfig{Enabled: true, Limit: 1}))
+	_, err = provider.Reload(ctx)
+	require.NoError(t, err)
+	require.NoError(t, provider.Update(ctx, func(c watchConfig) watchConfig {
+		c.Limit = 2
+		return c
+	}))
+	assert.Equal(t, []Change[watchConfig]{
+		{Old: watchConfig{Limit: 1}, New: watchConfig{Enabled: true, Limit: 1}},
+		{Old: watchConfig{Enabled: true, Limit: 1}, New: watchConfig{Enabled: true, Limit: 2}},
+	}, changes)
+
+	unsubscribe()
+	require.NoError(t, provider.Update(ctx, func(c watchConfig) watchConfig {
+		c.Limit = 3
+		return c
+	}))
+	assert.Len(t, changes, 2)
+}
+
+func TestConfigProvider_SubscribeConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	store := &changingStore{}
+	provider := NewConfigProvider[watchConfig](store)
+	_, err := provider.Get(ctx)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var changes []Change[watchConfig]
+	provider.Subscribe(func(ctx context.Context, change Change[watchConfig]) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, change)
+	})
+
+	const updates = 50
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, provider.Update(ctx, func(c watchConfig) watchConfig {
+				c.Limit++
+				return c
+			}))
+		}()
+	}
+	wg.Wait()
+
+	// The changes are delivered in the order of the updates
+	require.Len(t, changes, updates)
+	for i, change := range changes {
+		assert.Equal(t, i, change.Old.Limit)
+		assert.Equal(t, i+1, change.New.Limit)
+	}
+}
+
+// txStore stages the updates made with a transaction until they are committed or rolled back.
+type txStore struct {
+	*changingStore
+	staged *watchConfig
+}
+
+func (s *txStore) WithTx(tx *gorm.DB) ConfigStore[watchConfig] {
+	return &stagingStore{txStore: s}
+}
+
+func (s *txStore) commit(ctx context.Context) error {
+	defer func() { s.staged = nil }()
+	return s.changingStore.Save(ctx, *s.staged)
+}
+
+type stagingStore struct {
+	*txStore
+}
+
+func (s *stagingStore) Load(ctx context.Context) (watchConfig, error) {
+	if s.staged != nil {
+		return *s.staged, nil
+	}
+	return s.changingStore.Load(ctx)
+}
+
+func (s *stagingStore) Save(ctx context.Context, config watchConfig) error {
+	s.staged = &config
+	return nil
+}
+
+func (s *stagingStore) Update(ctx context.Context, updateFunc func(watchConfig) watchConfig) error {
+	config, _ := s.Load(ctx)
+	return s.Save(ctx, updateFunc(config))
+}
+
+func TestConfigProvider_SubscribeUpdateWithTx(t *testing.T) {
+	ctx := context.Background()
+	store := &txStore{changingStore: &changingStore{config: watchConfig{Limit: 1}}}
+	provider := NewConfigProvider[watchConfig](store)
+
+	var changes []Change[watchConfig]
+	provider.Subscribe(func(ctx context.Context, change Change[watchConfig]) {
+		changes = append(changes, change)
+	})
+	_, err := provider.Get(ctx)
+	require.NoError(t, err)
+
+	update := func(c watchConfig) watchConfig {
+		c.Limit = 2
+		return c
+	}
+
+	// A rolled back update is neither cached nor notified
+	require.NoError(t, provider.UpdateWithTx(ctx, nil, update))
+	assert.Empty(t, changes)
+	store.staged = nil
+	config, err := provider.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, watchConfig{Limit: 1}, config)
+	assert.Empty(t, changes)
+
+	// A committed update is notified once loaded
+	require.NoError(t, provider.UpdateWithTx(ctx, nil, update))
+	assert.Empty(t, changes)
+	require.NoError(t, store.commit(ctx))
+	config, err = provider.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, watchConfig{Limit: 2}, config)
+	assert.Equal(t, []Change[watchConfig]{{Old: watchConfig{Limit: 1}, New: watchConfig{Limit: 2}}}, changes)
+}
+
+func TestConfigProvider_SubscribeGetWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	store := &changingStore{}
+	provider := NewConfigProvider[watchConfig](store, WithTTL[watchConfig](0))
+
+	var got []watchConfig
+	provider.Subscribe(func(ctx context.Context, change Change[watchConfig]) {
+		// Loading the config again from a subscriber must not deadlock
+		config, err := provider.Get(ctx)
+		require.NoError(t, err)
+		got = append(got, config)
+	})
+
+	_, err := provider.Get(ctx)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, watchConfig{Enabled: true}))
+	_, err = provider.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []watchConfig{{Enabled: true}}, got)
+}
+
+func TestConfigProvider_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &changingStore{}
+	provider := NewConfigProvider[watchConfig](store, WithPollInterval[watchConfig](10*time.Millisecond))
+	_, err := provider.Get(ctx)
+	require.NoError(t, err)
+
+	changes := provider.Watch(ctx)
+	go provider.Run(ctx)
+
+	// Changed by another instance, picked up by polling
+	require.NoError(t, store.Save(ctx, watchConfig{Enabled: true}))
+	select {
+	case change := <-changes:
+		assert.Equal(t, Change[watchConfig]{Old: watchConfig{}, New: watchConfig{Enabled: true}}, change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not received")
+	}
+
+	config, err := provider.Get(ctx)
+	require.NoError(t, err)
+	assert.True(t, config.Enabled)
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestConfigProvider_RunWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &watchingStore{changingStore: &changingStore{}, changes: make(chan struct{}, 1)}
+	provider := NewConfigProvider[watchConfig](store, WithPollInterval[watchConfig](10*time.Millisecond))
+
+	changes := provider.Watch(ctx)
+	go provider.Run(ctx)
+	require.Eventually(t, func() bool { return store.loadCount.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, store.Save(ctx, watchConfig{Limit: 10}))
+	select {
+	case change := <-changes:
+		assert.Equal(t, watchConfig{Limit: 10}, change.New)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not received")
+	}
+
+	// No polling while watching
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), store.loadCount.Load())
+}
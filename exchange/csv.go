@@ -71,6 +71,75 @@ func (c *csvReader) Total() uint {
 	return uint(c.total)
 }
 
+// NewCSVStreamReader returns a Reader reading the rows from r one by one instead of all at once,
+// to import large files with ChunkSize without loading them into memory.
+// The total of rows is unknown, Total returns 0. Closing r is up to the caller.
+func NewCSVStreamReader(r io.Reader) (Reader, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvStreamReader{
+		r:      cr,
+		header: trimStringSliceSpace(header),
+	}, nil
+}
+
+type csvStreamReader struct {
+	r      *csv.Reader
+	header []string
+	row    []string
+	err    error
+}
+
+var _ Reader = (*csvStreamReader)(nil)
+
+func (c *csvStreamReader) Header() []string {
+	return c.header
+}
+
+func (c *csvStreamReader) ReadRow() ([]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.row == nil {
+		return nil, errors.New("no more row")
+	}
+
+	return c.row, nil
+}
+
+// Next reads the next row, a read error is returned by ReadRow.
+func (c *csvStreamReader) Next() bool {
+	if c.err != nil {
+		return false
+	}
+
+	row, err := c.r.Read()
+	if err == io.EOF {
+		c.row = nil
+		return false
+	}
+	if err != nil {
+		c.err = err
+		return true
+	}
+	c.row = trimStringSliceSpace(row)
+	return true
+}
+
+func (c *csvStreamReader) Total() uint {
+	return 0
+}
+
 func trimStringSliceSpace(rs []string) []string {
 	nrs := make([]string, 0, len(rs))
 	for _, r := range rs {
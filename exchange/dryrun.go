@@ -0,0 +1,136 @@
+package exchange
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mohae/deepcopy"
+)
+
+// RowAction is what importing a row does.
+type RowAction string
+
+const (
+	RowActionInsert    RowAction = "insert"
+	RowActionUpdate    RowAction = "update"
+	RowActionUnchanged RowAction = "unchanged"
+	RowActionError     RowAction = "error"
+)
+
+// FieldChange is a changed field of an updated record.
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// RowPreview is the preview of importing a row.
+type RowPreview struct {
+	// Row is the number of the row in the file, the header being row 1
+	Row    int
+	Action RowAction
+	// Changes are the changed fields of the record in the order of the struct, only for RowActionUpdate
+	Changes []FieldChange
+	// Errors are the errors of the row, only for RowActionError
+	Errors []*RowError
+}
+
+// DryRunResult is the result of Importer.Exec with the DryRun option.
+type DryRunResult struct {
+	Rows []*RowPreview
+}
+
+// DryRun makes Importer.Exec run the whole import in a transaction that is rolled back,
+// and fill result with what each row would do.
+// Like with Report, every row is validated and set, but the invalid rows are previewed as RowActionError
+// instead of failing the import, so Exec only returns the errors that are not of a row.
+func DryRun(result *DryRunResult) ImporterExecOption {
+	return &dryRunOption{result}
+}
+
+type dryRunOption struct {
+	result *DryRunResult
+}
+
+var _ ImporterExecOption = (*dryRunOption)(nil)
+
+func (o *dryRunOption) iePrivate() {}
+
+// errDryRunRollback rolls back the transaction of the dry run.
+var errDryRunRollback = errors.New("dry run rollback")
+
+// Count returns the number of rows per action.
+func (r *DryRunResult) Count() map[RowAction]int {
+	counts := make(map[RowAction]int)
+	for _, row := range r.Rows {
+		counts[row.Action]++
+	}
+	return counts
+}
+
+// add adds the preview of a valid row by comparing the record with the old record, which is invalid for a new record.
+// It must be called before the associations of the records are cleared.
+func (r *DryRunResult) add(num int, record reflect.Value, oldRecord reflect.Value) {
+	if !oldRecord.IsValid() {
+		r.Rows = append(r.Rows, &RowPreview{
+			Row:    num,
+			Action: RowActionInsert,
+		})
+		return
+	}
+
+	var changes []FieldChange
+	rv := record.Elem()
+	orv := oldRecord.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		newV := rv.Field(i).Interface()
+		oldV := orv.Field(i).Interface()
+		if cmp.Equal(newV, oldV) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Field: sf.Name,
+			Old:   oldV,
+			// the new associations are modified before saved
+			New: deepcopy.Copy(newV),
+		})
+	}
+
+	action := RowActionUpdate
+	if len(changes) == 0 {
+		action = RowActionUnchanged
+	}
+	r.Rows = append(r.Rows, &RowPreview{
+		Row:     num,
+		Action:  action,
+		Changes: changes,
+	})
+}
+
+// finish previews the rows having errors in the report as RowActionError, and sorts the rows.
+func (r *DryRunResult) finish(report *ImportReport) {
+	rows := make(map[int]*RowPreview, len(r.Rows))
+	for _, row := range r.Rows {
+		rows[row.Row] = row
+	}
+	for _, e := range report.Errors {
+		row, ok := rows[e.Row]
+		if !ok {
+			row = &RowPreview{Row: e.Row}
+			rows[e.Row] = row
+			r.Rows = append(r.Rows, row)
+		}
+		row.Action = RowActionError
+		row.Changes = nil
+		row.Errors = append(row.Errors, e)
+	}
+	sort.Slice(r.Rows, func(i, j int) bool {
+		return r.Rows[i].Row < r.Rows[j].Row
+	})
+}
@@ -476,3 +476,75 @@ func TestBatch(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, newCsvContent, buf.String())
 }
+
+func TestStreaming(t *testing.T) {
+	initTables()
+	initPhoneAssociations()
+
+	importer := exchange.NewImporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+	exporter := exchange.NewExporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+
+	csvContentB := bytes.Buffer{}
+	csvContentB.WriteString("Code,Name,ReleaseDate,Width,Height,Depth,ScreenSize,ScreenType,5G,WirelessCharge,Intro,ExtraIntro,FrontCamera,BackCamera,SellingOnJD,SellingOnTaoBao\n")
+	for i := 0; i < 250; i++ {
+		code := fmt.Sprintf("%d", i+100)
+		csvContentB.WriteString(code)
+		csvContentB.WriteString(",Orange")
+		csvContentB.WriteString(code)
+		csvContentB.WriteString(",2021-01-01,80,180,8,6.5,IPS,FALSE,TRUE,yyds,eyyds,3000px,6000px,TRUE,FALSE\n")
+	}
+	csvContent := csvContentB.String()
+
+	type progress struct {
+		processed, total uint
+	}
+
+	// import in chunks, twice to update the existing records
+	for i := 0; i < 2; i++ {
+		var progresses []progress
+		r, err := exchange.NewCSVStreamReader(strings.NewReader(csvContent))
+		assert.NoError(t, err)
+		err = importer.Exec(db, r, exchange.ChunkSize(100), exchange.Progress(func(processed, total uint) {
+			progresses = append(progresses, progress{processed, total})
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []progress{{100, 0}, {200, 0}, {250, 0}}, progresses)
+	}
+
+	var progresses []progress
+	buf := bytes.Buffer{}
+	w, err := exchange.NewCSVWriter(&buf)
+	assert.NoError(t, err)
+	err = exporter.Exec(db, w, exchange.ChunkSize(100), exchange.Progress(func(processed, total uint) {
+		progresses = append(progresses, progress{processed, total})
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, csvContent, buf.String())
+	assert.Equal(t, []progress{{100, 250}, {200, 250}, {250, 250}}, progresses)
+
+	// a failed chunk leaves the chunks before it imported
+	csvContentB = bytes.Buffer{}
+	csvContentB.WriteString("Code,Name,ReleaseDate,Width,Height,Depth,ScreenSize,ScreenType,5G,WirelessCharge,Intro,ExtraIntro,FrontCamera,BackCamera,SellingOnJD,SellingOnTaoBao\n")
+	for i := 0; i < 150; i++ {
+		code := fmt.Sprintf("%d", i+100)
+		releaseDate := "2021-02-01"
+		if i == 120 {
+			releaseDate = "invalid"
+		}
+		csvContentB.WriteString(code)
+		csvContentB.WriteString(",Orangee")
+		csvContentB.WriteString(code)
+		csvContentB.WriteString("," + releaseDate + ",80,180,8,6.5,IPS,FALSE,TRUE,yyds,eyyds,3000px,6000px,TRUE,FALSE\n")
+	}
+	r, err := exchange.NewCSVStreamReader(strings.NewReader(csvContentB.String()))
+	assert.NoError(t, err)
+	err = importer.Exec(db, r, exchange.ChunkSize(100))
+	assert.Error(t, err)
+	var updated int64
+	assert.NoError(t, db.Model(&Phone{}).Where("name like ?", "Orangee%").Count(&updated).Error)
+	assert.Equal(t, int64(100), updated)
+}
@@ -37,34 +37,7 @@ func (ep *Exporter) Exec(db *gorm.DB, w Writer, opts ...ExporterExecOption) erro
 		return err
 	}
 
-	maxParamsPerSQL := ep.parseOptions(opts...)
-
-	records := reflect.New(reflect.SliceOf(ep.rtResource)).Elem()
-	{
-		// gorm using id to order in FindInBatches
-		// var orderBy string
-		// for i, m := range ep.pkMetas {
-		// 	if i > 0 {
-		// 		orderBy += ", "
-		// 	}
-		// 	orderBy += fmt.Sprintf("%s asc", m.snakeField)
-		// }
-		chunkRecords := reflect.New(reflect.SliceOf(ep.rtResource)).Interface()
-		batchSize := maxParamsPerSQL
-		if len(ep.pkMetas) > 0 {
-			batchSize /= len(ep.pkMetas)
-		}
-		err = preloadDB(db, ep.associations).
-			Model(ep.resource).
-			// Order(orderBy).
-			FindInBatches(chunkRecords, batchSize, func(tx *gorm.DB, batch int) error {
-				records = reflect.AppendSlice(records, reflect.ValueOf(chunkRecords).Elem())
-				return nil
-			}).Error
-		if err != nil {
-			return err
-		}
-	}
+	eo := ep.parseOptions(opts...)
 
 	headers := make([]string, 0, len(ep.metas))
 	for _, m := range ep.metas {
@@ -75,24 +48,64 @@ func (ep *Exporter) Exec(db *gorm.DB, w Writer, opts ...ExporterExecOption) erro
 		return err
 	}
 
+	var total uint
+	if eo.progress != nil {
+		var count int64
+		err = db.Model(ep.resource).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		total = uint(count)
+	}
+
+	// gorm using id to order in FindInBatches
+	// var orderBy string
+	// for i, m := range ep.pkMetas {
+	// 	if i > 0 {
+	// 		orderBy += ", "
+	// 	}
+	// 	orderBy += fmt.Sprintf("%s asc", m.snakeField)
+	// }
+	chunkRecords := reflect.New(reflect.SliceOf(ep.rtResource)).Interface()
+	batchSize := eo.maxParamsPerSQL
+	if len(ep.pkMetas) > 0 {
+		batchSize /= len(ep.pkMetas)
+	}
+	if eo.chunkSize > 0 && eo.chunkSize < batchSize {
+		batchSize = eo.chunkSize
+	}
+	// each batch is written as it arrives, so only one batch of records is held in memory
+	var processed uint
 	vals := make([]string, len(ep.metas))
-	for i := 0; i < records.Len(); i++ {
-		record := records.Index(i)
-		for i, m := range ep.metas {
-			if m.valuer != nil {
-				v, err := m.valuer(record.Interface())
+	err = preloadDB(db, ep.associations).
+		Model(ep.resource).
+		// Order(orderBy).
+		FindInBatches(chunkRecords, batchSize, func(tx *gorm.DB, batch int) error {
+			records := reflect.ValueOf(chunkRecords).Elem()
+			for i := 0; i < records.Len(); i++ {
+				record := records.Index(i)
+				for i, m := range ep.metas {
+					if m.valuer != nil {
+						v, err := m.valuer(record.Interface())
+						if err != nil {
+							return err
+						}
+						vals[i] = v
+						continue
+					}
+					vals[i] = cast.ToString(record.Elem().FieldByName(m.field).Interface())
+				}
+				err := w.WriteRow(vals)
 				if err != nil {
 					return err
 				}
-				vals[i] = v
-				continue
 			}
-			vals[i] = cast.ToString(record.Elem().FieldByName(m.field).Interface())
-		}
-		err = w.WriteRow(vals)
-		if err != nil {
-			return err
-		}
+			processed += uint(records.Len())
+			eo.reportProgress(processed, total)
+			return nil
+		}).Error
+	if err != nil {
+		return err
 	}
 
 	return w.Flush()
@@ -116,16 +129,20 @@ func (ep *Exporter) validateAndInit() error {
 	return nil
 }
 
-func (ep *Exporter) parseOptions(opts ...ExporterExecOption) (
-	maxParamsPerSQL int,
-) {
-	maxParamsPerSQL = 65000
+func (ep *Exporter) parseOptions(opts ...ExporterExecOption) *execOptions {
+	eo := &execOptions{
+		maxParamsPerSQL: 65000,
+	}
 	for _, opt := range opts {
 		switch v := opt.(type) {
 		case *maxParamsPerSQLOption:
-			maxParamsPerSQL = v.v
+			eo.maxParamsPerSQL = v.v
+		case *chunkSizeOption:
+			eo.chunkSize = v.v
+		case *progressOption:
+			eo.progress = v.f
 		}
 	}
 
-	return maxParamsPerSQL
+	return eo
 }
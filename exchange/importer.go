@@ -39,80 +39,212 @@ func (ip *Importer) Associations(ts ...string) *Importer {
 	return ip
 }
 
+// Headers returns the column headers of the metas.
+func (ip *Importer) Headers() []string {
+	headers := make([]string, 0, len(ip.metas))
+	for _, m := range ip.metas {
+		headers = append(headers, m.columnHeader)
+	}
+	return headers
+}
+
 func (ip *Importer) Validators(vs ...func(metaValues MetaValues) error) *Importer {
 	ip.validators = vs
 	return ip
 }
 
+// Exec imports the rows of r.
+// By default all rows are read first and imported in one transaction,
+// with ChunkSize they are read and imported in chunks, each chunk in its own transaction,
+// so a failure leaves the chunks before it imported, and with Report only the invalid rows are not imported.
 func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) error {
 	err := ip.validateAndInit()
 	if err != nil {
 		return err
 	}
 
-	maxParamsPerSQL := ip.parseOptions(opts...)
+	eo := ip.parseOptions(opts...)
+	if eo.dryRun == nil {
+		return ip.exec(db, r, eo)
+	}
 
-	fullPrimaryKeyValues := make([][]string, 0, r.Total())
-	allMetaValues := make([]url.Values, 0, r.Total())
-	{
-		headerIdxMetas := make(map[int]*Meta)
-		header := r.Header()
-		for i := range ip.metas {
-			m := ip.metas[i]
-			hasCol := false
-			for hi, h := range header {
-				if h == m.columnHeader {
-					hasCol = true
-					headerIdxMetas[hi] = m
-					break
-				}
-			}
-			if !hasCol {
-				return fmt.Errorf("column %s not found", m.columnHeader)
-			}
+	eo.dryRun.Rows = nil
+	if eo.report == nil {
+		eo.report = &ImportReport{}
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := ip.exec(tx, r, eo); err != nil {
+			return err
 		}
+		return errDryRunRollback
+	})
+	if err != errDryRunRollback {
+		return err
+	}
+	eo.dryRun.finish(eo.report)
+	return nil
+}
 
-		for r.Next() {
-			metaValues := make(url.Values)
-			row, err := r.ReadRow()
-			if err != nil {
-				return err
+func (ip *Importer) exec(db *gorm.DB, r Reader, eo *execOptions) error {
+	headerIdxMetas := make(map[int]*Meta)
+	header := r.Header()
+	for i := range ip.metas {
+		m := ip.metas[i]
+		hasCol := false
+		for hi, h := range header {
+			if h == m.columnHeader {
+				hasCol = true
+				headerIdxMetas[hi] = m
+				break
 			}
+		}
+		if !hasCol {
+			return fmt.Errorf("column %s not found", m.columnHeader)
+		}
+	}
 
-			notEmptyPrimaryKeyValues := make([]string, 0, len(ip.pkMetas))
-			for i, v := range row {
-				m, ok := headerIdxMetas[i]
-				if !ok {
-					continue
-				}
-				metaValues.Set(m.field, v)
+	if eo.report != nil {
+		eo.report.reset(header, headerIdxMetas)
+		defer eo.report.sortErrors()
+	}
+	rr := &rowsReader{
+		r:              r,
+		headerIdxMetas: headerIdxMetas,
+		num:            1,
+		report:         eo.report,
+	}
 
-				if m.primaryKey && v != "" && m.setter == nil {
-					notEmptyPrimaryKeyValues = append(notEmptyPrimaryKeyValues, v)
-				}
+	total := r.Total()
+	if eo.chunkSize <= 0 {
+		chunk, err := ip.readRows(rr, 0)
+		if err != nil {
+			return err
+		}
+		err = ip.importRows(db, chunk, eo, false)
+		if err != nil {
+			return err
+		}
+		eo.reportProgress(uint(len(chunk.allMetaValues)), total)
+		return eo.reportErr()
+	}
+
+	var processed uint
+	for {
+		chunk, err := ip.readRows(rr, eo.chunkSize)
+		if err != nil {
+			return err
+		}
+		if len(chunk.allMetaValues) == 0 {
+			return eo.reportErr()
+		}
+		err = ip.importRows(db, chunk, eo, true)
+		if err != nil {
+			return err
+		}
+		processed += uint(len(chunk.allMetaValues))
+		eo.reportProgress(processed, total)
+		if len(chunk.allMetaValues) < eo.chunkSize {
+			return eo.reportErr()
+		}
+	}
+}
+
+// rowsReader reads the rows of a Reader in chunks, numbering them like in the file.
+type rowsReader struct {
+	r              Reader
+	headerIdxMetas map[int]*Meta
+	// num is the number of the last row read, the header being row 1
+	num    int
+	report *ImportReport
+}
+
+// rowsChunk is a chunk of rows read.
+type rowsChunk struct {
+	// fullPrimaryKeyValues are the primary key values of the rows having all of them
+	fullPrimaryKeyValues [][]string
+	// allMetaValues are the values of each row keyed by field
+	allMetaValues []url.Values
+	// nums and rows are the numbers and the values of the rows as read, kept only for the report
+	nums []int
+	rows [][]string
+	// invalid is whether any row has an error in the report
+	invalid bool
+	// invalidRows are the indexes of the rows failing a validator
+	invalidRows map[int]bool
+}
+
+// readRows reads and validates the next limit rows, or all the rows if limit is 0.
+func (ip *Importer) readRows(rr *rowsReader, limit int) (*rowsChunk, error) {
+	size := limit
+	if limit <= 0 {
+		size = int(rr.r.Total())
+	}
+	chunk := &rowsChunk{
+		fullPrimaryKeyValues: make([][]string, 0, size),
+		allMetaValues:        make([]url.Values, 0, size),
+		invalidRows:          make(map[int]bool),
+	}
+	for (limit <= 0 || len(chunk.allMetaValues) < limit) && rr.r.Next() {
+		metaValues := make(url.Values)
+		row, err := rr.r.ReadRow()
+		if err != nil {
+			return nil, err
+		}
+		rr.num++
+
+		notEmptyPrimaryKeyValues := make([]string, 0, len(ip.pkMetas))
+		for i, v := range row {
+			m, ok := rr.headerIdxMetas[i]
+			if !ok {
+				continue
 			}
-			if len(ip.pkMetas) > 0 && len(notEmptyPrimaryKeyValues) == len(ip.pkMetas) {
-				fullPrimaryKeyValues = append(fullPrimaryKeyValues, notEmptyPrimaryKeyValues)
+			metaValues.Set(m.field, v)
+
+			if m.primaryKey && v != "" && m.setter == nil {
+				notEmptyPrimaryKeyValues = append(notEmptyPrimaryKeyValues, v)
 			}
+		}
+		if len(ip.pkMetas) > 0 && len(notEmptyPrimaryKeyValues) == len(ip.pkMetas) {
+			chunk.fullPrimaryKeyValues = append(chunk.fullPrimaryKeyValues, notEmptyPrimaryKeyValues)
+		}
 
-			for _, vd := range ip.validators {
-				err = vd(metaValues)
-				if err != nil {
-					return err
+		for _, vd := range ip.validators {
+			err = vd(metaValues)
+			if err != nil {
+				if rr.report == nil {
+					return nil, err
 				}
+				rr.report.addValidatorError(rr.num, row, err)
+				chunk.invalid = true
+				chunk.invalidRows[len(chunk.allMetaValues)] = true
 			}
+		}
 
-			allMetaValues = append(allMetaValues, metaValues)
+		chunk.allMetaValues = append(chunk.allMetaValues, metaValues)
+		if rr.report != nil {
+			rr.report.Rows++
+			chunk.nums = append(chunk.nums, rr.num)
+			chunk.rows = append(chunk.rows, row)
 		}
 	}
+	return chunk, nil
+}
 
+// importRows imports the rows in one transaction.
+// If searchInKeys is true the old records are always loaded by the primary keys of the rows,
+// otherwise the whole table is loaded when the rows cover more than 1% of it.
+// In the report mode, the errors of the setters are added to the report and the rows are not imported if any is invalid,
+// unless it is a dry run. With ChunkSize only the invalid rows are skipped, the others are imported.
+func (ip *Importer) importRows(db *gorm.DB, chunk *rowsChunk, eo *execOptions, searchInKeys bool) (err error) {
+	fullPrimaryKeyValues := chunk.fullPrimaryKeyValues
+	maxParamsPerSQL := eo.maxParamsPerSQL
 	// primarykeys:record
 	oldRecordsMap := make(map[string]interface{})
 	if len(fullPrimaryKeyValues) > 0 {
 		oldRecords := reflect.New(reflect.SliceOf(ip.rtResource)).Elem()
-		tx := preloadDB(db, ip.associations)
-		searchInKeys := false
-		{
+		// a new session so the conditions of each group don't add up
+		tx := preloadDB(db, ip.associations).Session(&gorm.Session{})
+		if !searchInKeys {
 			var total int64
 			err = db.Model(ip.resource).Count(&total).Error
 			if err != nil {
@@ -125,23 +257,27 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 		if searchInKeys {
 			pkvsGroups := splitStringSliceSlice(fullPrimaryKeyValues, maxParamsPerSQL/len(ip.pkMetas))
 			for _, g := range pkvsGroups {
+				var gtx *gorm.DB
 				if len(ip.pkMetas) == 1 {
 					vs := make([]string, 0, len(g))
 					for _, pkvs := range g {
 						vs = append(vs, pkvs[0])
 					}
-					tx = tx.Where(fmt.Sprintf("%s in (?)", ip.pkMetas[0].snakeField), vs)
+					gtx = tx.Where(fmt.Sprintf("%s in (?)", ip.pkMetas[0].snakeField), vs)
 				} else {
 					var pks []string
 					for _, m := range ip.pkMetas {
 						pks = append(pks, m.snakeField)
 					}
 					// only test this on Postgres, not sure if this is valid for other databases
-					tx = tx.Where(fmt.Sprintf("(%s) in (?)", strings.Join(pks, ",")), g)
+					gtx = tx.Where(fmt.Sprintf("(%s) in (?)", strings.Join(pks, ",")), g)
 				}
 
 				chunkRecords := reflect.New(reflect.SliceOf(ip.rtResource)).Interface()
-				err = tx.Find(chunkRecords).Error
+				err = gtx.Find(chunkRecords).Error
+				if err != nil {
+					return err
+				}
 				oldRecords = reflect.AppendSlice(oldRecords, reflect.ValueOf(chunkRecords).Elem())
 			}
 		} else {
@@ -176,7 +312,8 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 	// key is association
 	toReplaceAssociations := make(map[string][]interface{})
 	maxAssociationsRecordsLen := make(map[string]int)
-	for _, metaValues := range allMetaValues {
+	skipInvalid := eo.chunkSize > 0
+	for i, metaValues := range chunk.allMetaValues {
 		var record reflect.Value
 		var oldRecord reflect.Value
 		{
@@ -192,20 +329,32 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 				record = reflect.New(ip.rtResource.Elem())
 			}
 		}
+		rowInvalid := chunk.invalidRows[i]
 		for _, m := range ip.metas {
 			if m.setter != nil {
 				err = m.setter(record.Interface(), metaValues.Get(m.field), metaValues)
-				if err != nil {
-					return err
-				}
-				continue
+			} else {
+				fv := record.Elem().FieldByName(m.field)
+				err = setValueFromString(fv, metaValues.Get(m.field))
 			}
-			fv := record.Elem().FieldByName(m.field)
-			err = setValueFromString(fv, metaValues.Get(m.field))
 			if err != nil {
-				return err
+				if eo.report == nil {
+					return err
+				}
+				eo.report.addError(chunk.nums[i], chunk.rows[i], eo.report.column(m.field), err)
+				rowInvalid = true
 			}
 		}
+		if rowInvalid {
+			chunk.invalid = true
+			continue
+		}
+		if eo.dryRun != nil {
+			eo.dryRun.add(chunk.nums[i], record, oldRecord)
+		} else if chunk.invalid && !skipInvalid {
+			// the rows are only checked for the report
+			continue
+		}
 		if oldRecord.IsValid() {
 			for _, a := range ip.associations {
 				newV := record.Elem().FieldByName(a)
@@ -247,6 +396,10 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 		records = reflect.Append(records, record)
 	}
 
+	if chunk.invalid && eo.dryRun == nil && !skipInvalid {
+		return nil
+	}
+
 	batchSize := 10000
 	{
 		max := maxParamsPerSQL / ip.resourceParams
@@ -273,7 +426,7 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 			if recordsToClearAssociations[a].Len() > 0 {
 				rgs := splitReflectSliceValue(recordsToClearAssociations[a], maxParamsPerSQL/len(ip.pkMetas))
 				for _, g := range rgs {
-					err = db.Model(g.Interface()).Association(a).Clear()
+					err = tx.Model(g.Interface()).Association(a).Clear()
 					if err != nil {
 						return err
 					}
@@ -282,7 +435,7 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 			if recordsToReplaceAssociations[a].Len() > 0 {
 				// TODO: limit batch size
 				// TODO: it seems not updated in batch from the gorm log
-				err = db.Model(recordsToReplaceAssociations[a].Interface()).Association(a).Replace(toReplaceAssociations[a]...)
+				err = tx.Model(recordsToReplaceAssociations[a].Interface()).Association(a).Replace(toReplaceAssociations[a]...)
 				if err != nil {
 					return err
 				}
@@ -302,7 +455,7 @@ func (ip *Importer) Exec(db *gorm.DB, r Reader, opts ...ImporterExecOption) erro
 			}
 		}
 		// .Session(&gorm.Session{FullSaveAssociations: true}) cannot auto delete associations and not work with many-to-many
-		return db.Clauses(clause.OnConflict{
+		return tx.Clauses(clause.OnConflict{
 			Columns:   ocPrimaryCols,
 			UpdateAll: true,
 		}).Model(ip.resource).CreateInBatches(records.Interface(), batchSize).Error
@@ -347,16 +500,24 @@ func (ip *Importer) validateAndInit() error {
 	return nil
 }
 
-func (ip *Importer) parseOptions(opts ...ImporterExecOption) (
-	maxParamsPerSQL int,
-) {
-	maxParamsPerSQL = 65000
+func (ip *Importer) parseOptions(opts ...ImporterExecOption) *execOptions {
+	eo := &execOptions{
+		maxParamsPerSQL: 65000,
+	}
 	for _, opt := range opts {
 		switch v := opt.(type) {
 		case *maxParamsPerSQLOption:
-			maxParamsPerSQL = v.v
+			eo.maxParamsPerSQL = v.v
+		case *chunkSizeOption:
+			eo.chunkSize = v.v
+		case *progressOption:
+			eo.progress = v.f
+		case *reportOption:
+			eo.report = v.report
+		case *dryRunOption:
+			eo.dryRun = v.result
 		}
 	}
 
-	return maxParamsPerSQL
+	return eo
 }
@@ -1,6 +1,7 @@
 package exchange_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -9,8 +10,11 @@ import (
 	"time"
 
 	"github.com/qor5/x/v3/exchange"
+	"github.com/qor5/x/v3/statusx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/theplant/testingutils"
+	"google.golang.org/grpc/codes"
 )
 
 func TestImport(t *testing.T) {
@@ -477,3 +481,197 @@ func TestNoAffectOnOldData(t *testing.T) {
 		},
 	}, records)
 }
+
+func TestImportReport(t *testing.T) {
+	importer := exchange.NewImporter(&TestExchangeModel{}).
+		Metas(
+			exchange.NewMeta("ID").PrimaryKey(true),
+			exchange.NewMeta("Name"),
+			exchange.NewMeta("Age"),
+			exchange.NewMeta("Birth").Setter(func(record interface{}, value string, metaValues exchange.MetaValues) error {
+				if value == "" {
+					return nil
+				}
+				t, err := time.ParseInLocation("2006-01-02", value, time.UTC)
+				if err != nil {
+					return errors.New("invalid date")
+				}
+				record.(*TestExchangeModel).Birth = &t
+				return nil
+			}),
+		).
+		Validators(
+			func(metaValues exchange.MetaValues) error {
+				if metaValues.Get("Name") == "" {
+					return statusx.BadRequest(statusx.NewFieldViolation("Name", "NAME_REQUIRED", "name cannot be empty")).Err()
+				}
+				return nil
+			},
+			func(metaValues exchange.MetaValues) error {
+				if metaValues.Get("ID") == "4" {
+					return errors.New("id 4 is reserved")
+				}
+				return nil
+			},
+		)
+
+	initTables()
+	r, err := exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(`ID,Name,Age,Birth
+1,Tom,6,1939-01-01
+2,,x,1940-02-10
+3,Spike,8,1941-13-01
+4,Tyke,1,
+`)))
+	require.NoError(t, err)
+	var report exchange.ImportReport
+	err = importer.Exec(db, r, exchange.Report(&report))
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, statusx.Convert(err).Code())
+	assert.Equal(t, 4, report.Rows)
+	assert.True(t, report.HasErrors())
+
+	type rowError struct {
+		Row    int
+		Column int
+		Header string
+		Value  string
+		Err    string
+	}
+	var errs []rowError
+	for _, e := range report.Errors {
+		errs = append(errs, rowError{e.Row, e.Column, e.Header, e.Value, e.Err.Error()})
+	}
+	assert.Equal(t, []rowError{
+		{3, 2, "Name", "", "name cannot be empty"},
+		{3, 3, "Age", "x", `strconv.ParseInt: parsing "x": invalid syntax`},
+		{4, 4, "Birth", "1941-13-01", "invalid date"},
+		{5, 0, "", "", "id 4 is reserved"},
+	}, errs)
+	assert.EqualError(t, report.Errors[2], "row 4, column Birth: invalid date")
+	assert.EqualError(t, report.Errors[3], "row 5: id 4 is reserved")
+
+	var fields, reasons []string
+	for _, fv := range report.FieldViolations() {
+		fields = append(fields, fv.Field())
+		reasons = append(reasons, fv.Reason())
+	}
+	assert.Equal(t, []string{"rows[3].Name", "rows[3].Age", "rows[4].Birth", "rows[5]"}, fields)
+	assert.Equal(t, []string{"NAME_REQUIRED", exchange.ErrorReasonInvalidValue, exchange.ErrorReasonInvalidValue, exchange.ErrorReasonInvalidRow}, reasons)
+
+	// nothing is imported
+	var count int64
+	require.NoError(t, db.Model(&TestExchangeModel{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, report.WriteErrorCSV(&buf))
+	assert.Equal(t, `ID,Name,Age,Birth,Errors
+2,,x,1940-02-10,"Name: name cannot be empty; Age: strconv.ParseInt: parsing ""x"": invalid syntax"
+3,Spike,8,1941-13-01,Birth: invalid date
+4,Tyke,1,,id 4 is reserved
+`, buf.String())
+
+	// with chunks only the invalid rows are not imported
+	r, err = exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(`ID,Name,Age,Birth
+1,Tom,6,1939-01-01
+2,Jerry,5,1940-02-10
+3,Spike,8,1941-13-01
+5,Tyke,1,
+6,,2,
+`)))
+	require.NoError(t, err)
+	err = importer.Exec(db, r, exchange.Report(&report), exchange.ChunkSize(2))
+	require.Error(t, err)
+	assert.Equal(t, 5, report.Rows)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 4, report.Errors[0].Row)
+	assert.Equal(t, 6, report.Errors[1].Row)
+	var records []*TestExchangeModel
+	require.NoError(t, db.Order("id asc").Find(&records).Error)
+	var names []string
+	for _, r := range records {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"Tom", "Jerry", "Tyke"}, names)
+
+	buf.Reset()
+	require.NoError(t, report.WriteErrorCSV(&buf))
+	assert.Equal(t, `ID,Name,Age,Birth,Errors
+3,Spike,8,1941-13-01,Birth: invalid date
+6,,2,,Name: name cannot be empty
+`, buf.String())
+
+	// no error
+	r, err = exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(`ID,Name,Age,Birth
+3,Spike,8,1941-01-01
+`)))
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, r, exchange.Report(&report)))
+	assert.Equal(t, 1, report.Rows)
+	assert.False(t, report.HasErrors())
+	assert.NoError(t, report.Err())
+}
+
+func TestImportDryRun(t *testing.T) {
+	initTables()
+	importer := exchange.NewImporter(&TestExchangeModel{}).
+		Metas(
+			exchange.NewMeta("ID").PrimaryKey(true),
+			exchange.NewMeta("Name"),
+			exchange.NewMeta("Age"),
+		).
+		Validators(func(metaValues exchange.MetaValues) error {
+			if metaValues.Get("Name") == "" {
+				return errors.New("name cannot be empty")
+			}
+			return nil
+		})
+	r, err := exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(`ID,Name,Age
+1,Tom,6
+2,Jerry,5
+`)))
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, r))
+
+	for _, chunkSize := range []int{0, 2} {
+		r, err = exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(`ID,Name,Age
+1,Tom,6
+2,Jerry,7
+3,Spike,
+4,,1
+5,Tyke,x
+`)))
+		require.NoError(t, err)
+		var result exchange.DryRunResult
+		require.NoError(t, importer.Exec(db, r, exchange.DryRun(&result), exchange.ChunkSize(chunkSize)))
+
+		require.Len(t, result.Rows, 5)
+		assert.Equal(t, &exchange.RowPreview{Row: 2, Action: exchange.RowActionUnchanged}, result.Rows[0])
+		assert.Equal(t, &exchange.RowPreview{
+			Row:     3,
+			Action:  exchange.RowActionUpdate,
+			Changes: []exchange.FieldChange{{Field: "Age", Old: ptrInt(5), New: ptrInt(7)}},
+		}, result.Rows[1])
+		assert.Equal(t, &exchange.RowPreview{Row: 4, Action: exchange.RowActionInsert}, result.Rows[2])
+		for i, row := range result.Rows[3:] {
+			assert.Equal(t, 5+i, row.Row)
+			assert.Equal(t, exchange.RowActionError, row.Action)
+			assert.Len(t, row.Errors, 1)
+		}
+		assert.EqualError(t, result.Rows[3].Errors[0], "row 5: name cannot be empty")
+		assert.Equal(t, map[exchange.RowAction]int{
+			exchange.RowActionUnchanged: 1,
+			exchange.RowActionUpdate:    1,
+			exchange.RowActionInsert:    1,
+			exchange.RowActionError:     2,
+		}, result.Count())
+
+		// rolled back
+		var records []*TestExchangeModel
+		require.NoError(t, db.Order("id asc").Find(&records).Error)
+		assert.Equal(t, []*TestExchangeModel{
+			{ID: 1, Name: "Tom", Age: ptrInt(6)},
+			{ID: 2, Name: "Jerry", Age: ptrInt(5)},
+		}, records)
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/x/v3/httpx"
+	"github.com/qor5/x/v3/oss"
+	"gorm.io/gorm"
+)
+
+// JobInfo is a job with its percent done and the presigned URLs of its files.
+type JobInfo struct {
+	*Job
+	// Percent is 0 while the total is unknown
+	Percent   float64 `json:"percent"`
+	OutputURL string  `json:"outputURL,omitempty"`
+	ErrorURL  string  `json:"errorURL,omitempty"`
+}
+
+// Info returns the job of id with the URLs of its files, presigned for WithURLExpires.
+func (m *Manager) Info(ctx context.Context, id string) (*JobInfo, error) {
+	job, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &JobInfo{Job: job}
+	switch {
+	case job.Status == StatusSucceeded:
+		info.Percent = 100
+	case job.Total > 0:
+		info.Percent = float64(job.Processed) * 100 / float64(job.Total)
+	}
+	if job.OutputPath != "" {
+		if info.OutputURL, err = m.presignGet(ctx, job.OutputPath); err != nil {
+			return nil, errors.Wrap(err, "failed to get output URL")
+		}
+	}
+	if job.ErrorPath != "" {
+		if info.ErrorURL, err = m.presignGet(ctx, job.ErrorPath); err != nil {
+			return nil, errors.Wrap(err, "failed to get error CSV URL")
+		}
+	}
+	return info, nil
+}
+
+// presignGet returns a URL downloading file as an attachment.
+func (m *Manager) presignGet(ctx context.Context, file string) (string, error) {
+	req, err := oss.PresignGet(ctx, m.storage, file,
+		oss.WithExpires(m.urlExpires),
+		oss.WithContentDisposition("attachment", path.Base(file)),
+	)
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// GetJob handles GET requests to retrieve the JobInfo of the job of the id path value, or of the id query parameter.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /api/exchange/jobs/{id}", manager.GetJob)
+func (m *Manager) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		id = r.URL.Query().Get("id")
+	}
+	if id == "" {
+		httpx.WriteJSONError(w, http.StatusBadRequest, errors.New("id is required"))
+		return
+	}
+
+	info, err := m.Info(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httpx.WriteJSONError(w, http.StatusNotFound, errors.Errorf("job %s not found", id))
+			return
+		}
+		httpx.WriteJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, info)
+}
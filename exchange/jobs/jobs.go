@@ -0,0 +1,435 @@
+// Package jobs runs exchange imports and exports in the background. Jobs are enqueued to go-que,
+// their input and output files are kept in an oss storage, and their status and progress in the database.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/qor5/go-que"
+	"github.com/qor5/x/v3/exchange"
+	"github.com/qor5/x/v3/goquex"
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/oss"
+	"github.com/theplant/appkit/errornotifier"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultQueue is the go-que queue of the jobs
+	DefaultQueue = "exchange.jobs"
+	// DefaultPathPrefix is where the files of the jobs are stored
+	DefaultPathPrefix = "/exchange/jobs"
+	// DefaultChunkSize is the rows imported per transaction, see exchange.ChunkSize
+	DefaultChunkSize = 1000
+	// DefaultProgressInterval is the minimum interval between the saves of the progress of a job
+	DefaultProgressInterval = time.Second
+	// DefaultURLExpires is how long the presigned URLs of the files of a job stay valid
+	DefaultURLExpires = 10 * time.Minute
+)
+
+// DefaultRetryPolicy retries a job when its status cannot be saved, a failed import or export is not retried.
+var DefaultRetryPolicy = que.RetryPolicy{
+	InitialInterval:        10 * time.Second,
+	MaxInterval:            time.Minute,
+	NextIntervalMultiplier: 2,
+	IntervalRandomPercent:  20,
+	MaxRetryCount:          3,
+}
+
+// Manager enqueues and performs the jobs of the registered importers and exporters, safe for concurrent use
+// once registered.
+type Manager struct {
+	db               *gorm.DB
+	queue            que.Queue
+	storage          oss.StorageInterface
+	queueName        string
+	pathPrefix       string
+	chunkSize        int
+	progressInterval time.Duration
+	urlExpires       time.Duration
+	retryPolicy      que.RetryPolicy
+	notifier         errornotifier.Notifier
+	importers        map[string]*importerDef
+	exporters        map[string]*exporterDef
+}
+
+type importerDef struct {
+	importer *exchange.Importer
+	opts     []exchange.ImporterExecOption
+}
+
+type exporterDef struct {
+	exporter *exchange.Exporter
+	opts     []exchange.ExporterExecOption
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithQueue sets the go-que queue of the jobs, defaults to DefaultQueue.
+func WithQueue(name string) Option {
+	return func(m *Manager) {
+		m.queueName = name
+	}
+}
+
+// WithPathPrefix sets where the files of the jobs are stored, defaults to DefaultPathPrefix.
+func WithPathPrefix(prefix string) Option {
+	return func(m *Manager) {
+		m.pathPrefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// WithChunkSize sets the rows imported per transaction, defaults to DefaultChunkSize.
+func WithChunkSize(size int) Option {
+	return func(m *Manager) {
+		m.chunkSize = size
+	}
+}
+
+// WithProgressInterval sets the minimum interval between the saves of the progress, defaults to DefaultProgressInterval.
+func WithProgressInterval(interval time.Duration) Option {
+	return func(m *Manager) {
+		m.progressInterval = interval
+	}
+}
+
+// WithURLExpires sets how long the presigned URLs of the files of a job stay valid, defaults to DefaultURLExpires.
+func WithURLExpires(expires time.Duration) Option {
+	return func(m *Manager) {
+		m.urlExpires = expires
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the enqueued jobs, defaults to DefaultRetryPolicy.
+func WithRetryPolicy(policy que.RetryPolicy) Option {
+	return func(m *Manager) {
+		m.retryPolicy = policy
+	}
+}
+
+// WithErrorNotifier sets the notifier of the errors of the workers.
+func WithErrorNotifier(notifier errornotifier.Notifier) Option {
+	return func(m *Manager) {
+		m.notifier = notifier
+	}
+}
+
+// New creates a Manager keeping the jobs in db, enqueuing them to queue and their files in storage.
+// The table must exist, see AutoMigrate. The files are downloaded with presigned URLs, so storage must
+// implement oss.Presigner, like s3 or a filesystem with a PresignConfig.
+func New(db *gorm.DB, queue que.Queue, storage oss.StorageInterface, opts ...Option) *Manager {
+	m := &Manager{
+		db:               db,
+		queue:            goquex.WithTracing(queue),
+		storage:          storage,
+		queueName:        DefaultQueue,
+		pathPrefix:       DefaultPathPrefix,
+		chunkSize:        DefaultChunkSize,
+		progressInterval: DefaultProgressInterval,
+		retryPolicy:      DefaultRetryPolicy,
+		importers:        make(map[string]*importerDef),
+		exporters:        make(map[string]*exporterDef),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// RegisterImporter registers the importer of the import jobs of name.
+// The options are given to Exec after the ones of the Manager, so they can override them.
+func (m *Manager) RegisterImporter(name string, importer *exchange.Importer, opts ...exchange.ImporterExecOption) *Manager {
+	m.importers[name] = &importerDef{importer: importer, opts: opts}
+	return m
+}
+
+// RegisterExporter registers the exporter of the export jobs of name.
+func (m *Manager) RegisterExporter(name string, exporter *exchange.Exporter, opts ...exchange.ExporterExecOption) *Manager {
+	m.exporters[name] = &exporterDef{exporter: exporter, opts: opts}
+	return m
+}
+
+// EnqueueImport stores the file read from r and enqueues a job importing it with the importer of name.
+func (m *Manager) EnqueueImport(ctx context.Context, name string, format Format, r io.Reader) (*Job, error) {
+	if _, ok := m.importers[name]; !ok {
+		return nil, errors.Errorf("importer %s is not registered", name)
+	}
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+
+	job := m.newJob(KindImport, name, format)
+	job.InputPath = m.path(job.ID, "input."+string(format))
+	if _, err := m.storage.Put(ctx, job.InputPath, r, oss.PutContentType(format.contentType())); err != nil {
+		return nil, errors.Wrap(err, "failed to store input")
+	}
+	if err := m.enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// EnqueueExport enqueues a job exporting with the exporter of name.
+func (m *Manager) EnqueueExport(ctx context.Context, name string, format Format) (*Job, error) {
+	if _, ok := m.exporters[name]; !ok {
+		return nil, errors.Errorf("exporter %s is not registered", name)
+	}
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+
+	job := m.newJob(KindExport, name, format)
+	if err := m.enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (m *Manager) newJob(kind Kind, name string, format Format) *Job {
+	return &Job{
+		Model:  gormx.Model{ID: uuid.NewString()},
+		Kind:   kind,
+		Name:   name,
+		Format: format,
+		Status: StatusPending,
+	}
+}
+
+func (m *Manager) path(id, name string) string {
+	return fmt.Sprintf("%s/%s/%s", m.pathPrefix, id, name)
+}
+
+// enqueue creates the job and enqueues it in the same transaction, so a job is never left pending without being in the queue.
+func (m *Manager) enqueue(ctx context.Context, job *Job) error {
+	return gormx.Transaction(m.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return errors.Wrap(err, "failed to create job")
+		}
+		// Without the transaction the plan would be enqueued even if the job is rolled back
+		sqlTx, ok := tx.Statement.ConnPool.(*sql.Tx)
+		if !ok {
+			return errors.Errorf("failed to enqueue job: transaction is %T, not *sql.Tx", tx.Statement.ConnPool)
+		}
+		_, err := m.queue.Enqueue(ctx, sqlTx, que.Plan{
+			Queue:       m.queueName,
+			Args:        que.Args(job.ID),
+			RunAt:       time.Now(),
+			RetryPolicy: m.retryPolicy,
+		})
+		return err
+	})
+}
+
+// Get returns the job of id, the error wraps gorm.ErrRecordNotFound if it does not exist.
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	job := &Job{}
+	if err := m.db.WithContext(ctx).Where("id = ?", id).First(job).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get job %s", id)
+	}
+	return job, nil
+}
+
+// NewWorker creates a worker performing the jobs of the queue, with goquex tracing.
+// Queue, Mutex and Perform of opts are set by the Manager.
+func (m *Manager) NewWorker(opts que.WorkerOptions) (*que.Worker, error) {
+	opts.Queue = m.queueName
+	opts.Mutex = m.queue.Mutex()
+	opts.Perform = goquex.PerformWithTracing(m.notifier)(m.Perform)
+	return que.NewWorker(opts)
+}
+
+// Perform performs a job of the queue, it is the Perform of the workers created by NewWorker.
+// A failed import or export is saved as failed and not retried, the job is expired.
+func (m *Manager) Perform(ctx context.Context, j que.Job) error {
+	var id string
+	if _, err := que.ParseArgs(j.Plan().Args, &id); err != nil {
+		return j.Expire(ctx, errors.Wrap(err, "invalid args"))
+	}
+	job, err := m.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return j.Expire(ctx, err)
+		}
+		return err
+	}
+	if job.Status.Finished() {
+		return j.Done(ctx)
+	}
+
+	now := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	job.Processed, job.Total = 0, 0
+	if err := m.save(ctx, job); err != nil {
+		return err
+	}
+
+	var runErr error
+	switch job.Kind {
+	case KindImport:
+		runErr = m.runImport(ctx, job)
+	case KindExport:
+		runErr = m.runExport(ctx, job)
+	default:
+		runErr = errors.Errorf("unknown kind %s", job.Kind)
+	}
+
+	now = time.Now()
+	job.FinishedAt = &now
+	job.Status = StatusSucceeded
+	job.Error = ""
+	if runErr != nil {
+		job.Status = StatusFailed
+		job.Error = runErr.Error()
+	}
+	if err := m.save(ctx, job); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return j.Expire(ctx, runErr)
+	}
+	return j.Done(ctx)
+}
+
+func (m *Manager) save(ctx context.Context, job *Job) error {
+	err := m.db.WithContext(ctx).Model(job).Select(
+		"Status", "Processed", "Total", "OutputPath", "ErrorPath", "Error", "StartedAt", "FinishedAt",
+	).Updates(job).Error
+	return errors.Wrapf(err, "failed to save job %s", job.ID)
+}
+
+// progress returns the exchange.ProgressFunc saving the progress of the job at most once per progress interval.
+func (m *Manager) progress(ctx context.Context, job *Job) exchange.ProgressFunc {
+	var saved time.Time
+	return func(processed, total uint) {
+		job.Processed, job.Total = processed, total
+		if time.Since(saved) < m.progressInterval {
+			return
+		}
+		saved = time.Now()
+		// the progress is saved again when the job finishes, an error here is not worth failing it
+		_ = m.db.WithContext(ctx).Model(job).Select("Processed", "Total").Updates(job).Error
+	}
+}
+
+func (m *Manager) runImport(ctx context.Context, job *Job) error {
+	def, ok := m.importers[job.Name]
+	if !ok {
+		return errors.Errorf("importer %s is not registered", job.Name)
+	}
+
+	rc, err := m.storage.GetStream(ctx, job.InputPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to get input")
+	}
+	defer rc.Close()
+	r, err := newReader(job.Format, rc, def.importer.Headers())
+	if err != nil {
+		return err
+	}
+
+	var report exchange.ImportReport
+	opts := append([]exchange.ImporterExecOption{
+		exchange.ChunkSize(m.chunkSize),
+		exchange.Report(&report),
+		exchange.Progress(m.progress(ctx, job)),
+	}, def.opts...)
+	err = def.importer.Exec(m.db.WithContext(ctx), r, opts...)
+	if report.HasErrors() {
+		buf := bytes.Buffer{}
+		if werr := report.WriteErrorCSV(&buf); werr != nil {
+			return errors.Wrap(werr, "failed to write error CSV")
+		}
+		job.ErrorPath = m.path(job.ID, "errors.csv")
+		if _, perr := m.storage.Put(ctx, job.ErrorPath, &buf, oss.PutContentType(FormatCSV.contentType())); perr != nil {
+			return errors.Wrap(perr, "failed to store error CSV")
+		}
+	}
+	return err
+}
+
+func (m *Manager) runExport(ctx context.Context, job *Job) error {
+	def, ok := m.exporters[job.Name]
+	if !ok {
+		return errors.Errorf("exporter %s is not registered", job.Name)
+	}
+
+	// the output is written to a temporary file first, since the exporters write while the storages read
+	f, err := os.CreateTemp("", "exchange-job-*."+string(job.Format))
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := newWriter(job.Format, f)
+	if err != nil {
+		return err
+	}
+	opts := append([]exchange.ExporterExecOption{
+		exchange.Progress(m.progress(ctx, job)),
+	}, def.opts...)
+	if err := def.exporter.Exec(m.db.WithContext(ctx), w, opts...); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind temporary file")
+	}
+
+	job.OutputPath = m.path(job.ID, "output."+string(job.Format))
+	_, err = m.storage.Put(ctx, job.OutputPath, f,
+		oss.PutContentType(job.Format.contentType()),
+		oss.PutContentDisposition(mime.FormatMediaType("attachment", map[string]string{"filename": job.Name + "." + string(job.Format)})),
+	)
+	return errors.Wrap(err, "failed to store output")
+}
+
+func (f Format) validate() error {
+	switch f {
+	case FormatCSV, FormatXLSX, FormatJSONL:
+		return nil
+	}
+	return errors.Errorf("unknown format %s", f)
+}
+
+func (f Format) contentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSONL:
+		return "application/jsonl"
+	}
+	return "text/csv"
+}
+
+// newReader returns the reader of format, the keys of JSON Lines being the headers of the importer.
+func newReader(format Format, r io.Reader, headers []string) (exchange.Reader, error) {
+	switch format {
+	case FormatXLSX:
+		return exchange.NewXLSXReader(r)
+	case FormatJSONL:
+		return exchange.NewJSONLReader(r, headers...)
+	}
+	return exchange.NewCSVStreamReader(r)
+}
+
+func newWriter(format Format, w io.Writer) (exchange.Writer, error) {
+	switch format {
+	case FormatXLSX:
+		return exchange.NewXLSXWriter(w)
+	case FormatJSONL:
+		return exchange.NewJSONLWriter(w)
+	}
+	return exchange.NewCSVWriter(w)
+}
@@ -0,0 +1,220 @@
+package jobs_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qor5/go-que"
+	"github.com/qor5/x/v3/exchange"
+	"github.com/qor5/x/v3/exchange/jobs"
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/oss/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var suite *gormx.TestSuite
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	suite = gormx.MustStartTestSuite(ctx)
+	defer func() {
+		if err := suite.Stop(context.Background()); err != nil {
+			fmt.Printf("Error during teardown: %v\n", err)
+		}
+	}()
+
+	os.Exit(m.Run())
+}
+
+type Pet struct {
+	ID   uint `gorm:"primarykey"`
+	Name string
+}
+
+// fakeQueue keeps the enqueued plans instead of saving them.
+type fakeQueue struct {
+	plans []que.Plan
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, tx *sql.Tx, plans ...que.Plan) ([]int64, error) {
+	if tx == nil {
+		return nil, errors.New("plans must be enqueued in a transaction")
+	}
+	ids := make([]int64, 0, len(plans))
+	for _, p := range plans {
+		q.plans = append(q.plans, p)
+		ids = append(ids, int64(len(q.plans)))
+	}
+	return ids, nil
+}
+
+func (q *fakeQueue) Mutex() que.Mutex {
+	return nil
+}
+
+// fakeJob records how the job ends.
+type fakeJob struct {
+	que.Job
+	plan    que.Plan
+	done    bool
+	expired error
+}
+
+func (j *fakeJob) Plan() que.Plan {
+	return j.plan
+}
+
+func (j *fakeJob) Done(ctx context.Context) error {
+	j.done = true
+	return nil
+}
+
+func (j *fakeJob) Expire(ctx context.Context, cerr error) error {
+	j.expired = cerr
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	db := suite.DB()
+	require.NoError(t, suite.ResetDB(ctx, &jobs.Job{}, &Pet{}))
+
+	metas := []*exchange.Meta{
+		exchange.NewMeta("ID").PrimaryKey(true),
+		exchange.NewMeta("Name"),
+	}
+	queue := &fakeQueue{}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	storage := filesystem.New(t.TempDir())
+	storage.Presign = &filesystem.PresignConfig{Secret: []byte("secret"), BaseURL: server.URL + "/oss"}
+	mux.Handle("/oss/", storage.PresignHandler())
+	m := jobs.New(db, queue, storage).
+		RegisterImporter("pets", exchange.NewImporter(&Pet{}).Metas(metas...).Validators(func(metaValues exchange.MetaValues) error {
+			if metaValues.Get("Name") == "" {
+				return errors.New("name cannot be empty")
+			}
+			return nil
+		})).
+		RegisterExporter("pets", exchange.NewExporter(&Pet{}).Metas(metas...))
+
+	perform := func(job *jobs.Job) *fakeJob {
+		require.NotEmpty(t, queue.plans)
+		plan := queue.plans[len(queue.plans)-1]
+		assert.Equal(t, jobs.DefaultQueue, plan.Queue)
+		fj := &fakeJob{plan: plan}
+		require.NoError(t, m.Perform(ctx, fj))
+		return fj
+	}
+
+	_, err := m.EnqueueImport(ctx, "cats", jobs.FormatCSV, strings.NewReader(""))
+	assert.EqualError(t, err, "importer cats is not registered")
+	_, err = m.EnqueueExport(ctx, "pets", "xml")
+	assert.EqualError(t, err, "unknown format xml")
+
+	// invalid rows fail the job with an error CSV
+	job, err := m.EnqueueImport(ctx, "pets", jobs.FormatCSV, strings.NewReader("ID,Name\n1,Tom\n2,\n"))
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusPending, job.Status)
+	fj := perform(job)
+	assert.False(t, fj.done)
+	assert.Error(t, fj.expired)
+	info, err := m.Info(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusFailed, info.Status)
+	assert.NotEmpty(t, info.Error)
+	assert.NotNil(t, info.FinishedAt)
+	require.NotEmpty(t, info.ErrorURL)
+	rc, err := storage.GetStream(ctx, info.ErrorPath)
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "ID,Name,Errors\n2,,name cannot be empty\n", string(content))
+
+	// performed again, a finished job is done
+	fj = perform(job)
+	assert.True(t, fj.done)
+
+	job, err = m.EnqueueImport(ctx, "pets", jobs.FormatJSONL, strings.NewReader(`{"ID":"1","Name":"Tom"}
+{"ID":"2","Name":"Jerry"}
+`))
+	require.NoError(t, err)
+	fj = perform(job)
+	assert.True(t, fj.done)
+	info, err = m.Info(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusSucceeded, info.Status)
+	assert.Equal(t, uint(2), info.Processed)
+	assert.Equal(t, float64(100), info.Percent)
+
+	job, err = m.EnqueueExport(ctx, "pets", jobs.FormatCSV)
+	require.NoError(t, err)
+	fj = perform(job)
+	assert.True(t, fj.done)
+	info, err = m.Info(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusSucceeded, info.Status)
+	assert.Equal(t, uint(2), info.Processed)
+	assert.Equal(t, uint(2), info.Total)
+	rc, err = storage.GetStream(ctx, info.OutputPath)
+	require.NoError(t, err)
+	content, err = io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "ID,Name\n1,Tom\n2,Jerry\n", string(content))
+
+	// the output is downloaded with its presigned URL
+	resp, err := http.Get(info.OutputURL)
+	require.NoError(t, err)
+	content, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ID,Name\n1,Tom\n2,Jerry\n", string(content))
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+
+	// status API
+	mux.HandleFunc("GET /jobs/{id}", m.GetJob)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, job.ID, got["id"])
+	assert.Equal(t, "succeeded", got["status"])
+	assert.Contains(t, got["outputURL"], server.URL+"/oss"+info.OutputPath)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// progress component
+	comp, err := m.Progress(ctx, job.ID)
+	require.NoError(t, err)
+	html, err := comp.MarshalHTML(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "succeeded")
+	assert.Contains(t, string(html), "2 / 2 (100%)")
+	assert.NotContains(t, string(html), jobs.EventProgress)
+
+	// reloaded until finished
+	job, err = m.EnqueueExport(ctx, "pets", jobs.FormatCSV)
+	require.NoError(t, err)
+	comp, err = m.Progress(ctx, job.ID)
+	require.NoError(t, err)
+	html, err = comp.MarshalHTML(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), jobs.EventProgress)
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/qor5/x/v3/gormx"
+	"gorm.io/gorm"
+)
+
+// Kind is whether a job imports or exports.
+type Kind string
+
+const (
+	KindImport Kind = "import"
+	KindExport Kind = "export"
+)
+
+// Status is the status of a job.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Finished reports whether the job will not change anymore.
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Format is the file format of a job.
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatXLSX  Format = "xlsx"
+	FormatJSONL Format = "jsonl"
+)
+
+// Job is an import or export run in the background, with its status and progress.
+type Job struct {
+	gormx.Model
+	Kind Kind `gorm:"not null" json:"kind"`
+	// Name is the name of the registered importer or exporter
+	Name   string `gorm:"index;not null" json:"name"`
+	Format Format `gorm:"not null" json:"format"`
+	Status Status `gorm:"index;not null" json:"status"`
+	// Processed and Total are the rows processed and the total, which is 0 if unknown
+	Processed uint `gorm:"not null;default:0" json:"processed"`
+	Total     uint `gorm:"not null;default:0" json:"total"`
+	// InputPath is the file imported, OutputPath the file exported, in the storage
+	InputPath  string `json:"inputPath,omitempty"`
+	OutputPath string `json:"outputPath,omitempty"`
+	// ErrorPath is the error CSV of the invalid rows of an import, see exchange.ImportReport.WriteErrorCSV
+	ErrorPath  string     `json:"errorPath,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// TableName specifies the table name for Job.
+func (*Job) TableName() string {
+	return "exchange_jobs"
+}
+
+// AutoMigrate creates or updates the table used by Manager.
+// The tables of the go-que queue are created separately.
+func AutoMigrate(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).AutoMigrate(&Job{})
+}
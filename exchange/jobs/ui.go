@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qor5/web/v3"
+	v "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	h "github.com/theplant/htmlgo"
+)
+
+// EventProgress is the event reloading the progress of a job
+const EventProgress = "exchange_jobs_progress"
+
+// progressReloadInterval is how often the progress of an unfinished job is reloaded
+var progressReloadInterval = 2 * time.Second
+
+// RegisterEventFuncs registers the events of the components of the jobs, e.g. on the web.PageBuilder of the page showing them.
+func (m *Manager) RegisterEventFuncs(hub web.EventFuncHub) {
+	hub.RegisterEventFunc(EventProgress, m.reloadProgress)
+}
+
+// Progress returns a vuetifyx.Progress of the job of id, reloaded until the job is finished.
+// The events must be registered, see RegisterEventFuncs.
+func (m *Manager) Progress(ctx context.Context, id string) (h.HTMLComponent, error) {
+	info, err := m.Info(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return web.Portal(progressContent(info)).Name(progressPortalName(id)), nil
+}
+
+func (m *Manager) reloadProgress(ctx *web.EventContext) (r web.EventResponse, err error) {
+	id := ctx.R.FormValue("id")
+	info, err := m.Info(ctx.R.Context(), id)
+	if err != nil {
+		return r, err
+	}
+	r.Body = progressBody(info)
+	if info.Status.Finished() {
+		// replaces the loader portal, which stops reloading
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: progressPortalName(id),
+			Body: r.Body,
+		})
+	}
+	return r, nil
+}
+
+// progressContent returns the progress of a finished job,
+// or a portal loading the progress with the event until the job is finished.
+func progressContent(info *JobInfo) h.HTMLComponent {
+	if info.Status.Finished() {
+		return progressBody(info)
+	}
+	return web.Portal().
+		Loader(web.Plaid().EventFunc(EventProgress).Query("id", info.ID)).
+		AutoReloadInterval(progressReloadInterval.Milliseconds())
+}
+
+func progressPortalName(id string) string {
+	return "exchangeJobProgress_" + id
+}
+
+func progressBody(info *JobInfo) h.HTMLComponent {
+	color := "primary"
+	switch info.Status {
+	case StatusSucceeded:
+		color = "success"
+	case StatusFailed:
+		color = "error"
+	}
+
+	var links []h.HTMLComponent
+	if info.OutputURL != "" {
+		links = append(links, v.VBtn("Download").Href(info.OutputURL).Variant(v.VariantTonal).Size(v.SizeSmall).Class("mr-2"))
+	}
+	if info.ErrorURL != "" {
+		links = append(links, v.VBtn("Download errors").Href(info.ErrorURL).Variant(v.VariantTonal).Size(v.SizeSmall).Color("error"))
+	}
+
+	return vx.Progress(links...).
+		Label(fmt.Sprintf("%s %s", info.Name, info.Kind)).
+		Status(string(info.Status)).
+		Color(color).
+		Message(info.Error).
+		Value(info.Processed, info.Total).
+		Running(!info.Status.Finished())
+}
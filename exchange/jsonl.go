@@ -0,0 +1,237 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NewJSONLReader returns a Reader of JSON Lines, one object per line keyed by the column headers.
+// The header is the given one, e.g. Importer.Headers(), other keys are ignored like the extra columns of a CSV.
+// Without it, the header is the keys of the first object in order, and a row having other keys fails to be read
+// rather than losing them. Keys missing from an object are read as empty values.
+// Strings are read as is, null as empty, other values as their JSON text, e.g. true, 6.5 or ["a","b"].
+// The rows are read one by one, Total returns 0. Closing r is up to the caller.
+func NewJSONLReader(r io.Reader, header ...string) (Reader, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+
+	jr := &jsonlReader{
+		dec: json.NewDecoder(r),
+	}
+	if len(header) > 0 {
+		jr.header = header
+		jr.headerIdx = make(map[string]int, len(header))
+		for i, h := range header {
+			jr.headerIdx[h] = i
+		}
+		jr.ignoreUnknown = true
+		return jr, nil
+	}
+
+	first, err := jr.readObject()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("no header")
+		}
+		return nil, err
+	}
+	jr.header = make([]string, 0, len(first))
+	jr.headerIdx = make(map[string]int, len(first))
+	for _, kv := range first {
+		jr.headerIdx[kv.key] = len(jr.header)
+		jr.header = append(jr.header, kv.key)
+	}
+	jr.first = first
+
+	return jr, nil
+}
+
+type jsonlReader struct {
+	dec       *json.Decoder
+	header    []string
+	headerIdx map[string]int
+	// ignoreUnknown is whether the keys not in the header are ignored, the header being given
+	ignoreUnknown bool
+	// the first object is a row besides the header
+	first []jsonlKeyValue
+	row   []string
+	err   error
+	line  int
+}
+
+type jsonlKeyValue struct {
+	key   string
+	value string
+}
+
+var _ Reader = (*jsonlReader)(nil)
+
+func (j *jsonlReader) Header() []string {
+	return j.header
+}
+
+func (j *jsonlReader) ReadRow() ([]string, error) {
+	if j.err != nil {
+		return nil, j.err
+	}
+	if j.row == nil {
+		return nil, errors.New("no more row")
+	}
+
+	return j.row, nil
+}
+
+// Next reads the next object, a read error is returned by ReadRow.
+func (j *jsonlReader) Next() bool {
+	if j.err != nil {
+		return false
+	}
+
+	obj := j.first
+	j.first = nil
+	if obj == nil {
+		var err error
+		obj, err = j.readObject()
+		if err == io.EOF {
+			j.row = nil
+			return false
+		}
+		if err != nil {
+			j.err = err
+			return true
+		}
+	}
+
+	row := make([]string, len(j.header))
+	for _, kv := range obj {
+		i, ok := j.headerIdx[kv.key]
+		if !ok {
+			if j.ignoreUnknown {
+				continue
+			}
+			j.err = fmt.Errorf("line %d: key %s is not in the first line", j.line, kv.key)
+			return true
+		}
+		row[i] = kv.value
+	}
+	j.row = row
+	return true
+}
+
+func (j *jsonlReader) Total() uint {
+	return 0
+}
+
+// readObject reads the next object keeping the order of its keys.
+func (j *jsonlReader) readObject() ([]jsonlKeyValue, error) {
+	var obj json.RawMessage
+	if err := j.dec.Decode(&obj); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("line %d: %w", j.line+1, err)
+	}
+	j.line++
+
+	// decoded by tokens, since a map loses the order of the keys
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("line %d: not an object", j.line)
+	}
+	var kvs []jsonlKeyValue
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", j.line, err)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", j.line, err)
+		}
+		kvs = append(kvs, jsonlKeyValue{
+			key:   t.(string),
+			value: jsonlValue(value),
+		})
+	}
+	return kvs, nil
+}
+
+func jsonlValue(v json.RawMessage) string {
+	s := strings.TrimSpace(string(v))
+	switch {
+	case s == "null":
+		return ""
+	case strings.HasPrefix(s, `"`):
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			return str
+		}
+	}
+	return s
+}
+
+// NewJSONLWriter returns a Writer of JSON Lines, one object per row keyed by the column headers in order.
+// The values are written as strings, so they are read back the same.
+func NewJSONLWriter(w io.Writer) (Writer, error) {
+	if w == nil {
+		return nil, errors.New("writer is nil")
+	}
+
+	return &jsonlWriter{
+		w: bufio.NewWriter(w),
+	}, nil
+}
+
+type jsonlWriter struct {
+	w      *bufio.Writer
+	header [][]byte
+	buf    bytes.Buffer
+}
+
+var _ Writer = (*jsonlWriter)(nil)
+
+func (j *jsonlWriter) WriteHeader(h []string) error {
+	j.header = make([][]byte, 0, len(h))
+	for _, v := range h {
+		key, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.header = append(j.header, key)
+	}
+	return nil
+}
+
+func (j *jsonlWriter) WriteRow(r []string) error {
+	if len(r) != len(j.header) {
+		return fmt.Errorf("row has %d values, header has %d", len(r), len(j.header))
+	}
+
+	j.buf.Reset()
+	j.buf.WriteByte('{')
+	for i, v := range r {
+		if i > 0 {
+			j.buf.WriteByte(',')
+		}
+		j.buf.Write(j.header[i])
+		j.buf.WriteByte(':')
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.buf.Write(value)
+	}
+	j.buf.WriteString("}\n")
+	_, err := j.w.Write(j.buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,104 @@
+package exchange_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONL(t *testing.T) {
+	initTables()
+	initPhoneAssociations()
+
+	importer := exchange.NewImporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+	exporter := exchange.NewExporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+
+	csvContent := `Code,Name,ReleaseDate,Width,Height,Depth,ScreenSize,ScreenType,5G,WirelessCharge,Intro,ExtraIntro,FrontCamera,BackCamera,SellingOnJD,SellingOnTaoBao
+100,Orange13,2021-01-01,80,180,8,6.5,IPS,FALSE,TRUE,yyds,eyyds,3000px,6000px,TRUE,FALSE
+300,Pear100,,,,,,,FALSE,FALSE,,,,,FALSE,FALSE
+`
+	jsonlContent := `{"Code":"100","Name":"Orange13","ReleaseDate":"2021-01-01","Width":"80","Height":"180","Depth":"8","ScreenSize":"6.5","ScreenType":"IPS","5G":"FALSE","WirelessCharge":"TRUE","Intro":"yyds","ExtraIntro":"eyyds","FrontCamera":"3000px","BackCamera":"6000px","SellingOnJD":"TRUE","SellingOnTaoBao":"FALSE"}
+{"Code":"300","Name":"Pear100","ReleaseDate":"","Width":"","Height":"","Depth":"","ScreenSize":"","ScreenType":"","5G":"FALSE","WirelessCharge":"FALSE","Intro":"","ExtraIntro":"","FrontCamera":"","BackCamera":"","SellingOnJD":"FALSE","SellingOnTaoBao":"FALSE"}
+`
+
+	r, err := exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(csvContent)))
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, r))
+
+	buf := bytes.Buffer{}
+	w, err := exchange.NewJSONLWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Exec(db, w))
+	assert.Equal(t, jsonlContent, buf.String())
+
+	initTables()
+	initPhoneAssociations()
+	r, err = exchange.NewJSONLReader(strings.NewReader(jsonlContent))
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, r))
+
+	buf = bytes.Buffer{}
+	w, err = exchange.NewCSVWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Exec(db, w))
+	assert.Equal(t, csvContent, buf.String())
+
+	// the keys are the headers of the importer, whatever the keys of the first object
+	initTables()
+	initPhoneAssociations()
+	r, err = exchange.NewJSONLReader(strings.NewReader(`{"Code":"100","Name":"Orange13"}
+{"Code":"300","Name":"Pear100","ScreenType":"IPS","Extra":"x"}
+`), importer.Headers()...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Code", "Name", "ReleaseDate", "Width", "Height", "Depth", "ScreenSize", "ScreenType", "5G", "WirelessCharge", "Intro", "ExtraIntro", "FrontCamera", "BackCamera", "SellingOnJD", "SellingOnTaoBao"}, r.Header())
+	require.NoError(t, importer.Exec(db, r))
+	var phone Phone
+	require.NoError(t, db.Where("code = ?", "300").First(&phone).Error)
+	assert.Equal(t, "IPS", phone.Screen.Type)
+
+	// typed values, missing keys and blank lines
+	r, err = exchange.NewJSONLReader(strings.NewReader(`{"ID":1,"Name":"Tom","Age":6.5,"Tags":["a","b"],"Active":true}
+
+{"Name":"Jerry","ID":2,"Age":null}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "Name", "Age", "Tags", "Active"}, r.Header())
+	var rows [][]string
+	for r.Next() {
+		row, err := r.ReadRow()
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+	assert.Equal(t, [][]string{
+		{"1", "Tom", "6.5", `["a","b"]`, "true"},
+		{"2", "Jerry", "", "", ""},
+	}, rows)
+
+	// keys not in the first object would be lost
+	r, err = exchange.NewJSONLReader(strings.NewReader(`{"ID":1}
+{"ID":2,"Name":"Jerry"}
+`))
+	require.NoError(t, err)
+	assert.True(t, r.Next())
+	assert.True(t, r.Next())
+	_, err = r.ReadRow()
+	assert.EqualError(t, err, "line 2: key Name is not in the first line")
+
+	r, err = exchange.NewJSONLReader(strings.NewReader(`{"ID":1}
+[1]
+`))
+	require.NoError(t, err)
+	assert.True(t, r.Next())
+	assert.True(t, r.Next())
+	_, err = r.ReadRow()
+	assert.EqualError(t, err, "line 2: not an object")
+}
@@ -21,10 +21,63 @@ type maxParamsPerSQLOption struct {
 	v int
 }
 
+// ChunkSize sets how many records are exported per query.
+// For Importer it enables streaming: rows are read and imported in chunks of v rows,
+// each chunk in its own transaction, instead of reading all rows into memory and importing them in one transaction.
+func ChunkSize(v int) ExecOption {
+	return &chunkSizeOption{v}
+}
+
+type chunkSizeOption struct {
+	v int
+}
+
+// ProgressFunc is called with the number of rows processed so far and the total, which is 0 if unknown.
+type ProgressFunc func(processed, total uint)
+
+// Progress sets a callback called after each exported batch or imported chunk.
+func Progress(f ProgressFunc) ExecOption {
+	return &progressOption{f}
+}
+
+type progressOption struct {
+	f ProgressFunc
+}
+
 var (
 	_ ImporterExecOption = (*maxParamsPerSQLOption)(nil)
 	_ ExporterExecOption = (*maxParamsPerSQLOption)(nil)
+	_ ExecOption         = (*chunkSizeOption)(nil)
+	_ ExecOption         = (*progressOption)(nil)
 )
 
 func (o *maxParamsPerSQLOption) iePrivate() {}
 func (o *maxParamsPerSQLOption) eePrivate() {}
+func (o *chunkSizeOption) iePrivate()       {}
+func (o *chunkSizeOption) eePrivate()       {}
+func (o *progressOption) iePrivate()        {}
+func (o *progressOption) eePrivate()        {}
+
+// execOptions are the options shared by Importer.Exec and Exporter.Exec.
+type execOptions struct {
+	maxParamsPerSQL int
+	chunkSize       int
+	progress        ProgressFunc
+	// report and dryRun are only set for Importer
+	report *ImportReport
+	dryRun *DryRunResult
+}
+
+func (eo *execOptions) reportProgress(processed, total uint) {
+	if eo.progress != nil {
+		eo.progress(processed, total)
+	}
+}
+
+// reportErr returns the error of the report, the errors of the rows are in the result in a dry run.
+func (eo *execOptions) reportErr() error {
+	if eo.report == nil || eo.dryRun != nil {
+		return nil
+	}
+	return eo.report.Err()
+}
@@ -0,0 +1,231 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/qor5/x/v3/statusx"
+)
+
+const (
+	// ErrorReasonInvalidValue is the reason of the field violation of a value that failed a setter or a validator
+	ErrorReasonInvalidValue = "EXCHANGE_INVALID_VALUE"
+	// ErrorReasonInvalidRow is the reason of the field violation of a row that failed a validator
+	ErrorReasonInvalidRow = "EXCHANGE_INVALID_ROW"
+)
+
+// RowError is an error of an imported row.
+type RowError struct {
+	// Row is the number of the row in the file, the header being row 1
+	Row int
+	// Column is the number of the column starting from 1, 0 if the error is of the whole row
+	Column int
+	Header string
+	Value  string
+	Err    error
+
+	// violation is the field violation returned by the validator
+	violation *statusx.FieldViolation
+}
+
+func (e *RowError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %s: %s", e.Row, e.Header, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Field returns the field of the error in the FieldViolations, e.g. rows[3].Name or rows[3] for the whole row.
+func (e *RowError) Field() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("rows[%d]", e.Row)
+	}
+	return fmt.Sprintf("rows[%d].%s", e.Row, e.Header)
+}
+
+// FieldViolation returns the error as a field violation.
+// The reason and the localization of the field violation returned by the validator are kept,
+// others are localized by their reason with the row, the header and the value as arguments.
+func (e *RowError) FieldViolation() *statusx.FieldViolation {
+	if e.violation != nil {
+		fv := statusx.NewFieldViolation(e.Field(), e.violation.Reason(), e.violation.Description())
+		if l := e.violation.Localized(); l != nil && l.Key() != "" {
+			fv = fv.WithLocalized(l.Key(), l.Args()...)
+		}
+		return fv
+	}
+
+	reason := ErrorReasonInvalidValue
+	if e.Column == 0 {
+		reason = ErrorReasonInvalidRow
+	}
+	return statusx.NewFieldViolation(e.Field(), reason, e.Err.Error()).WithLocalizedArgs(e.Row, e.Header, e.Value)
+}
+
+// ImportReport is the report of Importer.Exec with the Report option.
+//
+// In the report mode every row is validated and set, and the errors of all rows are collected instead of
+// returning the first one. Nothing is imported if any row is invalid,
+// with ChunkSize only the invalid rows are not imported, so the error CSV holds the rows left to import.
+type ImportReport struct {
+	// Rows is the number of rows read
+	Rows   int
+	Errors []*RowError
+
+	header []string
+	// fieldColumns are the indexes of the columns of the metas by field
+	fieldColumns map[string]int
+	// invalidRows are the values of the invalid rows by number, for the error CSV
+	invalidRows map[int][]string
+}
+
+// Report sets the report to fill by Importer.Exec, which then collects the errors of all rows.
+// Exec returns the error of ImportReport.Err if any row is invalid.
+func Report(report *ImportReport) ImporterExecOption {
+	return &reportOption{report}
+}
+
+type reportOption struct {
+	report *ImportReport
+}
+
+var _ ImporterExecOption = (*reportOption)(nil)
+
+func (o *reportOption) iePrivate() {}
+
+// HasErrors reports whether any row is invalid.
+func (r *ImportReport) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// FieldViolations returns the errors as field violations.
+func (r *ImportReport) FieldViolations() statusx.FieldViolations {
+	fvs := make(statusx.FieldViolations, 0, len(r.Errors))
+	for _, e := range r.Errors {
+		fvs = append(fvs, e.FieldViolation())
+	}
+	return fvs
+}
+
+// Err returns an InvalidArgument statusx error with the field violations of the errors, or nil if no row is invalid.
+func (r *ImportReport) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	return statusx.BadRequest(r.FieldViolations()).Err()
+}
+
+// WriteErrorCSV writes the invalid rows as CSV with the header, annotated with their errors in an extra Errors column.
+// The file can be fixed and imported again, since the extra column is ignored by the Importer.
+func (r *ImportReport) WriteErrorCSV(w io.Writer) error {
+	if w == nil {
+		return errors.New("writer is nil")
+	}
+
+	messages := make(map[int][]string)
+	for _, e := range r.Errors {
+		msg := e.Err.Error()
+		if e.Column > 0 {
+			msg = e.Header + ": " + msg
+		}
+		messages[e.Row] = append(messages[e.Row], msg)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append(append([]string{}, r.header...), "Errors")); err != nil {
+		return err
+	}
+	nums := make([]int, 0, len(r.invalidRows))
+	for num := range r.invalidRows {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		values := make([]string, len(r.header), len(r.header)+1)
+		copy(values, r.invalidRows[num])
+		if err := cw.Write(append(values, strings.Join(messages[num], "; "))); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r *ImportReport) reset(header []string, headerIdxMetas map[int]*Meta) {
+	*r = ImportReport{
+		header:       header,
+		fieldColumns: make(map[string]int, len(headerIdxMetas)),
+		invalidRows:  make(map[int][]string),
+	}
+	for i, m := range headerIdxMetas {
+		r.fieldColumns[m.field] = i
+	}
+}
+
+// addError adds the error of the value of the column at index, or of the whole row if index is -1.
+func (r *ImportReport) addError(num int, row []string, index int, err error) {
+	r.addRowError(num, row, &RowError{
+		Row: num,
+		Err: err,
+	}, index)
+}
+
+// addValidatorError adds the error of a validator,
+// the field violations of statusx errors are added as errors of the columns of their meta fields.
+func (r *ImportReport) addValidatorError(num int, row []string, err error) {
+	fvs := statusx.ToFieldViolations(err, "")
+	if len(fvs) == 0 {
+		r.addError(num, row, -1, err)
+		return
+	}
+	for _, fv := range fvs {
+		r.addRowError(num, row, &RowError{
+			Row:       num,
+			Err:       errors.New(fv.Description()),
+			violation: fv,
+		}, r.column(fv.Field()))
+	}
+}
+
+// column returns the index of the column of the meta field, or -1 if it is not in the header.
+func (r *ImportReport) column(field string) int {
+	if index, ok := r.fieldColumns[field]; ok {
+		return index
+	}
+	return -1
+}
+
+func (r *ImportReport) addRowError(num int, row []string, e *RowError, index int) {
+	if index >= 0 {
+		e.Column = index + 1
+		if index < len(r.header) {
+			e.Header = r.header[index]
+		}
+		if index < len(row) {
+			e.Value = row[index]
+		}
+	}
+	r.Errors = append(r.Errors, e)
+
+	if _, ok := r.invalidRows[num]; !ok {
+		r.invalidRows[num] = append([]string{}, row...)
+	}
+}
+
+// sortErrors sorts the errors by row and column, since the values of a row are set after the rows are validated.
+func (r *ImportReport) sortErrors() {
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		if r.Errors[i].Row != r.Errors[j].Row {
+			return r.Errors[i].Row < r.Errors[j].Row
+		}
+		return r.Errors[i].Column < r.Errors[j].Column
+	})
+}
@@ -0,0 +1,416 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxDefaultSheet = "Sheet1"
+	// Excel keeps 15 significant digits, longer numbers are written as strings to not lose precision
+	xlsxMaxNumberDigits = 15
+)
+
+type xlsxOptions struct {
+	shortDatePattern string
+	plainStrings     bool
+}
+
+// XLSXOption configures how a workbook is read and written.
+type XLSXOption func(o *xlsxOptions)
+
+// XLSXShortDatePattern sets how cells with the built-in date formats are read, defaults to "yyyy-mm-dd".
+// The built-in formats follow the regional settings of Excel, so they are read with a fixed pattern instead.
+func XLSXShortDatePattern(pattern string) XLSXOption {
+	return func(o *xlsxOptions) {
+		o.shortDatePattern = pattern
+	}
+}
+
+// XLSXPlainStrings makes the writers write every value as a string cell, instead of typed cells.
+func XLSXPlainStrings() XLSXOption {
+	return func(o *xlsxOptions) {
+		o.plainStrings = true
+	}
+}
+
+// XLSXWorkbook reads and writes the sheets of an Excel workbook, one Reader or Writer per sheet.
+//
+// Writers write typed cells from the values of the metas:
+//   - TRUE and FALSE are written as booleans
+//   - numbers are written as numbers if they are written back the same, so "007" stays a string
+//   - values in the layouts "2006-01-02" and "2006-01-02 15:04:05" are written as dates
+//
+// Readers read the cells as displayed, so the values written are read back the same.
+type XLSXWorkbook struct {
+	f       *excelize.File
+	opts    *xlsxOptions
+	fresh   bool
+	styles  map[string]int
+	writers []*xlsxWriter
+}
+
+func newXLSXOptions(opts []XLSXOption) *xlsxOptions {
+	o := &xlsxOptions{
+		shortDatePattern: "yyyy-mm-dd",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// NewXLSXWorkbook creates an empty workbook to write sheets to.
+func NewXLSXWorkbook(opts ...XLSXOption) *XLSXWorkbook {
+	o := newXLSXOptions(opts)
+	return &XLSXWorkbook{
+		f:     excelize.NewFile(excelize.Options{ShortDatePattern: o.shortDatePattern}),
+		opts:  o,
+		fresh: true,
+	}
+}
+
+// OpenXLSXWorkbook opens the workbook read from r to read its sheets.
+func OpenXLSXWorkbook(r io.Reader, opts ...XLSXOption) (*XLSXWorkbook, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+
+	o := newXLSXOptions(opts)
+	f, err := excelize.OpenReader(r, excelize.Options{ShortDatePattern: o.shortDatePattern})
+	if err != nil {
+		return nil, err
+	}
+	return &XLSXWorkbook{
+		f:    f,
+		opts: o,
+	}, nil
+}
+
+// Sheets returns the names of the sheets in order.
+func (wb *XLSXWorkbook) Sheets() []string {
+	return wb.f.GetSheetList()
+}
+
+// Reader returns a Reader of the sheet, its first row is the header.
+func (wb *XLSXWorkbook) Reader(sheet string) (Reader, error) {
+	rows, err := wb.f.Rows(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	xr := &xlsxReader{
+		rows: rows,
+	}
+	if rows.Next() {
+		header, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		xr.header = trimStringSliceSpace(header)
+	}
+	if len(xr.header) == 0 {
+		rows.Close()
+		return nil, fmt.Errorf("sheet %s has no header", sheet)
+	}
+
+	// the dimension may be missing or wrong, it is only used as a hint
+	if dimension, err := wb.f.GetSheetDimension(sheet); err == nil {
+		if _, end, ok := strings.Cut(dimension, ":"); ok {
+			if _, row, err := excelize.CellNameToCoordinates(end); err == nil && row > 1 {
+				xr.total = uint(row - 1)
+			}
+		}
+	}
+	return xr, nil
+}
+
+// Writer returns a Writer of a new sheet, the first sheet written takes the place of the default empty sheet.
+// Rows are streamed to a temporary file until the workbook is written.
+func (wb *XLSXWorkbook) Writer(sheet string) (Writer, error) {
+	if wb.fresh {
+		wb.fresh = false
+		if sheet != xlsxDefaultSheet {
+			if err := wb.f.SetSheetName(xlsxDefaultSheet, sheet); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if idx, _ := wb.f.GetSheetIndex(sheet); idx != -1 {
+			return nil, fmt.Errorf("sheet %s already exists", sheet)
+		}
+		if _, err := wb.f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+	}
+
+	sw, err := wb.f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxWriter{
+		wb: wb,
+		sw: sw,
+	}
+	wb.writers = append(wb.writers, xw)
+	return xw, nil
+}
+
+// WriteTo writes the workbook to w, the Writers of the sheets must be flushed before.
+func (wb *XLSXWorkbook) WriteTo(w io.Writer) (int64, error) {
+	for _, xw := range wb.writers {
+		if !xw.flushed {
+			return 0, fmt.Errorf("sheet %s is not flushed", xw.sw.Sheet)
+		}
+	}
+	return wb.f.WriteTo(w)
+}
+
+// Close removes the temporary files of the workbook.
+func (wb *XLSXWorkbook) Close() error {
+	return wb.f.Close()
+}
+
+func (wb *XLSXWorkbook) style(key string, style *excelize.Style) (int, error) {
+	if id, ok := wb.styles[key]; ok {
+		return id, nil
+	}
+	id, err := wb.f.NewStyle(style)
+	if err != nil {
+		return 0, err
+	}
+	if wb.styles == nil {
+		wb.styles = make(map[string]int)
+	}
+	wb.styles[key] = id
+	return id, nil
+}
+
+// NewXLSXReader returns a Reader of the first sheet of the workbook read from r.
+// The workbook is loaded into memory, the rows of the sheet are read one by one.
+func NewXLSXReader(r io.Reader, opts ...XLSXOption) (Reader, error) {
+	wb, err := OpenXLSXWorkbook(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sheets := wb.Sheets()
+	if len(sheets) == 0 {
+		wb.Close()
+		return nil, errors.New("workbook has no sheet")
+	}
+	xr, err := wb.Reader(sheets[0])
+	if err != nil {
+		wb.Close()
+		return nil, err
+	}
+	xr.(*xlsxReader).wb = wb
+	return xr, nil
+}
+
+type xlsxReader struct {
+	// closed with the reader if opened by NewXLSXReader
+	wb     *XLSXWorkbook
+	rows   *excelize.Rows
+	header []string
+	row    []string
+	err    error
+	total  uint
+	done   bool
+}
+
+var _ Reader = (*xlsxReader)(nil)
+
+func (x *xlsxReader) Header() []string {
+	return x.header
+}
+
+func (x *xlsxReader) ReadRow() ([]string, error) {
+	if x.err != nil {
+		return nil, x.err
+	}
+	if x.row == nil {
+		return nil, errors.New("no more row")
+	}
+
+	return x.row, nil
+}
+
+// Next reads the next row that is not empty, a read error is returned by ReadRow.
+func (x *xlsxReader) Next() bool {
+	if x.done {
+		return false
+	}
+
+	for x.rows.Next() {
+		row, err := x.rows.Columns()
+		if err != nil {
+			x.err = err
+			x.close()
+			return true
+		}
+		row = trimStringSliceSpace(row)
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		x.row = row
+		return true
+	}
+	if err := x.rows.Error(); err != nil {
+		x.err = err
+		x.close()
+		return true
+	}
+	x.row = nil
+	x.close()
+	return false
+}
+
+// Total returns the rows of the sheet by its dimension, including empty rows that are skipped.
+func (x *xlsxReader) Total() uint {
+	return x.total
+}
+
+func (x *xlsxReader) close() {
+	x.done = true
+	x.rows.Close()
+	if x.wb != nil {
+		x.wb.Close()
+	}
+}
+
+// NewXLSXWriter returns a Writer writing a workbook with one sheet to w when flushed.
+func NewXLSXWriter(w io.Writer, opts ...XLSXOption) (Writer, error) {
+	if w == nil {
+		return nil, errors.New("writer is nil")
+	}
+
+	wb := NewXLSXWorkbook(opts...)
+	xw, err := wb.Writer(xlsxDefaultSheet)
+	if err != nil {
+		wb.Close()
+		return nil, err
+	}
+	xw.(*xlsxWriter).out = w
+	return xw, nil
+}
+
+type xlsxWriter struct {
+	wb *XLSXWorkbook
+	sw *excelize.StreamWriter
+	// the workbook is written to out when flushed if created by NewXLSXWriter
+	out     io.Writer
+	row     int
+	flushed bool
+}
+
+var _ Writer = (*xlsxWriter)(nil)
+
+func (x *xlsxWriter) WriteHeader(h []string) error {
+	styleID, err := x.wb.style("header", &excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	cells := make([]interface{}, 0, len(h))
+	for _, v := range h {
+		cells = append(cells, excelize.Cell{StyleID: styleID, Value: v})
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxWriter) WriteRow(r []string) error {
+	cells := make([]interface{}, 0, len(r))
+	for _, v := range r {
+		cell, err := x.cell(v)
+		if err != nil {
+			return err
+		}
+		cells = append(cells, cell)
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxWriter) setRow(cells []interface{}) error {
+	x.row++
+	axis, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(axis, cells)
+}
+
+// cell returns the typed cell of v.
+func (x *xlsxWriter) cell(v string) (interface{}, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if x.wb.opts.plainStrings {
+		return v, nil
+	}
+
+	switch v {
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	}
+	if isXLSXNumber(v) {
+		f, _ := strconv.ParseFloat(v, 64)
+		return f, nil
+	}
+	for _, df := range xlsxDateFormats {
+		t, err := time.Parse(df.layout, v)
+		if err != nil {
+			continue
+		}
+		styleID, err := x.wb.style(df.numFmt, &excelize.Style{CustomNumFmt: &df.numFmt})
+		if err != nil {
+			return nil, err
+		}
+		return excelize.Cell{StyleID: styleID, Value: t}, nil
+	}
+	return v, nil
+}
+
+// Flush ends the sheet, and writes the workbook if the Writer is created by NewXLSXWriter.
+func (x *xlsxWriter) Flush() error {
+	if !x.flushed {
+		if err := x.sw.Flush(); err != nil {
+			return err
+		}
+		x.flushed = true
+	}
+	if x.out == nil {
+		return nil
+	}
+
+	defer x.wb.Close()
+	out := x.out
+	x.out = nil
+	_, err := x.wb.WriteTo(out)
+	return err
+}
+
+var xlsxDateFormats = []struct {
+	layout string
+	numFmt string
+}{
+	{"2006-01-02", "yyyy-mm-dd"},
+	{"2006-01-02 15:04:05", "yyyy-mm-dd hh:mm:ss"},
+}
+
+// isXLSXNumber reports whether v is a number that is read back the same from a cell.
+func isXLSXNumber(v string) bool {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || strconv.FormatFloat(f, 'f', -1, 64) != v {
+		return false
+	}
+	digits := strings.TrimLeft(strings.Replace(strings.TrimPrefix(v, "-"), ".", "", 1), "0")
+	return len(digits) <= xlsxMaxNumberDigits
+}
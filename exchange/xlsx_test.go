@@ -0,0 +1,151 @@
+package exchange_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestXLSX(t *testing.T) {
+	initTables()
+	initPhoneAssociations()
+
+	importer := exchange.NewImporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+	exporter := exchange.NewExporter(&Phone{}).
+		Metas(phoneMetas...).
+		Associations(phoneAssociations...)
+	modelMetas := []*exchange.Meta{
+		exchange.NewMeta("ID").PrimaryKey(true),
+		exchange.NewMeta("Name"),
+		exchange.NewMeta("Appender"),
+	}
+
+	csvContent := `Code,Name,ReleaseDate,Width,Height,Depth,ScreenSize,ScreenType,5G,WirelessCharge,Intro,ExtraIntro,FrontCamera,BackCamera,SellingOnJD,SellingOnTaoBao
+100,Orange13,2021-01-01,80,180,8,6.5,IPS,FALSE,TRUE,yyds,eyyds,3000px,6000px,TRUE,FALSE
+200,DaMi11,2021-02-02,100,200,10,6.1,LCD,TRUE,FALSE,dddd,edddd,2000px,5000px,FALSE,TRUE
+300,Pear100,,,,,,,FALSE,FALSE,,,,,FALSE,FALSE
+`
+	r, err := exchange.NewCSVReader(ioutil.NopCloser(strings.NewReader(csvContent)))
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, r))
+	require.NoError(t, db.Create([]*TestExchangeModel{
+		{ID: 1, Name: "Tom", Appender: "007"},
+		{ID: 2, Name: "Jerry", Appender: "12345678901234567890"},
+	}).Error)
+
+	// export the tables to the sheets of a workbook
+	wb := exchange.NewXLSXWorkbook()
+	phonesW, err := wb.Writer("Phones")
+	require.NoError(t, err)
+	require.NoError(t, exporter.Exec(db, phonesW))
+	modelsW, err := wb.Writer("Models")
+	require.NoError(t, err)
+	require.NoError(t, exchange.NewExporter(&TestExchangeModel{}).Metas(modelMetas...).Exec(db, modelsW))
+	buf := bytes.Buffer{}
+	_, err = wb.WriteTo(&buf)
+	require.NoError(t, err)
+	require.NoError(t, wb.Close())
+	xlsxContent := buf.Bytes()
+
+	// typed cells
+	f, err := excelize.OpenReader(bytes.NewReader(xlsxContent))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Phones", "Models"}, f.GetSheetList())
+	for _, c := range []struct {
+		sheet string
+		cell  string
+		typ   excelize.CellType
+		raw   string
+	}{
+		// numbers are cells without a type
+		{"Phones", "A2", excelize.CellTypeUnset, "100"},
+		{"Phones", "B2", excelize.CellTypeInlineString, "Orange13"},
+		{"Phones", "C2", excelize.CellTypeUnset, "44197"},
+		{"Phones", "G2", excelize.CellTypeUnset, "6.5"},
+		{"Phones", "I2", excelize.CellTypeBool, "0"},
+		{"Models", "C2", excelize.CellTypeInlineString, "007"},
+		{"Models", "C3", excelize.CellTypeInlineString, "12345678901234567890"},
+	} {
+		typ, err := f.GetCellType(c.sheet, c.cell)
+		assert.NoError(t, err)
+		assert.Equal(t, c.typ, typ, c.cell)
+		raw, err := f.GetCellValue(c.sheet, c.cell, excelize.Options{RawCellValue: true})
+		assert.NoError(t, err)
+		assert.Equal(t, c.raw, raw, c.cell)
+	}
+	require.NoError(t, f.Close())
+
+	// import the sheets back
+	initTables()
+	initPhoneAssociations()
+	rwb, err := exchange.OpenXLSXWorkbook(bytes.NewReader(xlsxContent))
+	require.NoError(t, err)
+	defer rwb.Close()
+	phonesR, err := rwb.Reader("Phones")
+	require.NoError(t, err)
+	require.NoError(t, importer.Exec(db, phonesR))
+	modelsR, err := rwb.Reader("Models")
+	require.NoError(t, err)
+	require.NoError(t, exchange.NewImporter(&TestExchangeModel{}).Metas(modelMetas...).Exec(db, modelsR))
+
+	buf = bytes.Buffer{}
+	w, err := exchange.NewCSVWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Exec(db, w))
+	assert.Equal(t, csvContent, buf.String())
+
+	var models []*TestExchangeModel
+	require.NoError(t, db.Order("id").Find(&models).Error)
+	require.Len(t, models, 2)
+	assert.Equal(t, "007", models[0].Appender)
+	assert.Equal(t, "12345678901234567890", models[1].Appender)
+
+	// a single sheet, with a date typed in Excel
+	f = excelize.NewFile()
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]string{"ID", "Name", "Birth"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]interface{}{3, "Spike", time.Date(1941, 3, 4, 0, 0, 0, 0, time.UTC)}))
+	require.NoError(t, f.SetCellStyle("Sheet1", "C2", "C2", dateStyle))
+	// Excel saves the dimension of the sheet, Total is read from it
+	require.NoError(t, f.SetSheetDimension("Sheet1", "A1:C2"))
+	buf = bytes.Buffer{}
+	_, err = f.WriteTo(&buf)
+	require.NoError(t, err)
+
+	r, err = exchange.NewXLSXReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "Name", "Birth"}, r.Header())
+	assert.Equal(t, uint(1), r.Total())
+	assert.True(t, r.Next())
+	row, err := r.ReadRow()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "Spike", "1941-03-04"}, row)
+	assert.False(t, r.Next())
+
+	buf = bytes.Buffer{}
+	w, err = exchange.NewXLSXWriter(&buf, exchange.XLSXPlainStrings())
+	require.NoError(t, err)
+	require.NoError(t, exchange.NewExporter(&TestExchangeModel{}).Metas(modelMetas...).Exec(db, w))
+	f, err = excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	typ, err := f.GetCellType("Sheet1", "A2")
+	assert.NoError(t, err)
+	assert.Equal(t, excelize.CellTypeInlineString, typ)
+	rows, err := f.GetRows("Sheet1")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"ID", "Name", "Appender"},
+		{"1", "Tom", "007"},
+		{"2", "Jerry", "12345678901234567890"},
+	}, rows)
+}
@@ -0,0 +1,76 @@
+package postgresx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// IsPostgres reports whether db is connected to PostgreSQL.
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector != nil && db.Dialector.Name() == "postgres"
+}
+
+// Notify sends a notification with payload on channel.
+// Within a transaction the notification is only delivered once the transaction commits.
+func Notify(ctx context.Context, db *gorm.DB, channel, payload string) error {
+	return db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+// Listen listens on channel with a dedicated connection of db and calls onNotify for every notification,
+// until ctx is done or the connection fails. onListen, if not nil, is called once the connection is listening,
+// notifications sent before that are not received, so callers should resync their state in it.
+//
+// The connection is discarded afterwards instead of returning to the pool. Callers usually
+// call Listen in a loop to reconnect:
+//
+//	for ctx.Err() == nil {
+//	    err := postgresx.Listen(ctx, db, "changes", resync, handle)
+//	    ...
+//	}
+func Listen(ctx context.Context, db *gorm.DB, channel string, onListen func(), onNotify func(payload string)) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		listenErr = listen(ctx, driverConn, channel, onListen, onNotify)
+		// Discard the connection, it is still subscribed to the channel
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func listen(ctx context.Context, driverConn any, channel string, onListen func(), onNotify func(payload string)) error {
+	stdConn, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return fmt.Errorf("listen requires the pgx driver, got %T", driverConn)
+	}
+	conn := stdConn.Conn()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	if onListen != nil {
+		onListen()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onNotify(notification.Payload)
+	}
+}
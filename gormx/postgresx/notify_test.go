@@ -0,0 +1,44 @@
+package postgresx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/gormx/postgresx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenNotify(t *testing.T) {
+	db := suite.DB()
+	require.True(t, postgresx.IsPostgres(db))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listening := make(chan struct{})
+	payloads := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- postgresx.Listen(ctx, db, "postgresx test", func() { close(listening) }, func(payload string) {
+			payloads <- payload
+		})
+	}()
+
+	select {
+	case <-listening:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not listening")
+	}
+	require.NoError(t, postgresx.Notify(ctx, db, "postgresx test", "hello"))
+
+	select {
+	case payload := <-payloads:
+		require.Equal(t, "hello", payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not received")
+	}
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
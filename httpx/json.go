@@ -0,0 +1,20 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteJSON writes data encoded as JSON with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// WriteJSONError writes {"error": err.Error()} with the given status code.
+func WriteJSONError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, map[string]any{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,97 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DiffOp is the kind of a Difference, named after the JSON Patch (RFC6902) operations.
+type DiffOp string
+
+const (
+	DiffOpAdd     DiffOp = "add"
+	DiffOpRemove  DiffOp = "remove"
+	DiffOpReplace DiffOp = "replace"
+)
+
+// Difference is a value that differs between two JSON documents.
+type Difference struct {
+	Op DiffOp `json:"op"`
+	// Path is the JSON Pointer (RFC6901) of the value
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Diff marshals a and b with Marshal and returns their differences ordered by path.
+// Objects are compared field by field, other values, including arrays, are compared as a whole.
+func Diff(a, b any) ([]Difference, error) {
+	aValue, err := toValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bValue, err := toValue(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []Difference
+	diff(&diffs, "", aValue, bValue)
+	return diffs, nil
+}
+
+func toValue(v any) (any, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return value, nil
+}
+
+func diff(diffs *[]Difference, path string, a, b any) {
+	aObject, aIsObject := a.(map[string]any)
+	bObject, bIsObject := b.(map[string]any)
+	if !aIsObject || !bIsObject {
+		if !reflect.DeepEqual(a, b) {
+			*diffs = append(*diffs, Difference{Op: DiffOpReplace, Path: path, Old: a, New: b})
+		}
+		return
+	}
+
+	keys := slices.Collect(maps.Keys(aObject))
+	for k := range bObject {
+		if _, ok := aObject[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		childPath := path + "/" + escapePointer(k)
+		aChild, inA := aObject[k]
+		bChild, inB := bObject[k]
+		switch {
+		case !inB:
+			*diffs = append(*diffs, Difference{Op: DiffOpRemove, Path: childPath, Old: aChild})
+		case !inA:
+			*diffs = append(*diffs, Difference{Op: DiffOpAdd, Path: childPath, New: bChild})
+		default:
+			diff(diffs, childPath, aChild, bChild)
+		}
+	}
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func escapePointer(key string) string {
+	return pointerEscaper.Replace(key)
+}
@@ -0,0 +1,59 @@
+package jsonx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	type Server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type Config struct {
+		Name    string            `json:"name"`
+		Server  Server            `json:"server"`
+		Tags    []string          `json:"tags"`
+		Labels  map[string]string `json:"labels,omitempty"`
+		Enabled *bool             `json:"enabled,omitempty"`
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		diffs, err := Diff(Config{Name: "a"}, &Config{Name: "a"})
+		require.NoError(t, err)
+		assert.Empty(t, diffs)
+	})
+
+	t.Run("nested changes", func(t *testing.T) {
+		enabled := true
+		a := Config{Name: "a", Server: Server{Host: "localhost", Port: 80}, Tags: []string{"x"}, Labels: map[string]string{"a/b": "1", "c": "2"}}
+		b := Config{Name: "a", Server: Server{Host: "localhost", Port: 8080}, Tags: []string{"x", "y"}, Labels: map[string]string{"c": "3"}, Enabled: &enabled}
+
+		diffs, err := Diff(a, b)
+		require.NoError(t, err)
+		assert.Equal(t, []Difference{
+			{Op: DiffOpAdd, Path: "/enabled", New: true},
+			{Op: DiffOpRemove, Path: "/labels/a~1b", Old: "1"},
+			{Op: DiffOpReplace, Path: "/labels/c", Old: "2", New: "3"},
+			{Op: DiffOpReplace, Path: "/server/port", Old: float64(80), New: float64(8080)},
+			{Op: DiffOpReplace, Path: "/tags", Old: []any{"x"}, New: []any{"x", "y"}},
+		}, diffs)
+	})
+
+	t.Run("different types", func(t *testing.T) {
+		diffs, err := Diff(map[string]any{"v": map[string]any{"a": 1}}, map[string]any{"v": "a"})
+		require.NoError(t, err)
+		assert.Equal(t, []Difference{{Op: DiffOpReplace, Path: "/v", Old: map[string]any{"a": float64(1)}, New: "a"}}, diffs)
+
+		diffs, err = Diff(1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []Difference{{Op: DiffOpReplace, Path: "", Old: float64(1), New: float64(2)}}, diffs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Diff(func() {}, 1)
+		assert.Error(t, err)
+	})
+}
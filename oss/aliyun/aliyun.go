@@ -2,11 +2,15 @@ package aliyun
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +18,8 @@ import (
 	"github.com/qor5/x/v3/oss"
 )
 
+var _ oss.PageLister = (*Client)(nil)
+
 // Client Aliyun storage
 type Client struct {
 	*aliyun.Bucket
@@ -30,6 +36,8 @@ type Config struct {
 	ACL           aliyun.ACLType
 	ClientOptions []aliyun.ClientOption
 	UseCname      bool
+	// Multipart makes Put upload large content in parallel parts
+	Multipart *oss.MultipartConfig
 }
 
 // New initialize Aliyun storage
@@ -81,26 +89,115 @@ func (client Client) Get(ctx context.Context, path string) (file *os.File, err e
 
 // GetStream get file as stream
 func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
-	return client.Bucket.GetObject(client.ToRelativePath(path))
+	readCloser, err := client.Bucket.GetObject(client.ToRelativePath(path))
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+	return readCloser, nil
 }
 
 // Put store a reader into given path
-func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader) (*oss.Object, error) {
+func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
 	if seeker, ok := reader.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
 
-	err := client.Bucket.PutObject(client.ToRelativePath(urlPath), reader, aliyun.ACL(client.Config.ACL))
+	reader, multipart, err := oss.ExceedsMultipartThreshold(reader, client.Config.Multipart)
+	if err != nil {
+		return nil, err
+	}
+	if multipart {
+		return oss.PutMultipart(ctx, client, urlPath, reader, client.Config.Multipart, opts...)
+	}
+
+	o := oss.NewPutOptions(opts...)
+	counter := &countingReader{Reader: reader}
+	err = client.Bucket.PutObject(client.ToRelativePath(urlPath), counter, client.putOptions(o)...)
 	now := time.Now()
 
 	return &oss.Object{
-		Path:             urlPath,
-		Name:             filepath.Base(urlPath),
-		LastModified:     &now,
-		StorageInterface: client,
+		Path:               urlPath,
+		Name:               filepath.Base(urlPath),
+		LastModified:       &now,
+		Size:               counter.n,
+		ContentType:        oss.DetectContentType(o.ContentType, urlPath, nil),
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		Metadata:           o.Metadata,
+		StorageInterface:   client,
 	}, err
 }
 
+func (client Client) putOptions(o *oss.PutOptions) []aliyun.Option {
+	options := []aliyun.Option{aliyun.ACL(client.Config.ACL)}
+	if o.ContentType != "" {
+		options = append(options, aliyun.ContentType(o.ContentType))
+	}
+	if o.CacheControl != "" {
+		options = append(options, aliyun.CacheControl(o.CacheControl))
+	}
+	if o.ContentDisposition != "" {
+		options = append(options, aliyun.ContentDisposition(o.ContentDisposition))
+	}
+	for k, v := range o.Metadata {
+		options = append(options, aliyun.Meta(k, v))
+	}
+	return options
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Stat get object's metadata with a HEAD request
+func (client Client) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	key := client.ToRelativePath(path)
+	header, err := client.Bucket.GetObjectDetailedMeta(key, aliyun.WithContext(ctx))
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	size, _ := strconv.ParseInt(header.Get(aliyun.HTTPHeaderContentLength), 10, 64)
+	object := &oss.Object{
+		Path:               "/" + key,
+		Name:               filepath.Base(key),
+		Size:               size,
+		ContentType:        header.Get(aliyun.HTTPHeaderContentType),
+		CacheControl:       header.Get(aliyun.HTTPHeaderCacheControl),
+		ContentDisposition: header.Get(aliyun.HTTPHeaderContentDisposition),
+		ETag:               strings.Trim(header.Get(aliyun.HTTPHeaderEtag), `"`),
+		StorageInterface:   client,
+	}
+	if lastModified, err := http.ParseTime(header.Get(aliyun.HTTPHeaderLastModified)); err == nil {
+		object.LastModified = &lastModified
+	}
+	for k := range header {
+		if name, ok := strings.CutPrefix(k, aliyun.HTTPHeaderOssMetaPrefix); ok {
+			if object.Metadata == nil {
+				object.Metadata = map[string]string{}
+			}
+			object.Metadata[strings.ToLower(name)] = header.Get(k)
+		}
+	}
+	return object, nil
+}
+
+// wrapNotFound wrap the errors of missing objects with oss.ErrNotFound
+func wrapNotFound(err error) error {
+	var serviceErr aliyun.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", oss.ErrNotFound, err)
+	}
+	return err
+}
+
 // Delete delete file
 func (client Client) Delete(ctx context.Context, path string) error {
 	return client.Bucket.DeleteObject(client.ToRelativePath(path))
@@ -108,22 +205,47 @@ func (client Client) Delete(ctx context.Context, path string) error {
 
 // List list all objects under current path
 func (client Client) List(ctx context.Context, path string) ([]*oss.Object, error) {
-	var objects []*oss.Object
+	return oss.ListAll(ctx, client, &oss.ListOptions{Prefix: oss.DirPrefix(path)})
+}
 
-	results, err := client.Bucket.ListObjects(aliyun.Prefix(path))
+// ListPage list a page of objects with ListObjectsV2
+func (client Client) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	options := []aliyun.Option{
+		aliyun.Prefix(client.ToRelativePath(opts.Prefix)),
+		aliyun.MaxKeys(opts.GetMaxKeys()),
+		aliyun.WithContext(ctx),
+	}
+	if opts.Delimiter != "" {
+		options = append(options, aliyun.Delimiter(opts.Delimiter))
+	}
+	if opts.ContinuationToken != "" {
+		options = append(options, aliyun.ContinuationToken(opts.ContinuationToken))
+	}
 
-	if err == nil {
-		for _, obj := range results.Objects {
-			objects = append(objects, &oss.Object{
-				Path:             "/" + client.ToRelativePath(obj.Key),
-				Name:             filepath.Base(obj.Key),
-				LastModified:     &obj.LastModified,
-				StorageInterface: client,
-			})
-		}
+	results, err := client.Bucket.ListObjectsV2(options...)
+	if err != nil {
+		return nil, err
 	}
 
-	return objects, err
+	result := &oss.ListResult{
+		IsTruncated:           results.IsTruncated,
+		NextContinuationToken: results.NextContinuationToken,
+	}
+	for _, obj := range results.Objects {
+		lastModified := obj.LastModified
+		result.Objects = append(result.Objects, &oss.Object{
+			Path:             "/" + client.ToRelativePath(obj.Key),
+			Name:             filepath.Base(obj.Key),
+			LastModified:     &lastModified,
+			Size:             obj.Size,
+			ETag:             strings.Trim(obj.ETag, `"`),
+			StorageInterface: client,
+		})
+	}
+	for _, commonPrefix := range results.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, "/"+client.ToRelativePath(commonPrefix))
+	}
+	return result, nil
 }
 
 // GetEndpoint get endpoint, FileSystem's endpoint is /
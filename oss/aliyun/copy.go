@@ -0,0 +1,38 @@
+package aliyun
+
+import (
+	"context"
+	"slices"
+
+	aliyun "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.Copier       = (*Client)(nil)
+	_ oss.BatchDeleter = (*Client)(nil)
+)
+
+// maxDeleteObjects is the maximum number of keys of a DeleteObjects request
+const maxDeleteObjects = 1000
+
+// Copy copy an object inside the bucket
+func (client Client) Copy(ctx context.Context, from, to string) error {
+	_, err := client.Bucket.CopyObject(client.ToRelativePath(from), client.ToRelativePath(to), aliyun.WithContext(ctx))
+	return wrapNotFound(err)
+}
+
+// DeleteObjects delete objects in batches of 1000, missing objects are ignored
+func (client Client) DeleteObjects(ctx context.Context, paths []string) error {
+	keys := make([]string, 0, len(paths))
+	for _, path := range paths {
+		keys = append(keys, client.ToRelativePath(path))
+	}
+
+	for batch := range slices.Chunk(keys, maxDeleteObjects) {
+		if _, err := client.Bucket.DeleteObjects(batch, aliyun.DeleteObjectsQuiet(true), aliyun.WithContext(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
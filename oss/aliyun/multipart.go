@@ -0,0 +1,96 @@
+package aliyun
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	aliyun "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.MultipartUploader = (*Client)(nil)
+
+func (client Client) multipartUpload(path string, uploadID string) aliyun.InitiateMultipartUploadResult {
+	return aliyun.InitiateMultipartUploadResult{
+		Bucket:   client.Config.Bucket,
+		Key:      client.ToRelativePath(path),
+		UploadID: uploadID,
+	}
+}
+
+// InitiateMultipartUpload start a multipart upload
+func (client Client) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	options := append(client.putOptions(oss.NewPutOptions(opts...)), aliyun.WithContext(ctx))
+	imur, err := client.Bucket.InitiateMultipartUpload(client.ToRelativePath(path), options...)
+	if err != nil {
+		return "", err
+	}
+	return imur.UploadID, nil
+}
+
+// UploadPart upload a part of a multipart upload
+func (client Client) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	part, err := client.Bucket.UploadPart(client.multipartUpload(path, uploadID), reader, size, partNumber, aliyun.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &oss.Part{PartNumber: part.PartNumber, ETag: part.ETag, Size: size}, nil
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (client Client) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	var (
+		parts  []*oss.Part
+		marker int
+	)
+
+	for {
+		result, err := client.Bucket.ListUploadedParts(client.multipartUpload(path, uploadID), aliyun.PartNumberMarker(marker), aliyun.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range result.UploadedParts {
+			lastModified := part.LastModified
+			parts = append(parts, &oss.Part{
+				PartNumber:   part.PartNumber,
+				ETag:         part.ETag,
+				Size:         int64(part.Size),
+				LastModified: &lastModified,
+			})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		if marker, err = strconv.Atoi(result.NextPartNumberMarker); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CompleteMultipartUpload assemble the uploaded parts into an object
+func (client Client) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	uploaded := make([]aliyun.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, aliyun.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	if _, err := client.Bucket.CompleteMultipartUpload(client.multipartUpload(path, uploadID), uploaded, aliyun.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &oss.Object{
+		Path:             path,
+		Name:             filepath.Base(path),
+		LastModified:     &now,
+		StorageInterface: client,
+	}, nil
+}
+
+// AbortMultipartUpload abort a multipart upload and remove its parts
+func (client Client) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	return client.Bucket.AbortMultipartUpload(client.multipartUpload(path, uploadID), aliyun.WithContext(ctx))
+}
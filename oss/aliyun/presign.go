@@ -0,0 +1,135 @@
+package aliyun
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	aliyun "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.Presigner = (*Client)(nil)
+
+// PresignPut generate a presigned upload request, uploads limited by MaxSize use a PostObject policy
+func (client Client) PresignPut(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+	key := client.ToRelativePath(path)
+	expiresAt := time.Now().Add(o.Expires)
+
+	if o.MaxSize > 0 {
+		return client.presignPost(key, o, expiresAt)
+	}
+
+	options := []aliyun.Option{aliyun.ObjectACL(client.Config.ACL)}
+	header := http.Header{}
+	header.Set(aliyun.HTTPHeaderOssObjectACL, string(client.Config.ACL))
+	if o.ContentType != "" {
+		options = append(options, aliyun.ContentType(o.ContentType))
+		header.Set(aliyun.HTTPHeaderContentType, o.ContentType)
+	}
+
+	signedURL, err := client.Bucket.SignURL(key, aliyun.HTTPPut, int64(o.Expires.Seconds()), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PresignedRequest{
+		Method:    http.MethodPut,
+		URL:       signedURL,
+		Header:    header,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignGet generate a presigned download request
+func (client Client) PresignGet(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+
+	var options []aliyun.Option
+	if o.ContentType != "" {
+		options = append(options, aliyun.ResponseContentType(o.ContentType))
+	}
+	if o.ContentDisposition != "" {
+		options = append(options, aliyun.ResponseContentDisposition(o.ContentDisposition))
+	}
+
+	signedURL, err := client.Bucket.SignURL(client.ToRelativePath(path), aliyun.HTTPGet, int64(o.Expires.Seconds()), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PresignedRequest{
+		Method:    http.MethodGet,
+		URL:       signedURL,
+		ExpiresAt: time.Now().Add(o.Expires),
+	}, nil
+}
+
+// presignPost build a PostObject form upload, see https://help.aliyun.com/document_detail/31988.html
+func (client Client) presignPost(key string, o *oss.PresignOptions, expiresAt time.Time) (*oss.PresignedRequest, error) {
+	conditions := []any{
+		map[string]string{"bucket": client.Config.Bucket},
+		[]any{"eq", "$key", key},
+		[]any{"content-length-range", 0, o.MaxSize},
+	}
+	if o.ContentType != "" {
+		conditions = append(conditions, []any{"eq", "$Content-Type", o.ContentType})
+	}
+
+	policy, err := json.Marshal(map[string]any{
+		"expiration": expiresAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := client.Bucket.Client.Config.GetCredentials()
+	encodedPolicy := base64.StdEncoding.EncodeToString(policy)
+	mac := hmac.New(sha1.New, []byte(credentials.GetAccessKeySecret()))
+	mac.Write([]byte(encodedPolicy))
+
+	formData := map[string]string{
+		"key":                   key,
+		"OSSAccessKeyId":        credentials.GetAccessKeyID(),
+		"policy":                encodedPolicy,
+		"Signature":             base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+		"x-oss-object-acl":      string(client.Config.ACL),
+		"success_action_status": "204",
+	}
+	if token := credentials.GetSecurityToken(); token != "" {
+		formData["x-oss-security-token"] = token
+	}
+	if o.ContentType != "" {
+		formData["Content-Type"] = o.ContentType
+	}
+
+	return &oss.PresignedRequest{
+		Method:    http.MethodPost,
+		URL:       client.bucketURL(),
+		FormData:  formData,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (client Client) bucketURL() string {
+	scheme := "https://"
+	endpoint := client.Bucket.Client.Config.Endpoint
+	if strings.HasPrefix(endpoint, "http://") {
+		scheme = "http://"
+	}
+	for _, prefix := range []string{"https://", "http://"} {
+		endpoint = strings.TrimPrefix(endpoint, prefix)
+	}
+
+	if client.Config.UseCname {
+		return scheme + endpoint + "/"
+	}
+	return scheme + client.Config.Bucket + "." + endpoint + "/"
+}
@@ -0,0 +1,80 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Copier is implemented by storages that copy objects server side
+type Copier interface {
+	Copy(ctx context.Context, from, to string) error
+}
+
+// Mover is implemented by storages that move objects server side
+type Mover interface {
+	Move(ctx context.Context, from, to string) error
+}
+
+// BatchDeleter is implemented by storages that delete several objects in a request, missing objects are ignored
+type BatchDeleter interface {
+	DeleteObjects(ctx context.Context, paths []string) error
+}
+
+// Copy copy an object inside storage, storages that are not a Copier are copied with StreamCopy
+func Copy(ctx context.Context, storage StorageInterface, from, to string) error {
+	if copier, ok := As[Copier](storage); ok {
+		return copier.Copy(ctx, from, to)
+	}
+	_, err := StreamCopy(ctx, storage, from, storage, to)
+	return err
+}
+
+// Move move an object inside storage, storages that are not a Mover are copied with Copy and the source is deleted
+func Move(ctx context.Context, storage StorageInterface, from, to string) error {
+	if mover, ok := As[Mover](storage); ok {
+		return mover.Move(ctx, from, to)
+	}
+	if err := Copy(ctx, storage, from, to); err != nil {
+		return err
+	}
+	return storage.Delete(ctx, from)
+}
+
+// DeleteObjects delete objects, missing objects are ignored.
+// Storages that are not a BatchDeleter delete the objects one by one, all failures are joined.
+func DeleteObjects(ctx context.Context, storage StorageInterface, paths []string) error {
+	if deleter, ok := As[BatchDeleter](storage); ok {
+		return deleter.DeleteObjects(ctx, paths)
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if err := storage.Delete(ctx, path); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("delete %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StreamCopy copy an object by streaming its content from src to dst, which may be different storages.
+// Content type, cache control, content disposition and metadata are kept when src supports Stat.
+func StreamCopy(ctx context.Context, src StorageInterface, from string, dst StorageInterface, to string) (*Object, error) {
+	var opts []PutOption
+	if object, err := src.Stat(ctx, from); err == nil {
+		opts = append(opts, PutContentType(object.ContentType), PutCacheControl(object.CacheControl), PutContentDisposition(object.ContentDisposition))
+		if len(object.Metadata) > 0 {
+			opts = append(opts, PutMetadata(object.Metadata))
+		}
+	} else if errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	stream, err := src.GetStream(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	return dst.Put(ctx, to, stream, opts...)
+}
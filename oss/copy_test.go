@@ -0,0 +1,52 @@
+package oss_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plain hides the optional interfaces of the wrapped storage
+type plain struct {
+	oss.StorageInterface
+}
+
+func TestCopyFallback(t *testing.T) {
+	ctx := context.Background()
+	for name, storage := range map[string]oss.StorageInterface{
+		"native":   memory.New(),
+		"fallback": plain{memory.New()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := storage.Put(ctx, "/a.txt", strings.NewReader("a"), oss.PutContentType("text/x-a"), oss.PutMetadata(map[string]string{"k": "v"}))
+			require.NoError(t, err)
+
+			require.NoError(t, oss.Copy(ctx, storage, "/a.txt", "/b.txt"))
+			object, err := storage.Stat(ctx, "/b.txt")
+			require.NoError(t, err)
+			assert.Equal(t, "text/x-a", object.ContentType)
+			assert.Equal(t, map[string]string{"k": "v"}, object.Metadata)
+
+			require.NoError(t, oss.Move(ctx, storage, "/b.txt", "/c/b.txt"))
+			_, err = storage.Stat(ctx, "/b.txt")
+			assert.ErrorIs(t, err, oss.ErrNotFound)
+			stream, err := storage.GetStream(ctx, "/c/b.txt")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(stream)
+			assert.Equal(t, "a", string(data))
+
+			assert.ErrorIs(t, oss.Copy(ctx, storage, "/missing.txt", "/d.txt"), oss.ErrNotFound)
+
+			require.NoError(t, oss.DeleteObjects(ctx, storage, []string{"/a.txt", "/c/b.txt", "/missing.txt"}))
+			objects, err := storage.List(ctx, "")
+			require.NoError(t, err)
+			assert.Empty(t, objects)
+		})
+	}
+}
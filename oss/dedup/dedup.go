@@ -0,0 +1,346 @@
+// Package dedup stores identical contents once. Objects are kept as blobs named by the SHA-256 of their
+// content in an underlying storage, and a path→hash index with reference counts lives in the database.
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/oss"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	_ oss.StorageInterface = (*Storage)(nil)
+	_ oss.Copier           = (*Storage)(nil)
+	_ oss.Mover            = (*Storage)(nil)
+)
+
+// DefaultBlobPrefix is where blobs are stored in the underlying storage
+const DefaultBlobPrefix = "/blobs"
+
+// Storage content-addressed storage, safe for concurrent use
+type Storage struct {
+	db         *gorm.DB
+	blobs      oss.StorageInterface
+	blobPrefix string
+}
+
+// Option configures a Storage.
+type Option func(*Storage)
+
+// WithBlobPrefix sets the path under which blobs are stored, defaults to DefaultBlobPrefix.
+func WithBlobPrefix(prefix string) Option {
+	return func(s *Storage) {
+		s.blobPrefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// New creates a Storage keeping its index in db and its blobs in blobs.
+// The tables must exist, see AutoMigrate.
+func New(db *gorm.DB, blobs oss.StorageInterface, opts ...Option) *Storage {
+	s := &Storage{db: db, blobs: blobs, blobPrefix: DefaultBlobPrefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Blobs returns the storage holding the blobs.
+func (s *Storage) Blobs() oss.StorageInterface {
+	return s.blobs
+}
+
+func key(path string) string {
+	return "/" + strings.TrimPrefix(path, "/")
+}
+
+// BlobPath returns where the blob of a hash is stored, fanned out by its first bytes.
+func (s *Storage) BlobPath(hash string) string {
+	return s.blobPrefix + "/" + hash[:2] + "/" + hash[2:4] + "/" + hash
+}
+
+func (s *Storage) ref(ctx context.Context, path string) (*Ref, error) {
+	var ref Ref
+	if err := s.db.WithContext(ctx).Where("path = ?", key(path)).First(&ref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(oss.ErrNotFound, "path %s", path)
+		}
+		return nil, errors.Wrap(err, "failed to load ref")
+	}
+	return &ref, nil
+}
+
+// Blob returns the blob referenced by path.
+func (s *Storage) Blob(ctx context.Context, path string) (*Blob, error) {
+	ref, err := s.ref(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	var blob Blob
+	if err := s.db.WithContext(ctx).Where("hash = ?", ref.Hash).First(&blob).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to load blob")
+	}
+	return &blob, nil
+}
+
+// Get receive file with given path
+func (s *Storage) Get(ctx context.Context, path string) (*os.File, error) {
+	ref, err := s.ref(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.blobs.Get(ctx, s.BlobPath(ref.Hash))
+}
+
+// GetStream get file as stream
+func (s *Storage) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	ref, err := s.ref(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.blobs.GetStream(ctx, s.BlobPath(ref.Hash))
+}
+
+// Put store a reader into given path, the content is uploaded only if no blob has the same hash.
+// The content is hashed while it's spooled to a temporary file.
+func (s *Storage) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		seeker.Seek(0, 0)
+	}
+
+	tmp, err := os.CreateTemp("", "dedup")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read content")
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	// touch the blob first, so GC skips it while it's uploaded and referenced
+	blob := &Blob{Hash: hash, Size: size}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at"}),
+	}).Create(blob).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to save blob")
+	}
+
+	blobPath := s.BlobPath(hash)
+	if _, err := s.blobs.Stat(ctx, blobPath); err != nil {
+		if !errors.Is(err, oss.ErrNotFound) {
+			return nil, errors.Wrap(err, "failed to stat blob")
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, "failed to rewind content")
+		}
+		if _, err := s.blobs.Put(ctx, blobPath, tmp); err != nil {
+			return nil, errors.Wrap(err, "failed to upload blob")
+		}
+	}
+
+	o := oss.NewPutOptions(opts...)
+	ref := &Ref{
+		Path:               key(path),
+		Hash:               hash,
+		ContentType:        oss.DetectContentType(o.ContentType, path, nil),
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		Metadata:           o.Metadata,
+	}
+	if err := gormx.Transaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		return saveRef(tx, ref)
+	}); err != nil {
+		return nil, err
+	}
+	return s.object(ref, size), nil
+}
+
+// saveRef creates or replaces the ref of ref.Path and updates the reference counts
+func saveRef(tx *gorm.DB, ref *Ref) error {
+	var previous Ref
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", ref.Path).First(&previous).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Create(ref).Error; err != nil {
+			return errors.Wrap(err, "failed to create ref")
+		}
+	case err != nil:
+		return errors.Wrap(err, "failed to load ref")
+	default:
+		ref.HardDeleteModel = previous.HardDeleteModel
+		if err := tx.Save(ref).Error; err != nil {
+			return errors.Wrap(err, "failed to update ref")
+		}
+		if previous.Hash == ref.Hash {
+			return nil
+		}
+		if err := addRefs(tx, previous.Hash, -1); err != nil {
+			return err
+		}
+	}
+	return addRefs(tx, ref.Hash, 1)
+}
+
+// Copy reference the blob of from at to, no content is copied
+func (s *Storage) Copy(ctx context.Context, from, to string) error {
+	return gormx.Transaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var source Ref
+		if err := tx.Where("path = ?", key(from)).First(&source).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.Wrapf(oss.ErrNotFound, "path %s", from)
+			}
+			return errors.Wrap(err, "failed to load ref")
+		}
+		ref := source
+		ref.HardDeleteModel = gormx.HardDeleteModel{}
+		ref.Path = key(to)
+		return saveRef(tx, &ref)
+	})
+}
+
+// Move move the reference of from to to, no content is copied
+func (s *Storage) Move(ctx context.Context, from, to string) error {
+	if key(from) == key(to) {
+		return nil
+	}
+	return gormx.Transaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var source Ref
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", key(from)).First(&source).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.Wrapf(oss.ErrNotFound, "path %s", from)
+			}
+			return errors.Wrap(err, "failed to load ref")
+		}
+		ref := source
+		ref.HardDeleteModel = gormx.HardDeleteModel{}
+		ref.Path = key(to)
+		if err := saveRef(tx, &ref); err != nil {
+			return err
+		}
+		if err := tx.Delete(&source).Error; err != nil {
+			return errors.Wrap(err, "failed to delete ref")
+		}
+		return addRefs(tx, source.Hash, -1)
+	})
+}
+
+func addRefs(tx *gorm.DB, hash string, delta int64) error {
+	err := tx.Model(&Blob{}).Where("hash = ?", hash).Updates(map[string]any{
+		"ref_count":  gorm.Expr("ref_count + ?", delta),
+		"updated_at": time.Now(),
+	}).Error
+	return errors.Wrapf(err, "failed to update references of blob %s", hash)
+}
+
+func (s *Storage) object(ref *Ref, size int64) *oss.Object {
+	updatedAt := ref.UpdatedAt
+	return &oss.Object{
+		Path:               ref.Path,
+		Name:               filepath.Base(ref.Path),
+		LastModified:       &updatedAt,
+		Size:               size,
+		ContentType:        ref.ContentType,
+		CacheControl:       ref.CacheControl,
+		ContentDisposition: ref.ContentDisposition,
+		ETag:               ref.Hash,
+		Metadata:           ref.Metadata,
+		StorageInterface:   s,
+	}
+}
+
+type refWithSize struct {
+	Ref
+	Size int64
+}
+
+func (s *Storage) refs(ctx context.Context, query string, args ...any) ([]*oss.Object, error) {
+	var rows []*refWithSize
+	err := s.db.WithContext(ctx).Model(&Ref{}).
+		Select("oss_dedup_refs.*, oss_dedup_blobs.size").
+		Joins("JOIN oss_dedup_blobs ON oss_dedup_blobs.hash = oss_dedup_refs.hash").
+		Where(query, args...).
+		Order("oss_dedup_refs.path").
+		Find(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load refs")
+	}
+
+	objects := make([]*oss.Object, 0, len(rows))
+	for _, row := range rows {
+		objects = append(objects, s.object(&row.Ref, row.Size))
+	}
+	return objects, nil
+}
+
+// Stat get object's metadata, the ETag is the SHA-256 of the content
+func (s *Storage) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	objects, err := s.refs(ctx, "oss_dedup_refs.path = ?", key(path))
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.Wrapf(oss.ErrNotFound, "path %s", path)
+	}
+	return objects[0], nil
+}
+
+// Delete remove the path, its blob is kept until GC
+func (s *Storage) Delete(ctx context.Context, path string) error {
+	return gormx.Transaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var ref Ref
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", key(path)).First(&ref).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.Wrapf(oss.ErrNotFound, "path %s", path)
+			}
+			return errors.Wrap(err, "failed to load ref")
+		}
+		if err := tx.Delete(&ref).Error; err != nil {
+			return errors.Wrap(err, "failed to delete ref")
+		}
+		return addRefs(tx, ref.Hash, -1)
+	})
+}
+
+// List list all objects under current path
+func (s *Storage) List(ctx context.Context, path string) ([]*oss.Object, error) {
+	prefix := "/"
+	if dir := strings.Trim(path, "/"); dir != "" {
+		prefix = "/" + dir + "/"
+	}
+	return s.refs(ctx, "oss_dedup_refs.path LIKE ?", escapeLike(prefix)+"%")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetURL get public accessible URL of the blob
+func (s *Storage) GetURL(ctx context.Context, path string) (string, error) {
+	ref, err := s.ref(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	return s.blobs.GetURL(ctx, s.BlobPath(ref.Hash))
+}
+
+// GetEndpoint get endpoint of the underlying storage
+func (s *Storage) GetEndpoint(ctx context.Context) string {
+	return s.blobs.GetEndpoint(ctx)
+}
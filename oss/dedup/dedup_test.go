@@ -0,0 +1,99 @@
+package dedup_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/dedup"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var suite *gormx.TestSuite
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	suite = gormx.MustStartTestSuite(ctx)
+	defer func() {
+		if err := suite.Stop(context.Background()); err != nil {
+			fmt.Printf("Error during teardown: %v\n", err)
+		}
+	}()
+
+	os.Exit(m.Run())
+}
+
+func setup(t *testing.T) (*dedup.Storage, *memory.Storage) {
+	require.NoError(t, suite.ResetDB(context.Background(), &dedup.Blob{}, &dedup.Ref{}))
+	blobs := memory.New()
+	return dedup.New(suite.DB(), blobs), blobs
+}
+
+func TestAll(t *testing.T) {
+	storage, _ := setup(t)
+	tests.TestAll(storage, t)
+}
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+	storage, blobs := setup(t)
+
+	_, err := storage.Put(ctx, "/a.txt", strings.NewReader("same"), oss.PutMetadata(map[string]string{"k": "v"}))
+	require.NoError(t, err)
+	_, err = storage.Put(ctx, "/b.txt", strings.NewReader("same"))
+	require.NoError(t, err)
+	require.NoError(t, oss.Copy(ctx, storage, "/b.txt", "/c.txt"))
+
+	stored, err := blobs.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+	blob, err := storage.Blob(ctx, "/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), blob.RefCount)
+
+	object, err := storage.Stat(ctx, "/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), object.Size)
+	assert.Equal(t, blob.Hash, object.ETag)
+	assert.Equal(t, map[string]string{"k": "v"}, object.Metadata)
+
+	// overwriting and deleting release the references
+	_, err = storage.Put(ctx, "/b.txt", strings.NewReader("other"))
+	require.NoError(t, err)
+	require.NoError(t, storage.Delete(ctx, "/a.txt"))
+	require.NoError(t, oss.Move(ctx, storage, "/c.txt", "/d.txt"))
+	assert.ErrorIs(t, storage.Delete(ctx, "/a.txt"), oss.ErrNotFound)
+
+	removed, err := storage.GC(ctx, &dedup.GCOptions{MinAge: time.Nanosecond})
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	require.NoError(t, storage.Delete(ctx, "/d.txt"))
+	removed, err = storage.GC(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed, "recently touched blobs are kept")
+	removed, err = storage.GC(ctx, &dedup.GCOptions{MinAge: time.Nanosecond})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	stored, err = blobs.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	stream, err := storage.GetStream(ctx, "/b.txt")
+	require.NoError(t, err)
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "other", string(data))
+}
@@ -0,0 +1,76 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/oss"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GCOptions options of GC
+type GCOptions struct {
+	// MinAge protects unreferenced blobs touched recently, which may be in the middle of a Put. Defaults to 1 hour.
+	MinAge time.Duration
+	// BatchSize is the number of blobs removed per transaction, defaults to 100
+	BatchSize int
+}
+
+// GC removes the blobs that are no longer referenced by any path and returns how many were removed.
+// It can run concurrently with itself and with the other methods.
+func (s *Storage) GC(ctx context.Context, opts *GCOptions) (int, error) {
+	o := GCOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MinAge <= 0 {
+		o.MinAge = time.Hour
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	removed := 0
+	for {
+		var n int
+		err := gormx.Transaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+			var blobs []*Blob
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("ref_count <= 0 AND updated_at < ?", time.Now().Add(-o.MinAge)).
+				Limit(o.BatchSize).
+				Find(&blobs).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to find unreferenced blobs")
+			}
+			if len(blobs) == 0 {
+				return nil
+			}
+
+			hashes := make([]string, 0, len(blobs))
+			paths := make([]string, 0, len(blobs))
+			for _, blob := range blobs {
+				hashes = append(hashes, blob.Hash)
+				paths = append(paths, s.BlobPath(blob.Hash))
+			}
+			// objects are deleted before the rows, a failure leaves rows that the next GC retries
+			if err := oss.DeleteObjects(ctx, s.blobs, paths); err != nil {
+				return errors.Wrap(err, "failed to delete blobs")
+			}
+			if err := tx.Where("hash IN ?", hashes).Delete(&Blob{}).Error; err != nil {
+				return errors.Wrap(err, "failed to delete blob records")
+			}
+			n = len(blobs)
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+		if n < o.BatchSize {
+			return removed, nil
+		}
+	}
+}
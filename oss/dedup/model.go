@@ -0,0 +1,45 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/qor5/x/v3/gormx"
+	"gorm.io/gorm"
+)
+
+// Blob is a stored content, identified by its SHA-256
+type Blob struct {
+	Hash string `gorm:"primaryKey" json:"hash"`
+	Size int64  `gorm:"not null" json:"size"`
+	// RefCount is the number of paths referencing the blob, blobs without references are removed by GC
+	RefCount  int64     `gorm:"not null;default:0;index" json:"refCount"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName specifies the table name for Blob.
+func (*Blob) TableName() string {
+	return "oss_dedup_blobs"
+}
+
+// Ref maps a path to the blob holding its content, with the options given to Put
+type Ref struct {
+	gormx.HardDeleteModel
+	Path               string            `gorm:"uniqueIndex;not null" json:"path"`
+	Hash               string            `gorm:"index;not null" json:"hash"`
+	ContentType        string            `json:"contentType"`
+	CacheControl       string            `json:"cacheControl"`
+	ContentDisposition string            `json:"contentDisposition"`
+	Metadata           map[string]string `gorm:"serializer:json" json:"metadata"`
+}
+
+// TableName specifies the table name for Ref.
+func (*Ref) TableName() string {
+	return "oss_dedup_refs"
+}
+
+// AutoMigrate creates or updates the tables used by Storage.
+func AutoMigrate(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).AutoMigrate(&Blob{}, &Ref{})
+}
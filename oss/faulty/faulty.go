@@ -0,0 +1,399 @@
+// Package faulty wraps an oss.StorageInterface to inject latency, errors and partial reads,
+// so retry, timeout and cleanup paths can be exercised in tests.
+package faulty
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.StorageInterface  = (*Storage)(nil)
+	_ oss.PageLister        = (*Storage)(nil)
+	_ oss.Presigner         = (*Storage)(nil)
+	_ oss.Copier            = (*Storage)(nil)
+	_ oss.Mover             = (*Storage)(nil)
+	_ oss.BatchDeleter      = (*Storage)(nil)
+	_ oss.MultipartUploader = (*Storage)(nil)
+)
+
+// ErrInjected is returned by operations failed with a Fault without Err
+var ErrInjected = errors.New("oss: injected fault")
+
+// Operation names a method of oss.StorageInterface
+type Operation string
+
+const (
+	OpGet         Operation = "Get"
+	OpGetStream   Operation = "GetStream"
+	OpPut         Operation = "Put"
+	OpStat        Operation = "Stat"
+	OpDelete      Operation = "Delete"
+	OpList        Operation = "List"
+	OpGetURL      Operation = "GetURL"
+	OpGetEndpoint Operation = "GetEndpoint"
+
+	OpListPage                Operation = "ListPage"
+	OpPresignPut              Operation = "PresignPut"
+	OpPresignGet              Operation = "PresignGet"
+	OpCopy                    Operation = "Copy"
+	OpMove                    Operation = "Move"
+	OpDeleteObjects           Operation = "DeleteObjects"
+	OpInitiateMultipartUpload Operation = "InitiateMultipartUpload"
+	OpUploadPart              Operation = "UploadPart"
+	OpListParts               Operation = "ListParts"
+	OpCompleteMultipartUpload Operation = "CompleteMultipartUpload"
+	OpAbortMultipartUpload    Operation = "AbortMultipartUpload"
+)
+
+var allOperations = []Operation{
+	OpGet, OpGetStream, OpPut, OpStat, OpDelete, OpList, OpGetURL, OpGetEndpoint,
+	OpListPage, OpPresignPut, OpPresignGet, OpCopy, OpMove, OpDeleteObjects,
+	OpInitiateMultipartUpload, OpUploadPart, OpListParts, OpCompleteMultipartUpload, OpAbortMultipartUpload,
+}
+
+// Fault describes what happens to a matching operation
+type Fault struct {
+	// Latency delays the operation, the delay is interrupted if the context is done
+	Latency time.Duration
+	// Err fails the operation without calling the wrapped storage, see ErrInjected
+	Err error
+	// Fail fails the operation with Err or ErrInjected, it is implied when Err is set
+	Fail bool
+	// PartialRead, when positive, lets only that many bytes through before the read fails with io.ErrUnexpectedEOF.
+	// It applies to the content returned by GetStream and to the reader given to Put and UploadPart, use it without Err and Fail.
+	PartialRead int64
+	// Probability that the fault applies, zero means always
+	Probability float64
+	// Times limits how many times the fault applies, zero means unlimited
+	Times int
+	// Match limits the fault to some paths, nil matches all paths.
+	// The path is the prefix for OpListPage, the source for OpCopy and OpMove, and empty for OpDeleteObjects.
+	Match func(path string) bool
+
+	applied int
+}
+
+// Storage decorate an oss.StorageInterface with faults, including its optional interfaces,
+// which return oss.ErrUnsupported if the wrapped storage doesn't support them
+type Storage struct {
+	storage oss.StorageInterface
+
+	mu     sync.Mutex
+	faults map[Operation][]*Fault
+	calls  map[Operation]int
+}
+
+// New wrap storage, without faults it behaves like storage
+func New(storage oss.StorageInterface) *Storage {
+	return &Storage{
+		storage: storage,
+		faults:  map[Operation][]*Fault{},
+		calls:   map[Operation]int{},
+	}
+}
+
+// Unwrap returns the wrapped storage
+func (storage *Storage) Unwrap() oss.StorageInterface {
+	return storage.storage
+}
+
+// Inject add a fault to the given operations, all operations if none is given
+func (storage *Storage) Inject(fault Fault, ops ...Operation) *Storage {
+	if len(ops) == 0 {
+		ops = allOperations
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	for _, op := range ops {
+		f := fault
+		storage.faults[op] = append(storage.faults[op], &f)
+	}
+	return storage
+}
+
+// Reset remove all faults and call counts
+func (storage *Storage) Reset() {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.faults = map[Operation][]*Fault{}
+	storage.calls = map[Operation]int{}
+}
+
+// Calls returns how many times op has been called
+func (storage *Storage) Calls(op Operation) int {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.calls[op]
+}
+
+// fault counts the call and returns the first fault that applies to it, or nil
+func (storage *Storage) fault(op Operation, path string) *Fault {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	storage.calls[op]++
+	for _, f := range storage.faults[op] {
+		if f.Times > 0 && f.applied >= f.Times {
+			continue
+		}
+		if f.Match != nil && !f.Match(path) {
+			continue
+		}
+		if f.Probability > 0 && rand.Float64() >= f.Probability {
+			continue
+		}
+		f.applied++
+		fault := *f
+		return &fault
+	}
+	return nil
+}
+
+// before applies the latency and failure of fault
+func (fault *Fault) before(ctx context.Context) error {
+	if fault == nil {
+		return nil
+	}
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fault.Err != nil {
+		return fault.Err
+	}
+	if fault.Fail {
+		return ErrInjected
+	}
+	return nil
+}
+
+func (fault *Fault) wrapReader(reader io.Reader) io.Reader {
+	if fault == nil || fault.PartialRead <= 0 {
+		return reader
+	}
+	return &partialReader{reader: reader, remaining: fault.PartialRead}
+}
+
+type partialReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *partialReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+type partialReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Get receive file with given path
+func (storage *Storage) Get(ctx context.Context, path string) (*os.File, error) {
+	if err := storage.fault(OpGet, path).before(ctx); err != nil {
+		return nil, err
+	}
+	return storage.storage.Get(ctx, path)
+}
+
+// GetStream get file as stream
+func (storage *Storage) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	fault := storage.fault(OpGetStream, path)
+	if err := fault.before(ctx); err != nil {
+		return nil, err
+	}
+	stream, err := storage.storage.GetStream(ctx, path)
+	if err != nil || fault == nil || fault.PartialRead <= 0 {
+		return stream, err
+	}
+	return partialReadCloser{Reader: fault.wrapReader(stream), Closer: stream}, nil
+}
+
+// Put store a reader into given path
+func (storage *Storage) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
+	fault := storage.fault(OpPut, path)
+	if err := fault.before(ctx); err != nil {
+		return nil, err
+	}
+	if seeker, ok := reader.(io.ReadSeeker); ok && fault != nil && fault.PartialRead > 0 {
+		seeker.Seek(0, 0)
+	}
+	return storage.storage.Put(ctx, path, fault.wrapReader(reader), opts...)
+}
+
+// Stat get object's metadata
+func (storage *Storage) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	if err := storage.fault(OpStat, path).before(ctx); err != nil {
+		return nil, err
+	}
+	return storage.storage.Stat(ctx, path)
+}
+
+// Delete delete file
+func (storage *Storage) Delete(ctx context.Context, path string) error {
+	if err := storage.fault(OpDelete, path).before(ctx); err != nil {
+		return err
+	}
+	return storage.storage.Delete(ctx, path)
+}
+
+// List list all objects under current path
+func (storage *Storage) List(ctx context.Context, path string) ([]*oss.Object, error) {
+	if err := storage.fault(OpList, path).before(ctx); err != nil {
+		return nil, err
+	}
+	return storage.storage.List(ctx, path)
+}
+
+// GetURL get public accessible URL
+func (storage *Storage) GetURL(ctx context.Context, path string) (string, error) {
+	if err := storage.fault(OpGetURL, path).before(ctx); err != nil {
+		return "", err
+	}
+	return storage.storage.GetURL(ctx, path)
+}
+
+// GetEndpoint get endpoint, faults only add latency as the method can't fail
+func (storage *Storage) GetEndpoint(ctx context.Context) string {
+	fault := storage.fault(OpGetEndpoint, "")
+	if fault != nil {
+		fault.Err, fault.Fail = nil, false
+		_ = fault.before(ctx)
+	}
+	return storage.storage.GetEndpoint(ctx)
+}
+
+// ListPage list a page of objects
+func (storage *Storage) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	var prefix string
+	if opts != nil {
+		prefix = opts.Prefix
+	}
+	if err := storage.fault(OpListPage, prefix).before(ctx); err != nil {
+		return nil, err
+	}
+	return oss.ListPage(ctx, storage.storage, opts)
+}
+
+// PresignPut generate a presigned upload request
+func (storage *Storage) PresignPut(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	if err := storage.fault(OpPresignPut, path).before(ctx); err != nil {
+		return nil, err
+	}
+	return oss.PresignPut(ctx, storage.storage, path, opts...)
+}
+
+// PresignGet generate a presigned download request
+func (storage *Storage) PresignGet(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	if err := storage.fault(OpPresignGet, path).before(ctx); err != nil {
+		return nil, err
+	}
+	return oss.PresignGet(ctx, storage.storage, path, opts...)
+}
+
+// Copy copy an object
+func (storage *Storage) Copy(ctx context.Context, from, to string) error {
+	if err := storage.fault(OpCopy, from).before(ctx); err != nil {
+		return err
+	}
+	return oss.Copy(ctx, storage.storage, from, to)
+}
+
+// Move move an object
+func (storage *Storage) Move(ctx context.Context, from, to string) error {
+	if err := storage.fault(OpMove, from).before(ctx); err != nil {
+		return err
+	}
+	return oss.Move(ctx, storage.storage, from, to)
+}
+
+// DeleteObjects delete objects
+func (storage *Storage) DeleteObjects(ctx context.Context, paths []string) error {
+	if err := storage.fault(OpDeleteObjects, "").before(ctx); err != nil {
+		return err
+	}
+	return oss.DeleteObjects(ctx, storage.storage, paths)
+}
+
+// InitiateMultipartUpload start a multipart upload
+func (storage *Storage) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	if err := storage.fault(OpInitiateMultipartUpload, path).before(ctx); err != nil {
+		return "", err
+	}
+	uploader, err := oss.AsMultipartUploader(storage.storage)
+	if err != nil {
+		return "", err
+	}
+	return uploader.InitiateMultipartUpload(ctx, path, opts...)
+}
+
+// UploadPart upload a part of a multipart upload
+func (storage *Storage) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	fault := storage.fault(OpUploadPart, path)
+	if err := fault.before(ctx); err != nil {
+		return nil, err
+	}
+	uploader, err := oss.AsMultipartUploader(storage.storage)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.UploadPart(ctx, path, uploadID, partNumber, fault.wrapReader(reader), size)
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (storage *Storage) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	if err := storage.fault(OpListParts, path).before(ctx); err != nil {
+		return nil, err
+	}
+	uploader, err := oss.AsMultipartUploader(storage.storage)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.ListParts(ctx, path, uploadID)
+}
+
+// CompleteMultipartUpload complete a multipart upload
+func (storage *Storage) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	if err := storage.fault(OpCompleteMultipartUpload, path).before(ctx); err != nil {
+		return nil, err
+	}
+	uploader, err := oss.AsMultipartUploader(storage.storage)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.CompleteMultipartUpload(ctx, path, uploadID, parts)
+}
+
+// AbortMultipartUpload abort a multipart upload
+func (storage *Storage) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	if err := storage.fault(OpAbortMultipartUpload, path).before(ctx); err != nil {
+		return err
+	}
+	uploader, err := oss.AsMultipartUploader(storage.storage)
+	if err != nil {
+		return err
+	}
+	return uploader.AbortMultipartUpload(ctx, path, uploadID)
+}
@@ -0,0 +1,75 @@
+package faulty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	tests.TestAll(New(memory.New()), t)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	storage := New(memory.New()).
+		Inject(Fault{Err: errBoom, Times: 2}, OpPut).
+		Inject(Fault{Fail: true, Match: func(path string) bool { return strings.HasSuffix(path, ".bad") }}, OpStat)
+
+	for i := 0; i < 2; i++ {
+		_, err := storage.Put(ctx, "/a.txt", strings.NewReader("a"))
+		assert.ErrorIs(t, err, errBoom)
+	}
+	_, err := storage.Put(ctx, "/a.txt", strings.NewReader("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, storage.Calls(OpPut))
+
+	_, err = storage.Stat(ctx, "/a.txt")
+	assert.NoError(t, err)
+	_, err = storage.Stat(ctx, "/a.bad")
+	assert.ErrorIs(t, err, ErrInjected)
+
+	storage.Reset()
+	_, err = storage.Stat(ctx, "/a.bad")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	assert.Equal(t, 1, storage.Calls(OpStat))
+}
+
+func TestLatency(t *testing.T) {
+	storage := New(memory.New()).Inject(Fault{Latency: time.Second}, OpList)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := storage.List(ctx, "/")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPartialRead(t *testing.T) {
+	ctx := context.Background()
+	content := bytes.Repeat([]byte("x"), 100)
+
+	storage := New(memory.New()).Inject(Fault{PartialRead: 10}, OpGetStream, OpPut)
+	_, err := storage.Put(ctx, "/a.txt", bytes.NewReader(content))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = storage.Unwrap().Put(ctx, "/a.txt", bytes.NewReader(content))
+	require.NoError(t, err)
+
+	stream, err := storage.GetStream(ctx, "/a.txt")
+	require.NoError(t, err)
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, data, 10)
+}
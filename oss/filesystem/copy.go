@@ -0,0 +1,115 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/qor5/x/v3/filepathx"
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.Copier       = (*FileSystem)(nil)
+	_ oss.Mover        = (*FileSystem)(nil)
+	_ oss.BatchDeleter = (*FileSystem)(nil)
+)
+
+func (fileSystem FileSystem) fullPaths(from, to string) (string, string, error) {
+	src, err := filepathx.Join(fileSystem.Base, from)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := filepathx.Join(fileSystem.Base, to)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return "", "", err
+	}
+	return src, dst, nil
+}
+
+func wrapNotExist(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %w", oss.ErrNotFound, err)
+	}
+	return err
+}
+
+// Copy copy a file with its metadata
+func (fileSystem FileSystem) Copy(ctx context.Context, from, to string) error {
+	src, dst, err := fileSystem.fullPaths(from, to)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return wrapNotExist(err)
+	}
+	defer in.Close()
+
+	// write to a temporary file first, so readers never see a partial copy
+	tmp, err := createTemp(dst)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+
+	o, err := readMetadata(src)
+	if err != nil {
+		return err
+	}
+	return writeMetadata(dst, o)
+}
+
+// Move rename a file with its metadata
+func (fileSystem FileSystem) Move(ctx context.Context, from, to string) error {
+	src, dst, err := fileSystem.fullPaths(from, to)
+	if err != nil {
+		return err
+	}
+	if src == dst {
+		// removing the metadata of src would remove the one of dst
+		_, err := os.Stat(src)
+		return wrapNotExist(err)
+	}
+
+	o, err := readMetadata(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return wrapNotExist(err)
+	}
+	if err := writeMetadata(dst, o); err != nil {
+		return err
+	}
+	return removeMetadata(src)
+}
+
+// DeleteObjects delete files, missing files are ignored
+func (fileSystem FileSystem) DeleteObjects(ctx context.Context, paths []string) error {
+	var errs []error
+	for _, path := range paths {
+		if err := fileSystem.Delete(ctx, path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,6 +3,9 @@ package filesystem
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,9 +16,15 @@ import (
 	"github.com/qor5/x/v3/oss"
 )
 
+var _ oss.PageLister = (*FileSystem)(nil)
+
 // FileSystem file system storage
 type FileSystem struct {
 	Base string
+	// Presign enables PresignPut and PresignGet, the URLs are served by PresignHandler
+	Presign *PresignConfig
+	// Multipart makes Put store large content through a multipart upload
+	Multipart *oss.MultipartConfig
 }
 
 // New initialize FileSystem storage
@@ -46,7 +55,7 @@ func (fileSystem FileSystem) GetStream(ctx context.Context, path string) (io.Rea
 }
 
 // Put store a reader into given path
-func (fileSystem FileSystem) Put(ctx context.Context, path string, reader io.Reader) (*oss.Object, error) {
+func (fileSystem FileSystem) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
 	fullpath, err := filepathx.Join(fileSystem.Base, path)
 	if err != nil {
 		return nil, err
@@ -58,18 +67,93 @@ func (fileSystem FileSystem) Put(ctx context.Context, path string, reader io.Rea
 	if seeker, ok := reader.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
+
+	reader, multipart, err := oss.ExceedsMultipartThreshold(reader, fileSystem.Multipart)
+	if err != nil {
+		return nil, err
+	}
+	if multipart {
+		return oss.PutMultipart(ctx, fileSystem, path, reader, fileSystem.Multipart, opts...)
+	}
+
 	buf := bytes.NewBuffer([]byte{})
 	if _, err = io.Copy(buf, reader); err != nil {
 		return nil, err
 	}
+	size := int64(buf.Len())
+	o := oss.NewPutOptions(opts...)
+	contentType := oss.DetectContentType(o.ContentType, path, buf.Bytes())
 
 	dst, err := os.Create(fullpath)
-
 	if err == nil {
 		_, err = io.Copy(dst, buf)
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err == nil {
+		err = writeMetadata(fullpath, o)
+	}
+
+	return &oss.Object{
+		Path:               path,
+		Name:               filepath.Base(path),
+		Size:               size,
+		ContentType:        contentType,
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		Metadata:           o.Metadata,
+		StorageInterface:   fileSystem,
+	}, err
+}
+
+// Stat get object's metadata, the ETag is the MD5 of the content like S3's single part uploads
+func (fileSystem FileSystem) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	fullPath, err := filepathx.Join(fileSystem.Base, path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %w", oss.ErrNotFound, err)
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", oss.ErrNotFound, path)
+	}
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	o, err := readMetadata(fullPath)
+	if err != nil {
+		return nil, err
 	}
 
-	return &oss.Object{Path: path, Name: filepath.Base(path), StorageInterface: fileSystem}, err
+	modTime := info.ModTime()
+	return &oss.Object{
+		Path:               path,
+		Name:               info.Name(),
+		LastModified:       &modTime,
+		Size:               info.Size(),
+		ContentType:        oss.DetectContentType(o.ContentType, path, nil),
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		ETag:               hex.EncodeToString(hash.Sum(nil)),
+		Metadata:           o.Metadata,
+		StorageInterface:   fileSystem,
+	}, nil
 }
 
 // Delete delete file
@@ -78,7 +162,10 @@ func (fileSystem FileSystem) Delete(ctx context.Context, path string) error {
 	if err != nil {
 		return err
 	}
-	return os.Remove(fullPath)
+	if err := os.Remove(fullPath); err != nil {
+		return err
+	}
+	return removeMetadata(fullPath)
 }
 
 // List list all objects under current path
@@ -94,12 +181,17 @@ func (fileSystem FileSystem) List(ctx context.Context, path string) ([]*oss.Obje
 			return nil
 		}
 
+		if err == nil && info.IsDir() && info.Name() == metadataDir {
+			return filepath.SkipDir
+		}
+
 		if err == nil && !info.IsDir() {
 			modTime := info.ModTime()
 			objects = append(objects, &oss.Object{
 				Path:             strings.TrimPrefix(path, fileSystem.Base),
 				Name:             info.Name(),
 				LastModified:     &modTime,
+				Size:             info.Size(),
 				StorageInterface: fileSystem,
 			})
 		}
@@ -109,6 +201,16 @@ func (fileSystem FileSystem) List(ctx context.Context, path string) ([]*oss.Obje
 	return objects, nil
 }
 
+// ListPage list a page of objects, the directory of the prefix is walked and paginated in memory
+func (fileSystem FileSystem) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	prefix := "/" + strings.TrimPrefix(filepath.ToSlash(opts.Prefix), "/")
+	objects, err := fileSystem.List(ctx, prefix[:strings.LastIndex(prefix, "/")+1])
+	if err != nil {
+		return nil, err
+	}
+	return oss.Paginate(objects, opts), nil
+}
+
 // GetEndpoint get endpoint, FileSystem's endpoint is /
 func (fileSystem FileSystem) GetEndpoint(ctx context.Context) string {
 	return "/"
@@ -1,12 +1,210 @@
 package filesystem
 
 import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/qor5/x/v3/oss"
 	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAll(t *testing.T) {
 	fileSystem := New("/tmp")
 	tests.TestAll(fileSystem, t)
 }
+
+func TestPresign(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fileSystem := New(t.TempDir())
+	fileSystem.Presign = &PresignConfig{Secret: []byte("secret"), BaseURL: server.URL + "/oss"}
+	mux.Handle("/oss/", fileSystem.PresignHandler())
+
+	do := func(req *oss.PresignedRequest, body string) *http.Response {
+		r, err := http.NewRequest(req.Method, req.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		for k := range req.Header {
+			r.Header.Set(k, req.Header.Get(k))
+		}
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	putReq, err := oss.PresignPut(ctx, fileSystem, "/a/b c.txt", oss.WithContentType("text/plain"), oss.WithMaxSize(5))
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, putReq.Method)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(putReq, "too large").StatusCode)
+	assert.Equal(t, http.StatusOK, do(putReq, "hello").StatusCode)
+
+	getReq, err := oss.PresignGet(ctx, fileSystem, "/a/b c.txt")
+	require.NoError(t, err)
+	resp := do(getReq, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Empty(t, resp.Header.Get("Content-Disposition"))
+
+	attachmentReq, err := oss.PresignGet(ctx, fileSystem, "/a/b c.txt", oss.WithContentDisposition("attachment", `b "c".txt`))
+	require.NoError(t, err)
+	resp = do(attachmentReq, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	require.NoError(t, err)
+	assert.Equal(t, `b "c".txt`, params["filename"])
+
+	tampered := *getReq
+	tampered.URL = strings.Replace(getReq.URL, "b%20c.txt", "other.txt", 1)
+	assert.Equal(t, http.StatusForbidden, do(&tampered, "").StatusCode)
+
+	expiredReq, err := oss.PresignGet(ctx, fileSystem, "/a/b c.txt", oss.WithExpires(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, do(expiredReq, "").StatusCode, "non-positive expires falls back to the default")
+
+	_, err = oss.PresignGet(ctx, New(t.TempDir()), "/a.txt")
+	assert.ErrorIs(t, err, oss.ErrUnsupported)
+}
+
+func TestMultipart(t *testing.T) {
+	ctx := context.Background()
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	fileSystem := New(t.TempDir())
+	fileSystem.Multipart = &oss.MultipartConfig{Threshold: 10, PartSize: 8, Concurrency: 3}
+
+	// a reader without Seek, so the threshold is detected by reading ahead
+	_, err := fileSystem.Put(ctx, "/large.txt", io.MultiReader(strings.NewReader(content)))
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(fileSystem.Base, "large.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	_, err = fileSystem.Put(ctx, "/small.txt", strings.NewReader("small"))
+	require.NoError(t, err)
+	data, err = os.ReadFile(filepath.Join(fileSystem.Base, "small.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "small", string(data))
+
+	t.Run("resume", func(t *testing.T) {
+		uploadID, err := fileSystem.InitiateMultipartUpload(ctx, "/resumed.txt")
+		require.NoError(t, err)
+		_, err = fileSystem.UploadPart(ctx, "/resumed.txt", uploadID, 2, strings.NewReader(content[8:16]), 8)
+		require.NoError(t, err)
+
+		parts, err := fileSystem.ListParts(ctx, "/resumed.txt", uploadID)
+		require.NoError(t, err)
+		require.Len(t, parts, 1)
+		assert.Equal(t, 2, parts[0].PartNumber)
+
+		_, err = oss.ResumeMultipart(ctx, fileSystem, "/resumed.txt", uploadID, strings.NewReader(content), fileSystem.Multipart)
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(fileSystem.Base, "resumed.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		_, err = fileSystem.ListParts(ctx, "/resumed.txt", uploadID)
+		assert.Error(t, err, "completed upload should be cleaned up")
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		uploadID, err := fileSystem.InitiateMultipartUpload(ctx, "/aborted.txt")
+		require.NoError(t, err)
+		_, err = fileSystem.ListParts(ctx, "/other.txt", uploadID)
+		assert.Error(t, err, "upload id should be bound to its path")
+
+		require.NoError(t, fileSystem.AbortMultipartUpload(ctx, "/aborted.txt", uploadID))
+		_, err = fileSystem.ListParts(ctx, "/aborted.txt", uploadID)
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Join(fileSystem.Base, "aborted.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestStat(t *testing.T) {
+	ctx := context.Background()
+	fileSystem := New(t.TempDir())
+
+	_, err := fileSystem.Put(ctx, "/dir/a.bin", strings.NewReader("hello"),
+		oss.PutContentType("application/x-test"),
+		oss.PutCacheControl("no-cache"),
+		oss.PutMetadata(map[string]string{"Owner": "editor"}),
+	)
+	require.NoError(t, err)
+
+	object, err := fileSystem.Stat(ctx, "/dir/a.bin")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), object.Size)
+	assert.Equal(t, "application/x-test", object.ContentType)
+	assert.Equal(t, "no-cache", object.CacheControl)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", object.ETag)
+	assert.Equal(t, map[string]string{"owner": "editor"}, object.Metadata)
+
+	objects, err := fileSystem.List(ctx, "/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1, "metadata files should not be listed")
+
+	// storing again without options drops the previous metadata
+	_, err = fileSystem.Put(ctx, "/dir/a.bin", strings.NewReader("hello"))
+	require.NoError(t, err)
+	object, err = fileSystem.Stat(ctx, "/dir/a.bin")
+	require.NoError(t, err)
+	assert.Nil(t, object.Metadata)
+
+	require.NoError(t, fileSystem.Delete(ctx, "/dir/a.bin"))
+	_, err = fileSystem.Stat(ctx, "/dir/a.bin")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	fileSystem := New(t.TempDir())
+
+	_, err := fileSystem.Put(ctx, "/a.txt", strings.NewReader("a"), oss.PutContentType("text/x-a"))
+	require.NoError(t, err)
+
+	require.NoError(t, fileSystem.Copy(ctx, "/a.txt", "/dir/b.txt"))
+	object, err := fileSystem.Stat(ctx, "/dir/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/x-a", object.ContentType)
+
+	require.NoError(t, fileSystem.Move(ctx, "/dir/b.txt", "/c.txt"))
+	object, err = fileSystem.Stat(ctx, "/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/x-a", object.ContentType)
+	_, err = fileSystem.Stat(ctx, "/dir/b.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	assert.ErrorIs(t, fileSystem.Move(ctx, "/dir/b.txt", "/d.txt"), oss.ErrNotFound)
+
+	// moving a file to itself keeps its metadata
+	require.NoError(t, fileSystem.Move(ctx, "/c.txt", "/c.txt"))
+	object, err = fileSystem.Stat(ctx, "/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/x-a", object.ContentType)
+	assert.ErrorIs(t, fileSystem.Move(ctx, "/d.txt", "/d.txt"), oss.ErrNotFound)
+
+	// an unfinished copy is not listed
+	tmp, err := createTemp(filepath.Join(fileSystem.Base, "e.txt"))
+	require.NoError(t, err)
+	require.NoError(t, tmp.Close())
+
+	require.NoError(t, fileSystem.DeleteObjects(ctx, []string{"/a.txt", "/c.txt", "/missing.txt"}))
+	objects, err := fileSystem.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
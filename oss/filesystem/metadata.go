@@ -0,0 +1,65 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// metadataDir is the hidden directory next to the stored files that keeps their PutOptions
+const metadataDir = ".ossmeta"
+
+// createTemp creates a temporary file to be renamed to fullpath, in the metadata directory so that List never sees it
+func createTemp(fullpath string) (*os.File, error) {
+	dir := filepath.Join(filepath.Dir(fullpath), metadataDir)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, "."+filepath.Base(fullpath)+".*.tmp")
+}
+
+func metadataPath(fullpath string) string {
+	return filepath.Join(filepath.Dir(fullpath), metadataDir, filepath.Base(fullpath)+".json")
+}
+
+// writeMetadata persist the options of an object, objects stored without options have no metadata file
+func writeMetadata(fullpath string, o *oss.PutOptions) error {
+	if o.ContentType == "" && o.CacheControl == "" && o.ContentDisposition == "" && len(o.Metadata) == 0 {
+		return removeMetadata(fullpath)
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	metaPath := metadataPath(fullpath)
+	if err := os.MkdirAll(filepath.Dir(metaPath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0o644)
+}
+
+func readMetadata(fullpath string) (*oss.PutOptions, error) {
+	o := &oss.PutOptions{}
+	data, err := os.ReadFile(metadataPath(fullpath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return o, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func removeMetadata(fullpath string) error {
+	if err := os.Remove(metadataPath(fullpath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
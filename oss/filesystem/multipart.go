@@ -0,0 +1,234 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/qor5/x/v3/filepathx"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.MultipartUploader = (*FileSystem)(nil)
+
+const (
+	multipartPathFile    = "path"
+	multipartOptionsFile = "options.json"
+)
+
+var uploadIDRegexp = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// multipartDir returns the directory holding the parts of an upload, it lives outside of Base so List never sees parts
+func (fileSystem FileSystem) multipartDir(uploadID string) (string, error) {
+	if !uploadIDRegexp.MatchString(uploadID) {
+		return "", fmt.Errorf("invalid upload id %q", uploadID)
+	}
+	base := sha256.Sum256([]byte(fileSystem.Base))
+	return filepath.Join(os.TempDir(), "oss-multipart", hex.EncodeToString(base[:8]), uploadID), nil
+}
+
+func (fileSystem FileSystem) openMultipart(path string, uploadID string) (string, error) {
+	dir, err := fileSystem.multipartDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+	target, err := os.ReadFile(filepath.Join(dir, multipartPathFile))
+	if err != nil {
+		return "", fmt.Errorf("multipart upload %s not found: %w", uploadID, err)
+	}
+	if string(target) != path {
+		return "", fmt.Errorf("multipart upload %s belongs to %s, not %s", uploadID, target, path)
+	}
+	return dir, nil
+}
+
+// InitiateMultipartUpload start a multipart upload
+func (fileSystem FileSystem) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	if _, err := filepathx.Join(fileSystem.Base, path); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+
+	dir, err := fileSystem.multipartDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, multipartPathFile), []byte(path), 0o600); err != nil {
+		return "", err
+	}
+	options, err := json.Marshal(oss.NewPutOptions(opts...))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, multipartOptionsFile), options, 0o600); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// UploadPart store a part of a multipart upload
+func (fileSystem FileSystem) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	if partNumber < 1 {
+		return nil, fmt.Errorf("invalid part number %d", partNumber)
+	}
+	dir, err := fileSystem.openMultipart(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	// write to a temporary file first, so an interrupted part is never listed
+	tmp, err := os.CreateTemp(dir, "part-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, fmt.Errorf("part %d has %d bytes, expected %d", partNumber, n, size)
+	}
+
+	etag := hex.EncodeToString(hash.Sum(nil))
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, partFileName(partNumber, etag))); err != nil {
+		return nil, err
+	}
+	return &oss.Part{PartNumber: partNumber, ETag: etag, Size: n}, nil
+}
+
+func partFileName(partNumber int, etag string) string {
+	return fmt.Sprintf("%05d.%s", partNumber, etag)
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (fileSystem FileSystem) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	dir, err := fileSystem.openMultipart(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[int]*oss.Part{}
+	for _, entry := range entries {
+		number, etag, ok := strings.Cut(entry.Name(), ".")
+		if !ok || strings.HasSuffix(etag, ".tmp") {
+			continue
+		}
+		partNumber, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		modTime := info.ModTime()
+		if prev, ok := latest[partNumber]; ok && prev.LastModified.After(modTime) {
+			continue
+		}
+		latest[partNumber] = &oss.Part{PartNumber: partNumber, ETag: etag, Size: info.Size(), LastModified: &modTime}
+	}
+
+	parts := make([]*oss.Part, 0, len(latest))
+	for _, part := range latest {
+		parts = append(parts, part)
+	}
+	slices.SortFunc(parts, func(a, b *oss.Part) int { return a.PartNumber - b.PartNumber })
+	return parts, nil
+}
+
+// CompleteMultipartUpload concatenate the given parts into path
+func (fileSystem FileSystem) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	dir, err := fileSystem.openMultipart(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	fullpath, err := filepathx.Join(fileSystem.Base, path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(fullpath), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	tmp, err := createTemp(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, part := range parts {
+		if err = appendPart(tmp, filepath.Join(dir, partFileName(part.PartNumber, part.ETag))); err != nil {
+			tmp.Close()
+			return nil, fmt.Errorf("part %d: %w", part.PartNumber, err)
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tmp.Name(), fullpath); err != nil {
+		return nil, err
+	}
+
+	o := &oss.PutOptions{}
+	if data, err := os.ReadFile(filepath.Join(dir, multipartOptionsFile)); err == nil {
+		if err := json.Unmarshal(data, o); err != nil {
+			return nil, err
+		}
+	}
+	if err = writeMetadata(fullpath, o); err != nil {
+		return nil, err
+	}
+
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	return &oss.Object{Path: path, Name: filepath.Base(path), StorageInterface: fileSystem}, nil
+}
+
+func appendPart(dst io.Writer, partPath string) error {
+	src, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// AbortMultipartUpload remove all parts of a multipart upload
+func (fileSystem FileSystem) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	dir, err := fileSystem.openMultipart(path, uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
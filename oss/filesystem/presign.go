@@ -0,0 +1,216 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qor5/x/v3/filepathx"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.Presigner = (*FileSystem)(nil)
+
+// PresignConfig enables HMAC signed URLs for FileSystem, which are served by PresignHandler
+type PresignConfig struct {
+	// Secret is the HMAC key used to sign URLs
+	Secret []byte
+	// BaseURL is the URL PresignHandler is mounted on, e.g. http://localhost:8080/oss
+	BaseURL string
+}
+
+const (
+	presignParamExpires     = "expires"
+	presignParamContentType = "content_type"
+	presignParamDisposition = "content_disposition"
+	presignParamMaxSize     = "max_size"
+	presignParamSignature   = "signature"
+)
+
+// PresignPut generate a signed PUT URL served by PresignHandler
+func (fileSystem FileSystem) PresignPut(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	return fileSystem.presign(http.MethodPut, path, oss.NewPresignOptions(opts...))
+}
+
+// PresignGet generate a signed GET URL served by PresignHandler
+func (fileSystem FileSystem) PresignGet(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	return fileSystem.presign(http.MethodGet, path, oss.NewPresignOptions(opts...))
+}
+
+func (fileSystem FileSystem) presign(method string, path string, o *oss.PresignOptions) (*oss.PresignedRequest, error) {
+	if fileSystem.Presign == nil || len(fileSystem.Presign.Secret) == 0 {
+		return nil, fmt.Errorf("%w: filesystem presign is not configured", oss.ErrUnsupported)
+	}
+
+	path = "/" + strings.TrimPrefix(filepath.ToSlash(path), "/")
+	expiresAt := time.Now().Add(o.Expires)
+
+	query := url.Values{}
+	query.Set(presignParamExpires, strconv.FormatInt(expiresAt.Unix(), 10))
+	if o.ContentType != "" {
+		query.Set(presignParamContentType, o.ContentType)
+	}
+	if method == http.MethodGet && o.ContentDisposition != "" {
+		query.Set(presignParamDisposition, o.ContentDisposition)
+	}
+	if method == http.MethodPut && o.MaxSize > 0 {
+		query.Set(presignParamMaxSize, strconv.FormatInt(o.MaxSize, 10))
+	}
+	query.Set(presignParamSignature, fileSystem.signature(method, path, query))
+
+	header := http.Header{}
+	if method == http.MethodPut && o.ContentType != "" {
+		header.Set("Content-Type", o.ContentType)
+	}
+
+	return &oss.PresignedRequest{
+		Method:    method,
+		URL:       strings.TrimSuffix(fileSystem.Presign.BaseURL, "/") + (&url.URL{Path: path}).EscapedPath() + "?" + query.Encode(),
+		Header:    header,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (fileSystem FileSystem) signature(method string, path string, query url.Values) string {
+	mac := hmac.New(sha256.New, fileSystem.Presign.Secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n%s",
+		method,
+		path,
+		query.Get(presignParamExpires),
+		query.Get(presignParamContentType),
+		query.Get(presignParamDisposition),
+		query.Get(presignParamMaxSize),
+	)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PresignHandler serve the URLs generated by PresignPut and PresignGet, it should be mounted on PresignConfig.BaseURL
+func (fileSystem FileSystem) PresignHandler() http.Handler {
+	var prefix string
+	if fileSystem.Presign != nil {
+		if u, err := url.Parse(fileSystem.Presign.BaseURL); err == nil {
+			prefix = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fileSystem.Presign == nil || len(fileSystem.Presign.Secret) == 0 {
+			http.Error(w, "presign is not configured", http.StatusNotImplemented)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPut {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		path, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		path = "/" + strings.TrimPrefix(path, "/")
+
+		query := r.URL.Query()
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		expected := fileSystem.signature(method, path, query)
+		if !hmac.Equal([]byte(expected), []byte(query.Get(presignParamSignature))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		expires, err := strconv.ParseInt(query.Get(presignParamExpires), 10, 64)
+		if err != nil || time.Now().Unix() > expires {
+			http.Error(w, "request has expired", http.StatusForbidden)
+			return
+		}
+
+		contentType := query.Get(presignParamContentType)
+		if method == http.MethodGet {
+			fileSystem.servePresignedGet(w, r, path, contentType, query.Get(presignParamDisposition))
+			return
+		}
+		fileSystem.servePresignedPut(w, r, path, contentType, query.Get(presignParamMaxSize))
+	})
+}
+
+func (fileSystem FileSystem) servePresignedGet(w http.ResponseWriter, r *http.Request, path string, contentType string, disposition string) {
+	fullPath, err := filepathx.Join(fileSystem.Base, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+func (fileSystem FileSystem) servePresignedPut(w http.ResponseWriter, r *http.Request, path string, contentType string, maxSize string) {
+	if contentType != "" {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != contentType {
+			http.Error(w, "content type does not match the signed content type", http.StatusForbidden)
+			return
+		}
+	}
+
+	var body io.Reader = r.Body
+	if maxSize != "" {
+		limit, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid max size", http.StatusBadRequest)
+			return
+		}
+		if r.ContentLength > limit {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	if _, err := fileSystem.Put(r.Context(), path, body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
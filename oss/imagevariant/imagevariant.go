@@ -0,0 +1,189 @@
+// Package imagevariant derives images such as thumbnails from originals kept in an oss.StorageInterface.
+// Originals are cropped, resized and converted to JPEG, PNG or WebP, re-encoding drops EXIF and other
+// metadata after the EXIF orientation has been applied.
+//
+// WebP variants are lossless, written by a pure Go encoder, another one can be registered with RegisterEncoder.
+package imagevariant
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp"
+
+	"github.com/qor5/x/v3/ui/cropper"
+)
+
+const (
+	// DefaultQuality is used when Spec.Quality is not set
+	DefaultQuality = 85
+	// DefaultMaxPixels is used when Spec.MaxPixels is not set, about 8K x 6K
+	DefaultMaxPixels = 50_000_000
+)
+
+var (
+	// ErrUnsupportedFormat is returned for formats without a registered Encoder
+	ErrUnsupportedFormat = errors.New("imagevariant: unsupported format")
+	// ErrTooLarge is returned for originals with more pixels than Spec.MaxPixels
+	ErrTooLarge = errors.New("imagevariant: image too large")
+)
+
+// Format an output image format
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	// WebP is lossless, the quality is ignored
+	WebP Format = "webp"
+)
+
+// Ext returns the file extension of the format
+func (format Format) Ext() string {
+	if format == JPEG {
+		return ".jpg"
+	}
+	return "." + string(format)
+}
+
+// ContentType returns the MIME type of the format
+func (format Format) ContentType() string {
+	return "image/" + string(format)
+}
+
+// Encoder writes img in a format, quality ranges from 1 to 100 and may be ignored
+type Encoder func(w io.Writer, img image.Image, quality int) error
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[Format]Encoder{
+		JPEG: func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		PNG: func(w io.Writer, img image.Image, quality int) error {
+			return png.Encode(w, img)
+		},
+		WebP: func(w io.Writer, img image.Image, quality int) error {
+			return encodeWebP(w, img)
+		},
+	}
+)
+
+// RegisterEncoder registers the encoder of a format, e.g. a lossy WebP encoder backed by a WebP library.
+func RegisterEncoder(format Format, encoder Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = encoder
+}
+
+func encoder(format Format) (Encoder, error) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	if e, ok := encoders[format]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// Crop a rectangle in the pixels of the original, after the EXIF orientation is applied.
+// It is the value produced by the ui/cropper component.
+type Crop = cropper.Value
+
+func rectangle(crop *Crop) image.Rectangle {
+	return image.Rect(
+		int(math.Round(crop.X)),
+		int(math.Round(crop.Y)),
+		int(math.Round(crop.X+crop.Width)),
+		int(math.Round(crop.Y+crop.Height)),
+	)
+}
+
+// Spec describes a variant
+type Spec struct {
+	// Crop is applied before resizing
+	Crop *Crop
+	// Width and Height resize the image, when one of them is zero the aspect ratio is kept
+	Width  int
+	Height int
+	// Fill crops the image around its center to exactly Width x Height, otherwise it's scaled to fit in the box
+	Fill bool
+	// Format defaults to the format of the original, or JPEG when it can't be encoded
+	Format Format
+	// Quality of JPEG, defaults to DefaultQuality
+	Quality int
+	// MaxPixels limits the width x height of the original, which is checked before decoding it, defaults to DefaultMaxPixels
+	MaxPixels int
+}
+
+// Process decode an image from r, apply spec and encode it to w, it returns the format written
+func Process(r io.Reader, w io.Writer, spec *Spec) (Format, error) {
+	if spec == nil {
+		spec = &Spec{}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	config, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	maxPixels := spec.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return "", fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return "", err
+	}
+
+	if spec.Crop != nil {
+		rect := rectangle(spec.Crop).Intersect(img.Bounds())
+		if rect.Empty() {
+			return "", fmt.Errorf("crop %v is outside of the image %v", rectangle(spec.Crop), img.Bounds())
+		}
+		img = imaging.Crop(img, rect)
+	}
+
+	switch {
+	case spec.Width > 0 && spec.Height > 0 && spec.Fill:
+		img = imaging.Fill(img, spec.Width, spec.Height, imaging.Center, imaging.Lanczos)
+	case spec.Width > 0 && spec.Height > 0:
+		img = imaging.Fit(img, spec.Width, spec.Height, imaging.Lanczos)
+	case spec.Width > 0 || spec.Height > 0:
+		img = imaging.Resize(img, spec.Width, spec.Height, imaging.Lanczos)
+	}
+
+	format := spec.Format
+	if format == "" {
+		format = Format(name)
+		if _, err := encoder(format); err != nil {
+			format = JPEG
+		}
+	}
+	encode, err := encoder(format)
+	if err != nil {
+		return "", err
+	}
+
+	quality := spec.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+	if err := encode(w, img, quality); err != nil {
+		return "", err
+	}
+	return format, nil
+}
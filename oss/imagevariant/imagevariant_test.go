@@ -0,0 +1,199 @@
+package imagevariant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qor5/x/v3/oss/faulty"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func sample(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, r io.Reader) (image.Image, string) {
+	img, format, err := image.Decode(r)
+	require.NoError(t, err)
+	return img, format
+}
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		name   string
+		spec   *Spec
+		size   image.Point
+		format string
+	}{
+		{"resize width", &Spec{Width: 50}, image.Pt(50, 25), "png"},
+		{"fit", &Spec{Width: 40, Height: 40, Format: JPEG}, image.Pt(40, 20), "jpeg"},
+		{"fill", &Spec{Width: 40, Height: 40, Fill: true}, image.Pt(40, 40), "png"},
+		{"crop", &Spec{Crop: &Crop{X: 10, Y: 10, Width: 30.4, Height: 20}}, image.Pt(30, 20), "png"},
+		{"crop then resize", &Spec{Crop: &Crop{Width: 100, Height: 50}, Width: 10}, image.Pt(10, 5), "png"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := Process(bytes.NewReader(sample(t, 200, 100)), &buf, c.spec)
+			require.NoError(t, err)
+			img, format := decode(t, &buf)
+			assert.Equal(t, c.size, img.Bounds().Size())
+			assert.Equal(t, c.format, format)
+		})
+	}
+
+	_, err := Process(bytes.NewReader(sample(t, 10, 10)), io.Discard, &Spec{Crop: &Crop{X: 20, Y: 20, Width: 5, Height: 5}})
+	assert.Error(t, err)
+	_, err = Process(bytes.NewReader(sample(t, 10, 10)), io.Discard, &Spec{Format: "bmp"})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = Process(bytes.NewReader(sample(t, 20, 10)), io.Discard, &Spec{MaxPixels: 100})
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestWebP(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	rnd.Read(noise.Pix)
+	gradient := image.NewNRGBA(image.Rect(0, 0, 300, 70))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 70; y++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y * 3), B: uint8(x ^ y), A: uint8(255 - y)})
+		}
+	}
+	solid := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(solid, solid.Bounds(), image.NewUniform(color.NRGBA{R: 10, G: 200, B: 30, A: 255}), image.Point{}, draw.Src)
+	for name, img := range map[string]*image.NRGBA{
+		"noise":    noise,
+		"gradient": gradient,
+		"solid":    solid,
+		"pixel":    image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+		"sub":      gradient.SubImage(image.Rect(5, 5, 40, 9)).(*image.NRGBA),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, encodeWebP(&buf, img))
+			decoded, err := webp.Decode(&buf)
+			require.NoError(t, err)
+			want := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+			draw.Draw(want, want.Bounds(), img, img.Bounds().Min, draw.Src)
+			assert.Equal(t, want.Pix, decoded.(*image.NRGBA).Pix)
+		})
+	}
+
+	var buf bytes.Buffer
+	format, err := Process(bytes.NewReader(sample(t, 200, 100)), &buf, &Spec{Width: 50, Format: WebP})
+	require.NoError(t, err)
+	assert.Equal(t, WebP, format)
+	original := buf.Bytes()
+	img, name := decode(t, bytes.NewReader(original))
+	assert.Equal(t, "webp", name)
+	assert.Equal(t, image.Pt(50, 25), img.Bounds().Size())
+
+	// WebP originals get WebP variants
+	buf.Reset()
+	format, err = Process(bytes.NewReader(original), &buf, &Spec{Width: 10})
+	require.NoError(t, err)
+	assert.Equal(t, WebP, format)
+	img, _ = decode(t, &buf)
+	assert.Equal(t, image.Pt(10, 5), img.Bounds().Size())
+}
+
+func TestStripEXIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10)), nil))
+	// insert an APP1 EXIF segment with orientation 6 (rotate 90 clockwise) after SOI
+	exif := []byte{
+		0xFF, 0xE1, 0x00, 0x22, 'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x01, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	original := append(append([]byte{0xFF, 0xD8}, exif...), buf.Bytes()[2:]...)
+
+	var out bytes.Buffer
+	_, err := Process(bytes.NewReader(original), &out, &Spec{})
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(out.Bytes(), []byte("Exif")))
+	img, _ := decode(t, &out)
+	assert.Equal(t, image.Pt(10, 20), img.Bounds().Size())
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	storage := faulty.New(memory.New())
+	_, err := storage.Put(ctx, "/photos/a.png", bytes.NewReader(sample(t, 200, 100)))
+	require.NoError(t, err)
+
+	service := New(storage, map[string]*Spec{
+		"thumb": {Width: 20, Height: 20, Fill: true, Format: JPEG},
+		"small": {Width: 100},
+		"tiny":  {Width: 10, MaxPixels: 100},
+	})
+
+	path, err := service.Path("/photos/a.png", "thumb")
+	require.NoError(t, err)
+	assert.Equal(t, "/photos/a.thumb.jpg", path)
+	_, err = service.Path("/photos/a.png", "large")
+	assert.ErrorIs(t, err, ErrUnknownVariant)
+
+	var crop Crop
+	require.NoError(t, json.Unmarshal([]byte(`{"x":0,"y":0,"width":100,"height":100,"rotate":0,"scaleX":1,"scaleY":1}`), &crop))
+	object, err := service.Generate(ctx, "/photos/a.png", "small", WithCrop(&crop))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", object.ContentType)
+
+	server := httptest.NewServer(http.StripPrefix("/variants", service.Handler()))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(server.URL + "/variants/thumb/photos/a.png")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/jpeg", res.Header.Get("Content-Type"))
+		img, _ := decode(t, res.Body)
+		res.Body.Close()
+		assert.Equal(t, image.Pt(20, 20), img.Bounds().Size())
+	}
+	// the variant is generated once, then served from the storage
+	assert.Equal(t, 3, storage.Calls(faulty.OpPut))
+
+	res, err := http.Get(server.URL + "/variants/tiny/photos/a.png")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	for _, path := range []string{"/variants/large/photos/a.png", "/variants/thumb/photos/missing.png", "/variants/thumb/photos/a.thumb.jpg"} {
+		res, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode, path)
+	}
+
+	require.NoError(t, service.DeleteAll(ctx, "/photos/a.png"))
+	objects, err := storage.List(ctx, "photos")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "/photos/a.png", objects[0].Path)
+}
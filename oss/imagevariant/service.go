@@ -0,0 +1,218 @@
+package imagevariant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/qor5/x/v3/oss"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrUnknownVariant is returned for variant names missing from Service.Variants
+var ErrUnknownVariant = errors.New("imagevariant: unknown variant")
+
+// Service stores named variants next to their originals
+type Service struct {
+	Storage oss.StorageInterface
+	// Variants are the specs by name, names must not contain "/"
+	Variants map[string]*Spec
+
+	group singleflight.Group
+}
+
+// New initialize a Service
+func New(storage oss.StorageInterface, variants map[string]*Spec) *Service {
+	return &Service{Storage: storage, Variants: variants}
+}
+
+func (service *Service) spec(name string) (*Spec, error) {
+	spec, ok := service.Variants[name]
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownVariant, name)
+	}
+	if spec == nil {
+		return &Spec{}, nil
+	}
+	return spec, nil
+}
+
+// format returns the output format of a variant, deduced from the original extension when the spec has none
+func format(original string, spec *Spec) Format {
+	if spec.Format != "" {
+		return spec.Format
+	}
+	f := Format(strings.TrimPrefix(strings.ToLower(filepath.Ext(original)), "."))
+	if f == "jpg" {
+		f = JPEG
+	}
+	if _, err := encoder(f); err != nil {
+		return JPEG
+	}
+	return f
+}
+
+// isVariant reports whether path is where a variant is stored, like /a/photo.thumb.png
+func (service *Service) isVariant(path string) bool {
+	name := strings.TrimPrefix(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))), ".")
+	_, ok := service.Variants[name]
+	return ok && name != ""
+}
+
+// Path returns where the named variant of original is stored, /a/photo.png with variant thumb is /a/photo.thumb.png
+func (service *Service) Path(original, name string) (string, error) {
+	spec, err := service.spec(name)
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimSuffix(original, filepath.Ext(original))
+	return base + "." + name + format(original, spec).Ext(), nil
+}
+
+// GenerateOption options of Generate
+type GenerateOption func(spec *Spec)
+
+// WithCrop overrides the crop of the spec, for example with the rectangle chosen with ui/cropper
+func WithCrop(crop *Crop) GenerateOption {
+	return func(spec *Spec) {
+		spec.Crop = crop
+	}
+}
+
+// Generate derive the named variant from original and store it through Put
+func (service *Service) Generate(ctx context.Context, original, name string, opts ...GenerateOption) (*oss.Object, error) {
+	s, err := service.spec(name)
+	if err != nil {
+		return nil, err
+	}
+	spec := *s
+	for _, opt := range opts {
+		opt(&spec)
+	}
+	spec.Format = format(original, s)
+
+	path, err := service.Path(original, name)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := service.Storage.GetStream(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var buf bytes.Buffer
+	f, err := Process(stream, &buf, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("process %s variant of %s: %w", name, original, err)
+	}
+	return service.Storage.Put(ctx, path, bytes.NewReader(buf.Bytes()), oss.PutContentType(f.ContentType()))
+}
+
+// GenerateAll derive every variant of original, crops are optional crop rectangles by variant name
+func (service *Service) GenerateAll(ctx context.Context, original string, crops map[string]*Crop) (map[string]*oss.Object, error) {
+	objects := map[string]*oss.Object{}
+	for name := range service.Variants {
+		var opts []GenerateOption
+		if crop, ok := crops[name]; ok {
+			opts = append(opts, WithCrop(crop))
+		}
+		object, err := service.Generate(ctx, original, name, opts...)
+		if err != nil {
+			return nil, err
+		}
+		objects[name] = object
+	}
+	return objects, nil
+}
+
+// DeleteAll delete every stored variant of original
+func (service *Service) DeleteAll(ctx context.Context, original string) error {
+	var paths []string
+	for name := range service.Variants {
+		path, err := service.Path(original, name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	return oss.DeleteObjects(ctx, service.Storage, paths)
+}
+
+// Handler serves variants at /{name}/{original path}, a missing variant is generated on its first request.
+// Variants of variants, like /thumb/a/photo.thumb.png, are not found, and originals larger than the MaxPixels
+// of the spec are rejected. Mount it with http.StripPrefix.
+func (service *Service) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		name, original, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if !ok || original == "" {
+			http.NotFound(w, r)
+			return
+		}
+		original = "/" + original
+		if service.isVariant(original) {
+			http.NotFound(w, r)
+			return
+		}
+
+		path, err := service.Path(original, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		object, err := service.Storage.Stat(ctx, path)
+		if errors.Is(err, oss.ErrNotFound) {
+			var v any
+			v, err, _ = service.group.Do(path, func() (any, error) {
+				// generation is shared by concurrent requests, so it must not be canceled by one of them
+				return service.Generate(context.WithoutCancel(ctx), original, name)
+			})
+			if err == nil {
+				object = v.(*oss.Object)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, oss.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if errors.Is(err, ErrTooLarge) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		stream, err := service.Storage.GetStream(ctx, path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer stream.Close()
+
+		if object.ContentType != "" {
+			w.Header().Set("Content-Type", object.ContentType)
+		}
+		if object.ETag != "" {
+			w.Header().Set("ETag", `"`+strings.Trim(object.ETag, `"`)+`"`)
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = io.Copy(w, stream)
+	})
+}
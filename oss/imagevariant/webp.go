@@ -0,0 +1,571 @@
+package imagevariant
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// The WebP encoder writes lossless VP8L images, see https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification.
+// The pixels are transformed by subtract green and a predictor chosen per tile,
+// then coded with one group of prefix codes, runs of pixels equal to the left or the top one being backward references.
+
+const (
+	vp8lSignature  = 0x2f
+	vp8lMaxSize    = 1 << 14
+	vp8lMaxCodeLen = 15
+
+	vp8lTransformPredictor     = 0
+	vp8lTransformSubtractGreen = 2
+
+	// vp8lPredictorBits is the log-2 size of the tiles of the predictor transform
+	vp8lPredictorBits  = 4
+	vp8lPredictorModes = 14
+
+	vp8lLiteralCodes  = 256
+	vp8lLengthCodes   = 24
+	vp8lDistanceCodes = 40
+	vp8lMaxRun        = 4096
+	vp8lMinRun        = 3
+
+	// the distance codes of the left and the top pixels
+	vp8lDistanceLeft = 2
+	vp8lDistanceTop  = 1
+)
+
+// vp8lCodeLengthOrder is the order of the code lengths of the code length code
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP writes img as a lossless WebP.
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxSize || height > vp8lMaxSize {
+		return fmt.Errorf("webp: invalid image size %dx%d", width, height)
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Stride != 4*width {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	}
+	pix := make([]byte, len(nrgba.Pix[:4*width*height]))
+	copy(pix, nrgba.Pix)
+
+	bw := &bitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(boolBit(hasAlpha(pix)), 1)
+	bw.write(0, 3)
+
+	bw.write(1, 1)
+	bw.write(vp8lTransformSubtractGreen, 2)
+	subtractGreen(pix)
+
+	bw.write(1, 1)
+	bw.write(vp8lTransformPredictor, 2)
+	bw.write(vp8lPredictorBits-2, 3)
+	modes, tilesPerRow, tilesPerCol := predict(pix, width, height)
+	writePixels(bw, modes, tilesPerRow, tilesPerCol, false)
+
+	bw.write(0, 1)
+	writePixels(bw, pix, width, height, true)
+	data := bw.flush()
+
+	size := len(data)
+	padded := size + size&1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+padded))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(size))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if padded > size {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func hasAlpha(pix []byte) bool {
+	for i := 3; i < len(pix); i += 4 {
+		if pix[i] != 0xff {
+			return true
+		}
+	}
+	return false
+}
+
+func boolBit(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func subtractGreen(pix []byte) {
+	for i := 0; i < len(pix); i += 4 {
+		pix[i] -= pix[i+1]
+		pix[i+2] -= pix[i+1]
+	}
+}
+
+// predict replaces pix by the residuals of the predictor transform, choosing the mode of each tile
+// with the smallest residuals. It returns the sub-image of the modes and its size.
+func predict(pix []byte, width, height int) ([]byte, int, int) {
+	tileSize := 1 << vp8lPredictorBits
+	tilesPerRow := (width + tileSize - 1) >> vp8lPredictorBits
+	tilesPerCol := (height + tileSize - 1) >> vp8lPredictorBits
+	modes := make([]byte, 4*tilesPerRow*tilesPerCol)
+	for ty := 0; ty < tilesPerCol; ty++ {
+		for tx := 0; tx < tilesPerRow; tx++ {
+			best, bestCost := 0, -1
+			for mode := 0; mode < vp8lPredictorModes; mode++ {
+				cost := 0
+				for y := ty * tileSize; y < min((ty+1)*tileSize, height); y++ {
+					for x := tx * tileSize; x < min((tx+1)*tileSize, width); x++ {
+						p := predictor(pix, width, x, y, mode)
+						i := 4 * (y*width + x)
+						for c := 0; c < 4; c++ {
+							cost += absResidual(pix[i+c] - p[c])
+						}
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			i := 4 * (ty*tilesPerRow + tx)
+			modes[i+1] = byte(best)
+			modes[i+3] = 0xff
+		}
+	}
+
+	// the residuals are computed from the last pixel, since the predictions use the pixels before
+	for y := height - 1; y >= 0; y-- {
+		for x := width - 1; x >= 0; x-- {
+			mode := int(modes[4*((y>>vp8lPredictorBits)*tilesPerRow+x>>vp8lPredictorBits)+1])
+			p := predictor(pix, width, x, y, mode)
+			i := 4 * (y*width + x)
+			for c := 0; c < 4; c++ {
+				pix[i+c] -= p[c]
+			}
+		}
+	}
+	return modes, tilesPerRow, tilesPerCol
+}
+
+func absResidual(v byte) int {
+	if v >= 128 {
+		return 256 - int(v)
+	}
+	return int(v)
+}
+
+// predictor returns the prediction of the pixel at x, y by mode,
+// the top-left pixel is predicted by opaque black, the first row by L and the first column by T.
+func predictor(pix []byte, width, x, y, mode int) [4]byte {
+	i := 4 * (y*width + x)
+	switch {
+	case x == 0 && y == 0:
+		return [4]byte{0, 0, 0, 0xff}
+	case y == 0:
+		return [4]byte(pix[i-4 : i])
+	case x == 0:
+		return [4]byte(pix[i-4*width : i-4*width+4])
+	}
+	// TR of the last pixel of a row is the first pixel of the row, which follows TL in memory
+	l, t, tl, tr := pix[i-4:i], pix[i-4*width:], pix[i-4*width-4:], pix[i-4*width+4:]
+	var p [4]byte
+	for c := 0; c < 4; c++ {
+		switch mode {
+		case 0:
+			if c == 3 {
+				p[c] = 0xff
+			}
+		case 1:
+			p[c] = l[c]
+		case 2:
+			p[c] = t[c]
+		case 3:
+			p[c] = tr[c]
+		case 4:
+			p[c] = tl[c]
+		case 5:
+			p[c] = avg2(avg2(l[c], tr[c]), t[c])
+		case 6:
+			p[c] = avg2(l[c], tl[c])
+		case 7:
+			p[c] = avg2(l[c], t[c])
+		case 8:
+			p[c] = avg2(tl[c], t[c])
+		case 9:
+			p[c] = avg2(t[c], tr[c])
+		case 10:
+			p[c] = avg2(avg2(l[c], tl[c]), avg2(t[c], tr[c]))
+		case 12:
+			p[c] = clamp(int(l[c]) + int(t[c]) - int(tl[c]))
+		case 13:
+			a := avg2(l[c], t[c])
+			p[c] = clamp(int(a) + (int(a)-int(tl[c]))/2)
+		}
+	}
+	if mode == 11 {
+		// Select(L, T, TL) picks the one of L and T farther from TL in the other direction
+		var pl, pt int
+		for c := 0; c < 4; c++ {
+			pl += abs(int(tl[c]) - int(t[c]))
+			pt += abs(int(tl[c]) - int(l[c]))
+		}
+		if pl < pt {
+			copy(p[:], l[:4])
+		} else {
+			copy(p[:], t[:4])
+		}
+	}
+	return p
+}
+
+func avg2(a, b byte) byte {
+	return byte((int(a) + int(b)) / 2)
+}
+
+func clamp(v int) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vp8lSymbol is a literal pixel, or a backward reference when length > 0.
+type vp8lSymbol struct {
+	pixel    [4]byte
+	length   int
+	distance int
+}
+
+// writePixels writes the entropy-coded image of width x height pix, without a color cache,
+// with a single group of prefix codes.
+func writePixels(bw *bitWriter, pix []byte, width, height int, topLevel bool) {
+	// runs of pixels equal to the left or the top one are backward references
+	var symbols []vp8lSymbol
+	n := width * height
+	for i := 0; i < n; {
+		left, top := 0, 0
+		if i >= 1 {
+			left = runLength(pix, i, 1, n)
+		}
+		if i >= width {
+			top = runLength(pix, i, width, n)
+		}
+		switch {
+		case left >= vp8lMinRun && left >= top:
+			symbols = append(symbols, vp8lSymbol{length: left, distance: vp8lDistanceLeft})
+			i += left
+		case top >= vp8lMinRun:
+			symbols = append(symbols, vp8lSymbol{length: top, distance: vp8lDistanceTop})
+			i += top
+		default:
+			symbols = append(symbols, vp8lSymbol{pixel: [4]byte(pix[4*i : 4*i+4])})
+			i++
+		}
+	}
+
+	histograms := [5][]uint32{
+		make([]uint32, vp8lLiteralCodes+vp8lLengthCodes),
+		make([]uint32, vp8lLiteralCodes),
+		make([]uint32, vp8lLiteralCodes),
+		make([]uint32, vp8lLiteralCodes),
+		make([]uint32, vp8lDistanceCodes),
+	}
+	for _, s := range symbols {
+		if s.length == 0 {
+			histograms[0][s.pixel[1]]++
+			histograms[1][s.pixel[0]]++
+			histograms[2][s.pixel[2]]++
+			histograms[3][s.pixel[3]]++
+			continue
+		}
+		code, _, _ := prefixCode(s.length)
+		histograms[0][vp8lLiteralCodes+code]++
+		code, _, _ = prefixCode(s.distance)
+		histograms[4][code]++
+	}
+
+	bw.write(0, 1) // no color cache
+	if topLevel {
+		bw.write(0, 1) // no meta prefix codes
+	}
+	var codes [5]*prefixCodes
+	for i, h := range histograms {
+		codes[i] = newPrefixCodes(h, vp8lMaxCodeLen)
+		codes[i].writeLengths(bw)
+	}
+
+	for _, s := range symbols {
+		if s.length == 0 {
+			codes[0].write(bw, int(s.pixel[1]))
+			codes[1].write(bw, int(s.pixel[0]))
+			codes[2].write(bw, int(s.pixel[2]))
+			codes[3].write(bw, int(s.pixel[3]))
+			continue
+		}
+		code, extraBits, extra := prefixCode(s.length)
+		codes[0].write(bw, vp8lLiteralCodes+code)
+		bw.write(extra, extraBits)
+		code, extraBits, extra = prefixCode(s.distance)
+		codes[4].write(bw, code)
+		bw.write(extra, extraBits)
+	}
+}
+
+// runLength returns how many pixels from i equal the pixels distance before them.
+func runLength(pix []byte, i, distance, n int) int {
+	run := 0
+	for j := i; j < n && run < vp8lMaxRun; j++ {
+		if [4]byte(pix[4*j:4*j+4]) != [4]byte(pix[4*(j-distance):4*(j-distance)+4]) {
+			break
+		}
+		run++
+	}
+	return run
+}
+
+// prefixCode returns the prefix code of a length or a distance code, and its extra bits.
+func prefixCode(v int) (code int, extraBits uint32, extra uint32) {
+	if v <= 4 {
+		return v - 1, 0, 0
+	}
+	d := uint32(v - 1)
+	highest := uint32(bits.Len32(d) - 1)
+	second := (d >> (highest - 1)) & 1
+	extraBits = highest - 1
+	return int(2*highest + second), extraBits, d & (1<<extraBits - 1)
+}
+
+// prefixCodes are the canonical prefix codes of an alphabet.
+type prefixCodes struct {
+	lengths []uint8
+	// codes are bit reversed, since the bits are written from the least significant one
+	codes []uint32
+	// single is whether there is only one symbol, written with 0 bits
+	single bool
+}
+
+// newPrefixCodes returns the codes of the symbols counted in histogram, limited to maxLen bits.
+// An alphabet with a single symbol has a code of 0 bits, and one without any gets the symbol 0.
+func newPrefixCodes(histogram []uint32, maxLen int) *prefixCodes {
+	lengths := huffmanLengths(histogram, maxLen)
+	used := 0
+	for _, l := range lengths {
+		if l > 0 {
+			used++
+		}
+	}
+	if used == 0 {
+		lengths[0] = 1
+	}
+
+	pc := &prefixCodes{lengths: lengths, codes: make([]uint32, len(lengths)), single: used <= 1}
+	if pc.single {
+		return pc
+	}
+	var counts [vp8lMaxCodeLen + 1]uint32
+	for _, l := range lengths {
+		counts[l]++
+	}
+	counts[0] = 0
+	var next [vp8lMaxCodeLen + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLen; l++ {
+		code = (code + counts[l-1]) << 1
+		next[l] = code
+	}
+	for s, l := range lengths {
+		if l > 0 {
+			pc.codes[s] = bits.Reverse32(next[l]) >> (32 - l)
+			next[l]++
+		}
+	}
+	return pc
+}
+
+func (pc *prefixCodes) write(bw *bitWriter, symbol int) {
+	if pc.single {
+		return
+	}
+	bw.write(pc.codes[symbol], uint32(pc.lengths[symbol]))
+}
+
+// writeLengths writes the code lengths as a normal code length code.
+func (pc *prefixCodes) writeLengths(bw *bitWriter) {
+	type token struct {
+		symbol    int
+		extraBits uint32
+		extra     uint32
+	}
+	var tokens []token
+	lengths := pc.lengths
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+		if l == 0 {
+			for run >= 11 {
+				r := min(run, 138)
+				tokens = append(tokens, token{18, 7, uint32(r - 11)})
+				run -= r
+			}
+			if run >= 3 {
+				tokens = append(tokens, token{17, 3, uint32(run - 3)})
+				run = 0
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, token{symbol: 0})
+			}
+			continue
+		}
+		tokens = append(tokens, token{symbol: int(l)})
+		run--
+		for run >= 3 {
+			r := min(run, 6)
+			tokens = append(tokens, token{16, 2, uint32(r - 3)})
+			run -= r
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, token{symbol: int(l)})
+		}
+	}
+
+	histogram := make([]uint32, len(vp8lCodeLengthOrder))
+	for _, t := range tokens {
+		histogram[t.symbol]++
+	}
+	lengthCodes := newPrefixCodes(histogram, 7)
+
+	count := 4
+	for i, s := range vp8lCodeLengthOrder {
+		if lengthCodes.lengths[s] > 0 && i+1 > count {
+			count = i + 1
+		}
+	}
+	bw.write(0, 1) // normal code
+	bw.write(uint32(count-4), 4)
+	for _, s := range vp8lCodeLengthOrder[:count] {
+		bw.write(uint32(lengthCodes.lengths[s]), 3)
+	}
+	bw.write(0, 1) // all the code lengths are written
+	for _, t := range tokens {
+		lengthCodes.write(bw, t.symbol)
+		bw.write(t.extra, t.extraBits)
+	}
+}
+
+// huffmanLengths returns the Huffman code lengths of the symbols counted in histogram, limited to maxLen bits
+// by flattening the counts until the tree is shallow enough.
+func huffmanLengths(histogram []uint32, maxLen int) []uint8 {
+	lengths := make([]uint8, len(histogram))
+	var symbols []int
+	for s, c := range histogram {
+		if c > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	if len(symbols) == 1 {
+		lengths[symbols[0]] = 1
+	}
+	if len(symbols) <= 1 {
+		return lengths
+	}
+
+	type node struct {
+		weight uint64
+		parent int
+	}
+	for minCount := uint64(1); ; minCount *= 2 {
+		nodes := make([]node, len(symbols), 2*len(symbols)-1)
+		for i, s := range symbols {
+			nodes[i] = node{weight: max(uint64(histogram[s]), minCount), parent: -1}
+		}
+		leaves := make([]int, len(symbols))
+		for i := range leaves {
+			leaves[i] = i
+		}
+		sort.SliceStable(leaves, func(i, j int) bool {
+			return nodes[leaves[i]].weight < nodes[leaves[j]].weight
+		})
+		// the internal nodes are created in increasing weight, so the two lightest nodes are
+		// at the front of the leaves or of the internal nodes
+		li, ii := 0, len(symbols)
+		lightest := func() int {
+			if li < len(leaves) && (ii >= len(nodes) || nodes[leaves[li]].weight <= nodes[ii].weight) {
+				li++
+				return leaves[li-1]
+			}
+			ii++
+			return ii - 1
+		}
+		for len(nodes) < cap(nodes) {
+			a, b := lightest(), lightest()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, parent: -1})
+			nodes[a].parent, nodes[b].parent = len(nodes)-1, len(nodes)-1
+		}
+
+		depths := make([]int, len(nodes))
+		for i := len(nodes) - 2; i >= 0; i-- {
+			depths[i] = depths[nodes[i].parent] + 1
+		}
+		deepest := 0
+		for i, s := range symbols {
+			lengths[s] = uint8(depths[i])
+			deepest = max(deepest, depths[i])
+		}
+		if deepest <= maxLen {
+			return lengths
+		}
+	}
+}
+
+// bitWriter writes bits from the least significant one.
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint32
+}
+
+func (bw *bitWriter) write(v uint32, n uint32) {
+	bw.bits |= uint64(v&(1<<n-1)) << bw.nBits
+	bw.nBits += n
+	for bw.nBits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.bits))
+		bw.bits >>= 8
+		bw.nBits -= 8
+	}
+}
+
+func (bw *bitWriter) flush() []byte {
+	if bw.nBits > 0 {
+		bw.buf = append(bw.buf, byte(bw.bits))
+		bw.bits, bw.nBits = 0, 0
+	}
+	return bw.buf
+}
@@ -0,0 +1,177 @@
+package oss
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// DefaultListMaxKeys is used when ListOptions.MaxKeys is not set
+const DefaultListMaxKeys = 1000
+
+// ListOptions options for listing a page of objects
+type ListOptions struct {
+	// Prefix limits the results to paths beginning with it, the leading "/" is optional
+	Prefix string
+	// Delimiter groups the paths that contain it after Prefix into ListResult.CommonPrefixes,
+	// use "/" to list a single "directory" level
+	Delimiter string
+	// ContinuationToken is ListResult.NextContinuationToken of the previous page
+	ContinuationToken string
+	// MaxKeys is the maximum number of objects and common prefixes in a page, defaults to DefaultListMaxKeys
+	MaxKeys int
+}
+
+// GetMaxKeys returns MaxKeys or DefaultListMaxKeys when it's not set
+func (opts *ListOptions) GetMaxKeys() int {
+	if opts == nil || opts.MaxKeys <= 0 {
+		return DefaultListMaxKeys
+	}
+	return opts.MaxKeys
+}
+
+// ListResult a page of objects
+type ListResult struct {
+	Objects []*Object
+	// CommonPrefixes are the "directories" found with ListOptions.Delimiter, with a leading "/" and the trailing delimiter
+	CommonPrefixes []string
+	// NextContinuationToken is set when IsTruncated, pass it to ListOptions.ContinuationToken to get the next page
+	NextContinuationToken string
+	IsTruncated           bool
+}
+
+// PageLister is implemented by storages that can list objects page by page
+type PageLister interface {
+	ListPage(ctx context.Context, opts *ListOptions) (*ListResult, error)
+}
+
+// ListPage list a page of objects, storages that are not a PageLister are listed completely with List and paginated in memory
+func ListPage(ctx context.Context, storage StorageInterface, opts *ListOptions) (*ListResult, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	if lister, ok := As[PageLister](storage); ok {
+		return lister.ListPage(ctx, opts)
+	}
+
+	objects, err := storage.List(ctx, listDir(opts.Prefix))
+	if err != nil {
+		return nil, err
+	}
+	return Paginate(objects, opts), nil
+}
+
+// DirPrefix returns the prefix listing the directory path, which ends with "/" so that List of /a doesn't return /ab/c,
+// it is empty for the root
+func DirPrefix(path string) string {
+	if dir := strings.Trim(path, "/"); dir != "" {
+		return dir + "/"
+	}
+	return ""
+}
+
+// listDir returns the directory part of a prefix, which is what List expects
+func listDir(prefix string) string {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		return prefix[:i]
+	}
+	return ""
+}
+
+// ListSeq stream all objects matching opts page by page, common prefixes are not included
+func ListSeq(ctx context.Context, storage StorageInterface, opts *ListOptions) iter.Seq2[*Object, error] {
+	return func(yield func(*Object, error) bool) {
+		pageOpts := &ListOptions{}
+		if opts != nil {
+			*pageOpts = *opts
+		}
+
+		for {
+			result, err := ListPage(ctx, storage, pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, object := range result.Objects {
+				if !yield(object, nil) {
+					return
+				}
+			}
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return
+			}
+			pageOpts.ContinuationToken = result.NextContinuationToken
+		}
+	}
+}
+
+// ListAll collect all objects matching opts, following every page
+func ListAll(ctx context.Context, storage StorageInterface, opts *ListOptions) ([]*Object, error) {
+	var objects []*Object
+	for object, err := range ListSeq(ctx, storage, opts) {
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// Paginate build a page from a complete list of objects, the continuation token is the last path of the page.
+// It is useful for storages that cannot paginate natively.
+func Paginate(objects []*Object, opts *ListOptions) *ListResult {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	prefix := "/" + strings.TrimPrefix(opts.Prefix, "/")
+	token := opts.ContinuationToken
+	maxKeys := opts.GetMaxKeys()
+
+	sorted := slices.Clone(objects)
+	slices.SortFunc(sorted, func(a, b *Object) int { return strings.Compare(objectPath(a), objectPath(b)) })
+
+	result := &ListResult{}
+	count := 0
+	for _, object := range sorted {
+		path := objectPath(object)
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if token != "" && (path <= token || (opts.Delimiter != "" && strings.HasSuffix(token, opts.Delimiter) && strings.HasPrefix(path, token))) {
+			continue
+		}
+
+		entry := path
+		if opts.Delimiter != "" {
+			if i := strings.Index(path[len(prefix):], opts.Delimiter); i >= 0 {
+				entry = path[:len(prefix)+i+len(opts.Delimiter)]
+				if n := len(result.CommonPrefixes); n > 0 && result.CommonPrefixes[n-1] == entry {
+					continue
+				}
+			}
+		}
+
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		count++
+		result.NextContinuationToken = entry
+		if entry == path {
+			result.Objects = append(result.Objects, object)
+		} else {
+			result.CommonPrefixes = append(result.CommonPrefixes, entry)
+		}
+	}
+
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+	return result
+}
+
+func objectPath(object *Object) string {
+	return "/" + strings.TrimPrefix(object.Path, "/")
+}
@@ -0,0 +1,61 @@
+package oss_test
+
+import (
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	var objects []*oss.Object
+	for _, path := range []string{"/b/2.txt", "a.txt", "/b/1.txt", "/b/c/3.txt", "/d/4.txt", "/e.txt"} {
+		objects = append(objects, &oss.Object{Path: path})
+	}
+	paths := func(objects []*oss.Object) []string {
+		var paths []string
+		for _, object := range objects {
+			paths = append(paths, object.Path)
+		}
+		return paths
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		result := oss.Paginate(objects, &oss.ListOptions{MaxKeys: 4})
+		assert.Equal(t, []string{"a.txt", "/b/1.txt", "/b/2.txt", "/b/c/3.txt"}, paths(result.Objects))
+		assert.True(t, result.IsTruncated)
+
+		result = oss.Paginate(objects, &oss.ListOptions{MaxKeys: 4, ContinuationToken: result.NextContinuationToken})
+		assert.Equal(t, []string{"/d/4.txt", "/e.txt"}, paths(result.Objects))
+		assert.False(t, result.IsTruncated)
+		assert.Empty(t, result.NextContinuationToken)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		result := oss.Paginate(objects, &oss.ListOptions{Prefix: "b/"})
+		assert.Equal(t, []string{"/b/1.txt", "/b/2.txt", "/b/c/3.txt"}, paths(result.Objects))
+	})
+
+	t.Run("delimiter", func(t *testing.T) {
+		result := oss.Paginate(objects, &oss.ListOptions{Delimiter: "/", MaxKeys: 2})
+		assert.Equal(t, []string{"a.txt"}, paths(result.Objects))
+		assert.Equal(t, []string{"/b/"}, result.CommonPrefixes)
+		assert.True(t, result.IsTruncated)
+
+		result = oss.Paginate(objects, &oss.ListOptions{Delimiter: "/", MaxKeys: 2, ContinuationToken: result.NextContinuationToken})
+		assert.Equal(t, []string{"/e.txt"}, paths(result.Objects))
+		assert.Equal(t, []string{"/d/"}, result.CommonPrefixes)
+		assert.False(t, result.IsTruncated)
+
+		result = oss.Paginate(objects, &oss.ListOptions{Prefix: "/b/", Delimiter: "/"})
+		assert.Equal(t, []string{"/b/1.txt", "/b/2.txt"}, paths(result.Objects))
+		assert.Equal(t, []string{"/b/c/"}, result.CommonPrefixes)
+	})
+}
+
+func TestDirPrefix(t *testing.T) {
+	assert.Equal(t, "", oss.DirPrefix(""))
+	assert.Equal(t, "", oss.DirPrefix("/"))
+	assert.Equal(t, "a/", oss.DirPrefix("/a"))
+	assert.Equal(t, "a/b/", oss.DirPrefix("a/b/"))
+}
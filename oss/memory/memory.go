@@ -0,0 +1,345 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.StorageInterface  = (*Storage)(nil)
+	_ oss.PageLister        = (*Storage)(nil)
+	_ oss.MultipartUploader = (*Storage)(nil)
+	_ oss.Copier            = (*Storage)(nil)
+	_ oss.Mover             = (*Storage)(nil)
+	_ oss.BatchDeleter      = (*Storage)(nil)
+)
+
+// Storage in-memory storage, it is safe for concurrent use and meant for tests
+type Storage struct {
+	mu      sync.RWMutex
+	objects map[string]*entry
+	uploads map[string]*upload
+}
+
+type entry struct {
+	data   []byte
+	object oss.Object
+}
+
+type upload struct {
+	path    string
+	options *oss.PutOptions
+	parts   map[int][]byte
+}
+
+// New initialize an empty in-memory storage
+func New() *Storage {
+	return &Storage{
+		objects: map[string]*entry{},
+		uploads: map[string]*upload{},
+	}
+}
+
+func key(path string) string {
+	return "/" + strings.TrimPrefix(path, "/")
+}
+
+func (storage *Storage) entry(path string) (*entry, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	e, ok := storage.objects[key(path)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", oss.ErrNotFound, path)
+	}
+	return e, nil
+}
+
+// object returns a copy of the object, so callers can't modify the stored one
+func (storage *Storage) object(e *entry) *oss.Object {
+	object := e.object
+	object.Metadata = maps.Clone(e.object.Metadata)
+	object.StorageInterface = storage
+	return &object
+}
+
+// Get receive file with given path, the content is copied to a temporary file
+func (storage *Storage) Get(ctx context.Context, path string) (*os.File, error) {
+	e, err := storage.entry(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "memory*"+filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(e.data); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// GetStream get file as stream
+func (storage *Storage) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	e, err := storage.entry(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(e.data)), nil
+}
+
+// Put store a reader into given path
+func (storage *Storage) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		seeker.Seek(0, 0)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return storage.store(path, data, oss.NewPutOptions(opts...)), nil
+}
+
+func (storage *Storage) store(path string, data []byte, o *oss.PutOptions) *oss.Object {
+	sum := md5.Sum(data)
+	now := time.Now()
+	e := &entry{
+		data: data,
+		object: oss.Object{
+			Path:               key(path),
+			Name:               filepath.Base(path),
+			LastModified:       &now,
+			Size:               int64(len(data)),
+			ContentType:        oss.DetectContentType(o.ContentType, path, data),
+			CacheControl:       o.CacheControl,
+			ContentDisposition: o.ContentDisposition,
+			ETag:               hex.EncodeToString(sum[:]),
+			Metadata:           maps.Clone(o.Metadata),
+		},
+	}
+
+	storage.mu.Lock()
+	storage.objects[key(path)] = e
+	storage.mu.Unlock()
+
+	return storage.object(e)
+}
+
+// Stat get object's metadata
+func (storage *Storage) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	e, err := storage.entry(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.object(e), nil
+}
+
+// Delete delete file, deleting a missing file succeeds like S3
+func (storage *Storage) Delete(ctx context.Context, path string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	delete(storage.objects, key(path))
+	return nil
+}
+
+// Copy copy an object with its metadata
+func (storage *Storage) Copy(ctx context.Context, from, to string) error {
+	e, err := storage.entry(from)
+	if err != nil {
+		return err
+	}
+	storage.store(to, e.data, &oss.PutOptions{
+		ContentType:        e.object.ContentType,
+		CacheControl:       e.object.CacheControl,
+		ContentDisposition: e.object.ContentDisposition,
+		Metadata:           e.object.Metadata,
+	})
+	return nil
+}
+
+// Move move an object with its metadata
+func (storage *Storage) Move(ctx context.Context, from, to string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	e, ok := storage.objects[key(from)]
+	if !ok {
+		return fmt.Errorf("%w: %s", oss.ErrNotFound, from)
+	}
+	delete(storage.objects, key(from))
+
+	moved := *e
+	moved.object.Path = key(to)
+	moved.object.Name = filepath.Base(to)
+	storage.objects[key(to)] = &moved
+	return nil
+}
+
+// DeleteObjects delete objects, missing objects are ignored
+func (storage *Storage) DeleteObjects(ctx context.Context, paths []string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	for _, path := range paths {
+		delete(storage.objects, key(path))
+	}
+	return nil
+}
+
+// List list all objects under current path
+func (storage *Storage) List(ctx context.Context, path string) ([]*oss.Object, error) {
+	prefix := "/"
+	if dir := strings.Trim(path, "/"); dir != "" {
+		prefix = "/" + dir + "/"
+	}
+
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	var objects []*oss.Object
+	for _, k := range slices.Sorted(maps.Keys(storage.objects)) {
+		if strings.HasPrefix(k, prefix) {
+			objects = append(objects, storage.object(storage.objects[k]))
+		}
+	}
+	return objects, nil
+}
+
+// ListPage list a page of objects
+func (storage *Storage) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	objects, err := storage.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return oss.Paginate(objects, opts), nil
+}
+
+// GetURL get public accessible URL, which is the path itself
+func (storage *Storage) GetURL(ctx context.Context, path string) (string, error) {
+	return path, nil
+}
+
+// GetEndpoint get endpoint, Storage's endpoint is /
+func (storage *Storage) GetEndpoint(ctx context.Context) string {
+	return "/"
+}
+
+// InitiateMultipartUpload start a multipart upload
+func (storage *Storage) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+
+	storage.mu.Lock()
+	storage.uploads[uploadID] = &upload{path: key(path), options: oss.NewPutOptions(opts...), parts: map[int][]byte{}}
+	storage.mu.Unlock()
+
+	return uploadID, nil
+}
+
+// upload returns the upload, the lock must be held by the caller
+func (storage *Storage) upload(path string, uploadID string) (*upload, error) {
+	u, ok := storage.uploads[uploadID]
+	if !ok || u.path != key(path) {
+		return nil, fmt.Errorf("multipart upload %s of %s not found", uploadID, path)
+	}
+	return u, nil
+}
+
+// UploadPart store a part of a multipart upload
+func (storage *Storage) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, err := storage.upload(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	u.parts[partNumber] = data
+
+	sum := md5.Sum(data)
+	return &oss.Part{PartNumber: partNumber, ETag: hex.EncodeToString(sum[:]), Size: int64(len(data))}, nil
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (storage *Storage) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	u, err := storage.upload(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []*oss.Part
+	for _, partNumber := range slices.Sorted(maps.Keys(u.parts)) {
+		sum := md5.Sum(u.parts[partNumber])
+		parts = append(parts, &oss.Part{PartNumber: partNumber, ETag: hex.EncodeToString(sum[:]), Size: int64(len(u.parts[partNumber]))})
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload concatenate the given parts into path
+func (storage *Storage) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	storage.mu.Lock()
+	u, err := storage.upload(path, uploadID)
+	if err != nil {
+		storage.mu.Unlock()
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, part := range parts {
+		data, ok := u.parts[part.PartNumber]
+		if !ok {
+			storage.mu.Unlock()
+			return nil, fmt.Errorf("part %d of multipart upload %s not found", part.PartNumber, uploadID)
+		}
+		buf.Write(data)
+	}
+	delete(storage.uploads, uploadID)
+	storage.mu.Unlock()
+
+	return storage.store(path, buf.Bytes(), u.options), nil
+}
+
+// AbortMultipartUpload remove all parts of a multipart upload
+func (storage *Storage) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, err := storage.upload(path, uploadID); err != nil {
+		return err
+	}
+	delete(storage.uploads, uploadID)
+	return nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	tests.TestAll(New(), t)
+}
+
+func TestConcurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/concurrent/%d.txt", i%5)
+			_, err := storage.Put(ctx, path, strings.NewReader(path))
+			assert.NoError(t, err)
+			_, err = storage.Stat(ctx, path)
+			assert.NoError(t, err)
+			_, err = storage.List(ctx, "concurrent")
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	objects, err := storage.List(ctx, "concurrent")
+	require.NoError(t, err)
+	assert.Len(t, objects, 5)
+}
+
+func TestNotFound(t *testing.T) {
+	ctx := context.Background()
+	storage := New()
+
+	_, err := storage.GetStream(ctx, "/missing.txt")
+	assert.True(t, errors.Is(err, oss.ErrNotFound))
+	_, err = storage.Stat(ctx, "/missing.txt")
+	assert.True(t, errors.Is(err, oss.ErrNotFound))
+	assert.NoError(t, storage.Delete(ctx, "/missing.txt"), "deleting a missing object succeeds like S3")
+}
+
+func TestMultipart(t *testing.T) {
+	ctx := context.Background()
+	storage := New()
+
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	object, err := oss.PutMultipart(ctx, storage, "/multipart.bin", bytes.NewReader(content), &oss.MultipartConfig{PartSize: 64}, oss.PutContentType("application/x-test"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), object.Size)
+	assert.Equal(t, "application/x-test", object.ContentType)
+
+	stream, err := storage.GetStream(ctx, "/multipart.bin")
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Empty(t, storage.uploads)
+}
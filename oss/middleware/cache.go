@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// CacheConfig configures Cache
+type CacheConfig struct {
+	// Dir holds the cached files
+	Dir string
+	// TTL is how long a cached file is served, zero keeps it until the object is Put or Deleted through the cache
+	TTL time.Duration
+	// Match limits caching to some paths, nil caches every path
+	Match func(path string) bool
+}
+
+// Cache is a read-through local disk cache for GetStream. A stream is cached once it has been read to the end,
+// Put, Delete, Copy, Move, DeleteObjects and completed multipart uploads through the cache invalidate the cached files.
+// Uploads with presigned requests don't go through the cache, use a TTL if there are any.
+func Cache(conf CacheConfig) Middleware {
+	return func(next oss.StorageInterface) oss.StorageInterface {
+		return &cache{StorageInterface: next, conf: conf, generations: map[string]uint64{}}
+	}
+}
+
+type cache struct {
+	oss.StorageInterface
+	conf CacheConfig
+
+	mu sync.Mutex
+	// generations is bumped on every invalidation, so a fill started before it is discarded
+	generations map[string]uint64
+}
+
+var (
+	_ oss.Copier            = (*cache)(nil)
+	_ oss.Mover             = (*cache)(nil)
+	_ oss.BatchDeleter      = (*cache)(nil)
+	_ oss.MultipartUploader = (*cache)(nil)
+	_ oss.Unwrapper         = (*cache)(nil)
+)
+
+func (c *cache) Unwrap() oss.StorageInterface {
+	return c.StorageInterface
+}
+
+func (c *cache) file(path string) string {
+	sum := sha256.Sum256([]byte("/" + strings.TrimPrefix(path, "/")))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.conf.Dir, key[:2], key)
+}
+
+func (c *cache) generation(file string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[file]
+}
+
+func (c *cache) invalidate(path string) {
+	file := c.file(path)
+	c.mu.Lock()
+	c.generations[file]++
+	c.mu.Unlock()
+	os.Remove(file)
+}
+
+// GetStream get file as stream, from the cache when possible
+func (c *cache) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	if c.conf.Match != nil && !c.conf.Match(path) {
+		return c.StorageInterface.GetStream(ctx, path)
+	}
+
+	file := c.file(path)
+	if info, err := os.Stat(file); err == nil && (c.conf.TTL <= 0 || time.Since(info.ModTime()) < c.conf.TTL) {
+		if f, err := os.Open(file); err == nil {
+			return f, nil
+		}
+	}
+
+	generation := c.generation(file)
+	stream, err := c.StorageInterface.GetStream(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return stream, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return stream, nil
+	}
+	return &cacheFill{cache: c, stream: stream, tmp: tmp, file: file, generation: generation}, nil
+}
+
+// Put store a reader into given path and invalidate its cached file
+func (c *cache) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
+	defer c.invalidate(path)
+	return c.StorageInterface.Put(ctx, path, reader, opts...)
+}
+
+// Delete delete file and its cached file
+func (c *cache) Delete(ctx context.Context, path string) error {
+	defer c.invalidate(path)
+	return c.StorageInterface.Delete(ctx, path)
+}
+
+// Copy copy an object and invalidate the cached file of the destination
+func (c *cache) Copy(ctx context.Context, from, to string) error {
+	defer c.invalidate(to)
+	return oss.Copy(ctx, c.StorageInterface, from, to)
+}
+
+// Move move an object and invalidate the cached files of the source and the destination
+func (c *cache) Move(ctx context.Context, from, to string) error {
+	defer c.invalidate(from)
+	defer c.invalidate(to)
+	return oss.Move(ctx, c.StorageInterface, from, to)
+}
+
+// DeleteObjects delete objects and their cached files
+func (c *cache) DeleteObjects(ctx context.Context, paths []string) error {
+	defer func() {
+		for _, path := range paths {
+			c.invalidate(path)
+		}
+	}()
+	return oss.DeleteObjects(ctx, c.StorageInterface, paths)
+}
+
+func (c *cache) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	uploader, err := oss.AsMultipartUploader(c.StorageInterface)
+	if err != nil {
+		return "", err
+	}
+	return uploader.InitiateMultipartUpload(ctx, path, opts...)
+}
+
+func (c *cache) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	uploader, err := oss.AsMultipartUploader(c.StorageInterface)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.UploadPart(ctx, path, uploadID, partNumber, reader, size)
+}
+
+func (c *cache) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	uploader, err := oss.AsMultipartUploader(c.StorageInterface)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.ListParts(ctx, path, uploadID)
+}
+
+// CompleteMultipartUpload complete an upload and invalidate the cached file of its path
+func (c *cache) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	uploader, err := oss.AsMultipartUploader(c.StorageInterface)
+	if err != nil {
+		return nil, err
+	}
+	defer c.invalidate(path)
+	return uploader.CompleteMultipartUpload(ctx, path, uploadID, parts)
+}
+
+func (c *cache) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	uploader, err := oss.AsMultipartUploader(c.StorageInterface)
+	if err != nil {
+		return err
+	}
+	return uploader.AbortMultipartUpload(ctx, path, uploadID)
+}
+
+// cacheFill copies the stream into a temporary file, which replaces the cached file if the stream is read completely
+type cacheFill struct {
+	cache      *cache
+	stream     io.ReadCloser
+	tmp        *os.File
+	file       string
+	generation uint64
+	complete   bool
+	failed     bool
+}
+
+func (f *cacheFill) Read(p []byte) (int, error) {
+	n, err := f.stream.Read(p)
+	if n > 0 && !f.failed {
+		if _, werr := f.tmp.Write(p[:n]); werr != nil {
+			f.failed = true
+		}
+	}
+	if err == io.EOF {
+		f.complete = true
+	}
+	return n, err
+}
+
+func (f *cacheFill) Close() error {
+	err := f.stream.Close()
+
+	closeErr := f.tmp.Close()
+	if f.complete && !f.failed && closeErr == nil {
+		f.cache.mu.Lock()
+		if f.cache.generations[f.file] == f.generation {
+			if os.Rename(f.tmp.Name(), f.file) == nil {
+				f.cache.mu.Unlock()
+				return err
+			}
+		}
+		f.cache.mu.Unlock()
+	}
+	os.Remove(f.tmp.Name())
+	return err
+}
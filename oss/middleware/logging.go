@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// Logging logs every operation with its path and duration, failures are logged at error level,
+// successes and ErrNotFound at debug level. A nil logger uses slog.Default.
+func Logging(logger *slog.Logger) Middleware {
+	return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+
+		start := time.Now()
+		err := invoke(ctx)
+
+		attrs := []slog.Attr{
+			slog.String("oss.operation", string(call.Operation)),
+			slog.String("oss.path", call.Path),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if call.To != "" {
+			attrs = append(attrs, slog.String("oss.to", call.To))
+		}
+		if call.Paths != nil {
+			attrs = append(attrs, slog.Int("oss.count", len(call.Paths)))
+		}
+		switch {
+		case err == nil:
+			l.LogAttrs(ctx, slog.LevelDebug, "oss operation succeeded", attrs...)
+		case errors.Is(err, oss.ErrNotFound):
+			l.LogAttrs(ctx, slog.LevelDebug, "oss object not found", attrs...)
+		default:
+			l.LogAttrs(ctx, slog.LevelError, "oss operation failed", append(attrs, slog.Any("error", err))...)
+		}
+		return err
+	})
+}
@@ -0,0 +1,275 @@
+// Package middleware decorates oss.StorageInterface with retries, timeouts, logging and caching.
+//
+// Middlewares are chained like hook.Chain, the first one is the outermost:
+//
+//	storage = middleware.Wrap(client,
+//		middleware.Logging(slog.Default()),
+//		middleware.Retry(nil),
+//		middleware.Timeout(30*time.Second),
+//	)
+package middleware
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/qor5/x/v3/hook"
+	"github.com/qor5/x/v3/oss"
+)
+
+// Middleware decorates a storage
+type Middleware = hook.Hook[oss.StorageInterface]
+
+// Chain chains middlewares, the first one is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return hook.Chain(middlewares...)
+}
+
+// Wrap decorates storage with middlewares, the first one is the outermost
+func Wrap(storage oss.StorageInterface, middlewares ...Middleware) oss.StorageInterface {
+	if chain := Chain(middlewares...); chain != nil {
+		return chain(storage)
+	}
+	return storage
+}
+
+// Operation names a method of oss.StorageInterface
+type Operation string
+
+const (
+	OpGet       Operation = "Get"
+	OpGetStream Operation = "GetStream"
+	OpPut       Operation = "Put"
+	OpStat      Operation = "Stat"
+	OpDelete    Operation = "Delete"
+	OpList      Operation = "List"
+	OpListPage  Operation = "ListPage"
+	OpGetURL    Operation = "GetURL"
+
+	OpPresignPut              Operation = "PresignPut"
+	OpPresignGet              Operation = "PresignGet"
+	OpCopy                    Operation = "Copy"
+	OpMove                    Operation = "Move"
+	OpDeleteObjects           Operation = "DeleteObjects"
+	OpInitiateMultipartUpload Operation = "InitiateMultipartUpload"
+	OpUploadPart              Operation = "UploadPart"
+	OpListParts               Operation = "ListParts"
+	OpCompleteMultipartUpload Operation = "CompleteMultipartUpload"
+	OpAbortMultipartUpload    Operation = "AbortMultipartUpload"
+)
+
+// Call describes an intercepted operation
+type Call struct {
+	Operation Operation
+	// Path is the object path, the prefix for OpListPage, or the source for OpCopy and OpMove
+	Path string
+	// To is the destination of OpCopy and OpMove
+	To string
+	// Paths are the objects of OpDeleteObjects
+	Paths []string
+	// Reader is the content of OpPut and OpUploadPart
+	Reader io.Reader
+	// Stream is the result of OpGetStream once invoked, interceptors may replace it
+	Stream io.ReadCloser
+}
+
+// Interceptor runs around an operation, invoke calls the next storage and may be called more than once
+type Interceptor func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error
+
+// Intercept builds a middleware that runs interceptor around every operation but GetEndpoint,
+// including the optional interfaces of oss, which return oss.ErrUnsupported if the wrapped storage doesn't support them
+func Intercept(interceptor Interceptor) Middleware {
+	return func(next oss.StorageInterface) oss.StorageInterface {
+		return &intercepted{next: next, interceptor: interceptor}
+	}
+}
+
+type intercepted struct {
+	next        oss.StorageInterface
+	interceptor Interceptor
+}
+
+var (
+	_ oss.StorageInterface  = (*intercepted)(nil)
+	_ oss.PageLister        = (*intercepted)(nil)
+	_ oss.Presigner         = (*intercepted)(nil)
+	_ oss.Copier            = (*intercepted)(nil)
+	_ oss.Mover             = (*intercepted)(nil)
+	_ oss.BatchDeleter      = (*intercepted)(nil)
+	_ oss.MultipartUploader = (*intercepted)(nil)
+	_ oss.Unwrapper         = (*intercepted)(nil)
+)
+
+func (s *intercepted) Unwrap() oss.StorageInterface {
+	return s.next
+}
+
+func (s *intercepted) Get(ctx context.Context, path string) (file *os.File, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpGet, Path: path}, func(ctx context.Context) (err error) {
+		file, err = s.next.Get(ctx, path)
+		return err
+	})
+	return file, err
+}
+
+func (s *intercepted) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	call := &Call{Operation: OpGetStream, Path: path}
+	err := s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		call.Stream, err = s.next.GetStream(ctx, path)
+		return err
+	})
+	if err != nil {
+		if call.Stream != nil {
+			call.Stream.Close()
+		}
+		return nil, err
+	}
+	return call.Stream, nil
+}
+
+func (s *intercepted) Put(ctx context.Context, path string, reader io.Reader, opts ...oss.PutOption) (object *oss.Object, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpPut, Path: path, Reader: reader}, func(ctx context.Context) (err error) {
+		object, err = s.next.Put(ctx, path, reader, opts...)
+		return err
+	})
+	return object, err
+}
+
+func (s *intercepted) Stat(ctx context.Context, path string) (object *oss.Object, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpStat, Path: path}, func(ctx context.Context) (err error) {
+		object, err = s.next.Stat(ctx, path)
+		return err
+	})
+	return object, err
+}
+
+func (s *intercepted) Delete(ctx context.Context, path string) error {
+	return s.interceptor(ctx, &Call{Operation: OpDelete, Path: path}, func(ctx context.Context) error {
+		return s.next.Delete(ctx, path)
+	})
+}
+
+func (s *intercepted) List(ctx context.Context, path string) (objects []*oss.Object, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpList, Path: path}, func(ctx context.Context) (err error) {
+		objects, err = s.next.List(ctx, path)
+		return err
+	})
+	return objects, err
+}
+
+func (s *intercepted) ListPage(ctx context.Context, opts *oss.ListOptions) (result *oss.ListResult, err error) {
+	call := &Call{Operation: OpListPage}
+	if opts != nil {
+		call.Path = opts.Prefix
+	}
+	err = s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		result, err = oss.ListPage(ctx, s.next, opts)
+		return err
+	})
+	return result, err
+}
+
+func (s *intercepted) GetURL(ctx context.Context, path string) (url string, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpGetURL, Path: path}, func(ctx context.Context) (err error) {
+		url, err = s.next.GetURL(ctx, path)
+		return err
+	})
+	return url, err
+}
+
+func (s *intercepted) GetEndpoint(ctx context.Context) string {
+	return s.next.GetEndpoint(ctx)
+}
+
+func (s *intercepted) PresignPut(ctx context.Context, path string, opts ...oss.PresignOption) (req *oss.PresignedRequest, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpPresignPut, Path: path}, func(ctx context.Context) (err error) {
+		req, err = oss.PresignPut(ctx, s.next, path, opts...)
+		return err
+	})
+	return req, err
+}
+
+func (s *intercepted) PresignGet(ctx context.Context, path string, opts ...oss.PresignOption) (req *oss.PresignedRequest, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpPresignGet, Path: path}, func(ctx context.Context) (err error) {
+		req, err = oss.PresignGet(ctx, s.next, path, opts...)
+		return err
+	})
+	return req, err
+}
+
+func (s *intercepted) Copy(ctx context.Context, from, to string) error {
+	return s.interceptor(ctx, &Call{Operation: OpCopy, Path: from, To: to}, func(ctx context.Context) error {
+		return oss.Copy(ctx, s.next, from, to)
+	})
+}
+
+func (s *intercepted) Move(ctx context.Context, from, to string) error {
+	return s.interceptor(ctx, &Call{Operation: OpMove, Path: from, To: to}, func(ctx context.Context) error {
+		return oss.Move(ctx, s.next, from, to)
+	})
+}
+
+func (s *intercepted) DeleteObjects(ctx context.Context, paths []string) error {
+	return s.interceptor(ctx, &Call{Operation: OpDeleteObjects, Paths: paths}, func(ctx context.Context) error {
+		return oss.DeleteObjects(ctx, s.next, paths)
+	})
+}
+
+func (s *intercepted) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (uploadID string, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpInitiateMultipartUpload, Path: path}, func(ctx context.Context) error {
+		uploader, err := oss.AsMultipartUploader(s.next)
+		if err != nil {
+			return err
+		}
+		uploadID, err = uploader.InitiateMultipartUpload(ctx, path, opts...)
+		return err
+	})
+	return uploadID, err
+}
+
+func (s *intercepted) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (part *oss.Part, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpUploadPart, Path: path, Reader: reader}, func(ctx context.Context) error {
+		uploader, err := oss.AsMultipartUploader(s.next)
+		if err != nil {
+			return err
+		}
+		part, err = uploader.UploadPart(ctx, path, uploadID, partNumber, reader, size)
+		return err
+	})
+	return part, err
+}
+
+func (s *intercepted) ListParts(ctx context.Context, path string, uploadID string) (parts []*oss.Part, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpListParts, Path: path}, func(ctx context.Context) error {
+		uploader, err := oss.AsMultipartUploader(s.next)
+		if err != nil {
+			return err
+		}
+		parts, err = uploader.ListParts(ctx, path, uploadID)
+		return err
+	})
+	return parts, err
+}
+
+func (s *intercepted) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (object *oss.Object, err error) {
+	err = s.interceptor(ctx, &Call{Operation: OpCompleteMultipartUpload, Path: path}, func(ctx context.Context) error {
+		uploader, err := oss.AsMultipartUploader(s.next)
+		if err != nil {
+			return err
+		}
+		object, err = uploader.CompleteMultipartUpload(ctx, path, uploadID, parts)
+		return err
+	})
+	return object, err
+}
+
+func (s *intercepted) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	return s.interceptor(ctx, &Call{Operation: OpAbortMultipartUpload, Path: path}, func(ctx context.Context) error {
+		uploader, err := oss.AsMultipartUploader(s.next)
+		if err != nil {
+			return err
+		}
+		return uploader.AbortMultipartUpload(ctx, path, uploadID)
+	})
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/faulty"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	storage := Wrap(memory.New(),
+		Logging(slog.New(slog.DiscardHandler)),
+		Retry(nil),
+		Timeout(time.Second),
+		Cache(CacheConfig{Dir: t.TempDir()}),
+	)
+	tests.TestAll(storage, t)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	storage := faulty.New(memory.New()).
+		Inject(faulty.Fault{PartialRead: 2, Times: 2}, faulty.OpPut).
+		Inject(faulty.Fault{Fail: true}, faulty.OpDelete)
+
+	wrapped := Wrap(storage, Retry(&RetryConfig{InitialBackoff: time.Millisecond}))
+
+	_, err := wrapped.Put(ctx, "/a.txt", strings.NewReader("content"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, storage.Calls(faulty.OpPut))
+
+	stream, err := wrapped.GetStream(ctx, "/a.txt")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(stream)
+	assert.Equal(t, "content", string(data))
+
+	// not a seeker, so it can't be retried
+	_, err = Wrap(storage, Retry(nil)).Put(ctx, "/b.txt", io.MultiReader(strings.NewReader("content")))
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, wrapped.Delete(ctx, "/a.txt"), faulty.ErrInjected)
+	assert.Equal(t, 1, storage.Calls(faulty.OpDelete))
+
+	_, err = wrapped.Stat(ctx, "/missing.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+	storage := faulty.New(memory.New()).Inject(faulty.Fault{Latency: time.Second}, faulty.OpStat)
+	wrapped := Wrap(storage, Timeout(10*time.Millisecond, OpStat))
+
+	_, err := wrapped.Put(ctx, "/a.txt", strings.NewReader("content"))
+	require.NoError(t, err)
+
+	_, err = wrapped.Stat(ctx, "/a.txt")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	storage := faulty.New(memory.New())
+	wrapped := Wrap(storage, Cache(CacheConfig{Dir: t.TempDir()}))
+
+	read := func() string {
+		stream, err := wrapped.GetStream(ctx, "/a.txt")
+		require.NoError(t, err)
+		defer stream.Close()
+		data, err := io.ReadAll(stream)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	_, err := wrapped.Put(ctx, "/a.txt", strings.NewReader("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", read())
+	assert.Equal(t, "v1", read())
+	assert.Equal(t, 1, storage.Calls(faulty.OpGetStream))
+
+	_, err = wrapped.Put(ctx, "/a.txt", bytes.NewReader([]byte("v2")))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", read())
+	assert.Equal(t, 2, storage.Calls(faulty.OpGetStream))
+
+	// partial reads are not cached
+	stream, err := wrapped.GetStream(ctx, "/a.txt")
+	require.NoError(t, err)
+	_, err = stream.Read(make([]byte, 1))
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+
+	require.NoError(t, wrapped.Delete(ctx, "/a.txt"))
+	_, err = wrapped.GetStream(ctx, "/a.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	storage := faulty.New(memory.New())
+
+	var (
+		mu  sync.Mutex
+		ops []Operation
+	)
+	record := Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		mu.Lock()
+		ops = append(ops, call.Operation)
+		mu.Unlock()
+		return invoke(ctx)
+	})
+	wrapped := Wrap(storage, record, Retry(&RetryConfig{InitialBackoff: time.Millisecond}), Cache(CacheConfig{Dir: t.TempDir()}))
+
+	read := func(path string) (string, error) {
+		stream, err := wrapped.GetStream(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		defer stream.Close()
+		data, err := io.ReadAll(stream)
+		return string(data), err
+	}
+	for path, content := range map[string]string{"/a.txt": "a", "/b.txt": "b"} {
+		_, err := wrapped.Put(ctx, path, strings.NewReader(content))
+		require.NoError(t, err)
+		data, err := read(path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	}
+
+	// the copy is retried and invalidates the cached destination
+	storage.Inject(faulty.Fault{Err: io.ErrUnexpectedEOF, Times: 1}, faulty.OpCopy)
+	require.NoError(t, oss.Copy(ctx, wrapped, "/a.txt", "/b.txt"))
+	assert.Equal(t, 2, storage.Calls(faulty.OpCopy))
+	data, err := read("/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a", data)
+
+	require.NoError(t, oss.Move(ctx, wrapped, "/b.txt", "/c.txt"))
+	_, err = read("/b.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	data, err = read("/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a", data)
+
+	require.NoError(t, oss.DeleteObjects(ctx, wrapped, []string{"/a.txt", "/c.txt"}))
+	_, err = read("/a.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	assert.Equal(t, 1, storage.Calls(faulty.OpDeleteObjects))
+
+	_, err = wrapped.Put(ctx, "/m.txt", strings.NewReader("old"))
+	require.NoError(t, err)
+	_, err = read("/m.txt")
+	require.NoError(t, err)
+	uploader, err := oss.AsMultipartUploader(wrapped)
+	require.NoError(t, err)
+	_, err = oss.PutMultipart(ctx, uploader, "/m.txt", strings.NewReader("new content"), &oss.MultipartConfig{PartSize: 4})
+	require.NoError(t, err)
+	data, err = read("/m.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "new content", data)
+	assert.Equal(t, 3, storage.Calls(faulty.OpUploadPart))
+
+	_, err = oss.ListPage(ctx, wrapped, &oss.ListOptions{Prefix: "/"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, storage.Calls(faulty.OpListPage))
+
+	_, err = oss.PresignGet(ctx, wrapped, "/m.txt")
+	assert.ErrorIs(t, err, oss.ErrUnsupported)
+	assert.Equal(t, 1, storage.Calls(faulty.OpPresignGet))
+
+	for _, op := range []Operation{OpCopy, OpMove, OpDeleteObjects, OpInitiateMultipartUpload, OpUploadPart, OpCompleteMultipartUpload, OpListPage, OpPresignGet} {
+		assert.Contains(t, ops, op)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	storage := Wrap(memory.New(), Retry(nil), Cache(CacheConfig{Dir: t.TempDir()}))
+	_, ok := oss.As[oss.MultipartUploader](storage)
+	assert.True(t, ok)
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// RetryConfig configures Retry
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts including the first one, defaults to 3
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, defaults to 100ms
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries, defaults to 5s
+	MaxBackoff time.Duration
+	// Retryable reports whether an error is transient, defaults to IsTransient
+	Retryable func(err error) bool
+}
+
+func (conf *RetryConfig) withDefaults() RetryConfig {
+	c := RetryConfig{}
+	if conf != nil {
+		c = *conf
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = 100 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Second
+	}
+	if c.Retryable == nil {
+		c.Retryable = IsTransient
+	}
+	return c
+}
+
+// backoff returns the delay before the given retry, doubling each time with full jitter
+func (conf RetryConfig) backoff(retry int) time.Duration {
+	d := conf.InitialBackoff << (retry - 1)
+	if d <= 0 || d > conf.MaxBackoff {
+		d = conf.MaxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// Retry retries operations failing with transient errors with exponential backoff.
+// Put is only retried when its reader is an io.Seeker, so the content can be read again.
+func Retry(conf *RetryConfig) Middleware {
+	c := conf.withDefaults()
+	return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		var start int64
+		seeker, replayable := call.Reader.(io.Seeker)
+		if call.Reader == nil {
+			replayable = true
+		} else if replayable {
+			var err error
+			if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+				replayable = false
+			}
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := invoke(ctx)
+			if err == nil || !replayable || attempt >= c.MaxAttempts || !c.Retryable(err) || ctx.Err() != nil {
+				return err
+			}
+
+			timer := time.NewTimer(c.backoff(attempt))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+
+			if seeker != nil {
+				if _, seekErr := seeker.Seek(start, io.SeekStart); seekErr != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+// IsTransient reports whether err is likely to go away on retry: network errors, unexpected EOFs,
+// timeouts of a single attempt, throttling and server errors of HTTP based SDKs
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, oss.ErrNotFound) || errors.Is(err, oss.ErrUnsupported) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		code := statusErr.HTTPStatusCode()
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+)
+
+// Timeout bounds the given operations, or all of them if none is given.
+// The timeout of GetStream covers reading the stream until it's closed.
+// Put it inside Retry to bound each attempt, or outside to bound all attempts.
+func Timeout(timeout time.Duration, ops ...Operation) Middleware {
+	return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		if timeout <= 0 || (len(ops) > 0 && !slices.Contains(ops, call.Operation)) {
+			return invoke(ctx)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		if err := invoke(ctx); err != nil {
+			cancel()
+			return err
+		}
+		if call.Operation == OpGetStream && call.Stream != nil {
+			call.Stream = &cancelOnClose{ReadCloser: call.Stream, cancel: cancel}
+			return nil
+		}
+		cancel()
+		return nil
+	})
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
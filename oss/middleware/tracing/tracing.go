@@ -0,0 +1,43 @@
+// Package tracing records a logtracing span for every oss operation, it lives apart from
+// package middleware so that depending on oss does not pull in appkit.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/middleware"
+	"github.com/theplant/appkit/logtracing"
+)
+
+// Middleware records a span named oss.<operation> around every operation,
+// ErrNotFound is recorded as an attribute instead of a span error
+func Middleware() middleware.Middleware {
+	return middleware.Intercept(func(ctx context.Context, call *middleware.Call, invoke func(ctx context.Context) error) (xerr error) {
+		ctx, span := logtracing.StartSpan(ctx, fmt.Sprintf("oss.%s", call.Operation))
+		defer func() {
+			if errors.Is(xerr, oss.ErrNotFound) {
+				span.AppendKVs("oss.not_found", true)
+				logtracing.EndSpan(ctx, nil)
+				return
+			}
+			logtracing.EndSpan(ctx, xerr)
+		}()
+
+		span.AppendKVs(
+			"span.type", "storage",
+			"span.role", "client",
+			"oss.operation", string(call.Operation),
+			"oss.path", call.Path,
+		)
+		if call.To != "" {
+			span.AppendKVs("oss.to", call.To)
+		}
+		if call.Paths != nil {
+			span.AppendKVs("oss.count", len(call.Paths))
+		}
+		return invoke(ctx)
+	})
+}
@@ -0,0 +1,219 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMultipartPartSize is used when MultipartConfig.PartSize is not set
+	DefaultMultipartPartSize int64 = 8 << 20
+	// DefaultMultipartConcurrency is used when MultipartConfig.Concurrency is not set
+	DefaultMultipartConcurrency = 4
+	// MinMultipartPartSize is the smallest part size accepted by S3 compatible storages, except for the last part
+	MinMultipartPartSize int64 = 5 << 20
+)
+
+// Part an uploaded part of a multipart upload
+type Part struct {
+	PartNumber   int
+	ETag         string
+	Size         int64
+	LastModified *time.Time
+}
+
+// MultipartUploader is implemented by storages that support multipart uploads,
+// an upload can be resumed by listing the parts already uploaded with the upload ID
+type MultipartUploader interface {
+	InitiateMultipartUpload(ctx context.Context, path string, opts ...PutOption) (uploadID string, err error)
+	UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*Part, error)
+	ListParts(ctx context.Context, path string, uploadID string) ([]*Part, error)
+	CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*Part) (*Object, error)
+	AbortMultipartUpload(ctx context.Context, path string, uploadID string) error
+}
+
+// AsMultipartUploader returns the MultipartUploader found by As, or ErrUnsupported if storage is not one
+func AsMultipartUploader(storage StorageInterface) (MultipartUploader, error) {
+	uploader, ok := As[MultipartUploader](storage)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return uploader, nil
+}
+
+// MultipartConfig controls how Put switches to multipart uploads
+type MultipartConfig struct {
+	// Threshold is the content size above which Put uses a multipart upload, zero disables it
+	Threshold int64
+	// PartSize is the size of each part, defaults to DefaultMultipartPartSize
+	PartSize int64
+	// Concurrency is the number of parts uploaded in parallel, defaults to DefaultMultipartConcurrency
+	Concurrency int
+}
+
+func (conf *MultipartConfig) partSize() int64 {
+	if conf == nil || conf.PartSize <= 0 {
+		return DefaultMultipartPartSize
+	}
+	return conf.PartSize
+}
+
+func (conf *MultipartConfig) concurrency() int {
+	if conf == nil || conf.Concurrency <= 0 {
+		return DefaultMultipartConcurrency
+	}
+	return conf.Concurrency
+}
+
+// ExceedsMultipartThreshold reports whether reader holds more than conf.Threshold bytes.
+// It may read up to Threshold+1 bytes, so the returned reader must be used in place of reader.
+func ExceedsMultipartThreshold(reader io.Reader, conf *MultipartConfig) (io.Reader, bool, error) {
+	if conf == nil || conf.Threshold <= 0 {
+		return reader, false, nil
+	}
+
+	if seeker, ok := reader.(io.Seeker); ok {
+		if size, err := remainingSize(seeker); err == nil {
+			return reader, size > conf.Threshold, nil
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	n, err := io.CopyN(buf, reader, conf.Threshold+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false, err
+	}
+	return io.MultiReader(buf, reader), n > conf.Threshold, nil
+}
+
+func remainingSize(seeker io.Seeker) (int64, error) {
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - current, nil
+}
+
+// PutMultipart upload reader in parts of conf.PartSize with conf.Concurrency parallel uploads,
+// the upload is aborted if any part fails
+func PutMultipart(ctx context.Context, uploader MultipartUploader, path string, reader io.Reader, conf *MultipartConfig, opts ...PutOption) (*Object, error) {
+	uploadID, err := uploader.InitiateMultipartUpload(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := uploadParts(ctx, uploader, path, uploadID, reader, nil, conf)
+	if err != nil {
+		// use a fresh context, the upload should be cleaned up even if ctx is canceled
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if abortErr := uploader.AbortMultipartUpload(abortCtx, path, uploadID); abortErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("abort multipart upload %s: %w", uploadID, abortErr))
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+// ResumeMultipart continue an interrupted multipart upload, reader must provide the whole content from the beginning.
+// Parts already uploaded with the expected size are skipped, the upload is kept on failure so it can be resumed again.
+func ResumeMultipart(ctx context.Context, uploader MultipartUploader, path string, uploadID string, reader io.Reader, conf *MultipartConfig) (*Object, error) {
+	parts, err := uploader.ListParts(ctx, path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return uploadParts(ctx, uploader, path, uploadID, reader, parts, conf)
+}
+
+func uploadParts(ctx context.Context, uploader MultipartUploader, path string, uploadID string, reader io.Reader, uploaded []*Part, conf *MultipartConfig) (*Object, error) {
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		seeker.Seek(0, 0)
+	}
+
+	partSize := conf.partSize()
+	uploadedByNumber := map[int]*Part{}
+	for _, part := range uploaded {
+		uploadedByNumber[part.PartNumber] = part
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu    sync.Mutex
+		parts []*Part
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, conf.concurrency())
+	)
+
+	for partNumber := 1; ; partNumber++ {
+		buf := make([]byte, partSize)
+		n, err := io.ReadFull(reader, buf)
+		if errors.Is(err, io.EOF) && partNumber > 1 {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			cancel(err)
+			break
+		}
+		last := n < len(buf)
+
+		if part, ok := uploadedByNumber[partNumber]; ok && part.Size == int64(n) {
+			mu.Lock()
+			parts = append(parts, part)
+			mu.Unlock()
+		} else {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+
+			wg.Add(1)
+			go func(partNumber int, data []byte) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				part, err := uploader.UploadPart(ctx, path, uploadID, partNumber, bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					cancel(fmt.Errorf("upload part %d: %w", partNumber, err))
+					return
+				}
+				if part.Size == 0 {
+					part.Size = int64(len(data))
+				}
+
+				mu.Lock()
+				parts = append(parts, part)
+				mu.Unlock()
+			}(partNumber, buf[:n])
+		}
+
+		if last {
+			break
+		}
+	}
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(parts, func(a, b *Part) int { return a.PartNumber - b.PartNumber })
+	return uploader.CompleteMultipartUpload(ctx, path, uploadID, parts)
+}
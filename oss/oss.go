@@ -2,27 +2,72 @@ package oss
 
 import (
 	"context"
+	"errors"
 	"io"
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+// ErrNotFound is wrapped by the errors returned for objects that do not exist
+var ErrNotFound = errors.New("oss: object not found")
+
 // StorageInterface define common API to operate storage
 type StorageInterface interface {
 	Get(ctx context.Context, path string) (*os.File, error)
 	GetStream(ctx context.Context, path string) (io.ReadCloser, error)
-	Put(ctx context.Context, path string, reader io.Reader) (*Object, error)
+	Put(ctx context.Context, path string, reader io.Reader, opts ...PutOption) (*Object, error)
+	// Stat returns the object's metadata without downloading its content
+	Stat(ctx context.Context, path string) (*Object, error)
 	Delete(ctx context.Context, path string) error
 	List(ctx context.Context, path string) ([]*Object, error)
 	GetURL(ctx context.Context, path string) (string, error)
 	GetEndpoint(ctx context.Context) string
 }
 
+// Unwrapper is implemented by storages that decorate another storage without changing its paths
+type Unwrapper interface {
+	Unwrap() StorageInterface
+}
+
+// As finds the first storage in the Unwrap chain of storage that implements T,
+// so optional interfaces like Presigner keep working through decorators that only Unwrap.
+// Decorators that act on operations, like retries or caching, must implement the optional interfaces themselves,
+// otherwise As would bypass them.
+func As[T any](storage StorageInterface) (T, bool) {
+	for storage != nil {
+		if t, ok := storage.(T); ok {
+			return t, true
+		}
+		unwrapper, ok := storage.(Unwrapper)
+		if !ok {
+			break
+		}
+		storage = unwrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 // Object content object
 type Object struct {
-	Path             string
-	Name             string
-	LastModified     *time.Time
+	Path         string
+	Name         string
+	LastModified *time.Time
+	// Size is the content length in bytes, it is only reliable for objects returned by Stat and List
+	Size               int64
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	// ETag is the backend's content hash without quotes, it is only comparable between objects of the same backend
+	ETag string
+	// Metadata is the user defined metadata, keys are lower case
+	Metadata map[string]string
+	// VersionID is the version of the object, it is only set by backends with versioning enabled
+	VersionID        string
 	StorageInterface StorageInterface
 }
 
@@ -30,3 +75,72 @@ type Object struct {
 func (object Object) Get(ctx context.Context) (*os.File, error) {
 	return object.StorageInterface.Get(ctx, object.Path)
 }
+
+// PutOptions options for storing an object
+type PutOptions struct {
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
+	// Extra holds backend specific options keyed by an unexported type of the backend, other backends ignore them
+	Extra map[any]any `json:"-"`
+}
+
+// PutOption configures PutOptions
+type PutOption func(*PutOptions)
+
+// PutContentType sets the Content-Type of the stored object, by default it is detected from the path and content
+func PutContentType(contentType string) PutOption {
+	return func(o *PutOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// PutCacheControl sets the Cache-Control of the stored object
+func PutCacheControl(cacheControl string) PutOption {
+	return func(o *PutOptions) {
+		o.CacheControl = cacheControl
+	}
+}
+
+// PutContentDisposition sets the Content-Disposition of the stored object
+func PutContentDisposition(contentDisposition string) PutOption {
+	return func(o *PutOptions) {
+		o.ContentDisposition = contentDisposition
+	}
+}
+
+// PutMetadata adds user defined metadata to the stored object, keys are case insensitive
+func PutMetadata(metadata map[string]string) PutOption {
+	return func(o *PutOptions) {
+		if o.Metadata == nil {
+			o.Metadata = map[string]string{}
+		}
+		for k, v := range metadata {
+			o.Metadata[strings.ToLower(k)] = v
+		}
+	}
+}
+
+// NewPutOptions applies opts
+func NewPutOptions(opts ...PutOption) *PutOptions {
+	o := &PutOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DetectContentType returns contentType if not empty, otherwise the type by path's extension, and finally by sniffing data
+func DetectContentType(contentType string, path string, data []byte) string {
+	if contentType != "" {
+		return contentType
+	}
+	if fileType := mime.TypeByExtension(filepath.Ext(path)); fileType != "" {
+		return fileType
+	}
+	if data == nil {
+		return ""
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,113 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// ErrUnsupported is returned when a storage does not implement an optional capability
+var ErrUnsupported = errors.New("oss: operation not supported by storage")
+
+// DefaultPresignExpires is used when no expiry is given to a presign call
+const DefaultPresignExpires = time.Hour
+
+// PresignOptions options for generating presigned requests
+type PresignOptions struct {
+	// Expires is how long the presigned request stays valid
+	Expires time.Duration
+	// ContentType restricts (for uploads) or overrides (for downloads) the content type
+	ContentType string
+	// MaxSize limits the size of an upload in bytes, zero means no limit.
+	// Backends that cannot enforce it on a PUT request switch to a POST form upload.
+	MaxSize int64
+	// ContentDisposition overrides the Content-Disposition of downloads, the stored one is served if empty
+	ContentDisposition string
+}
+
+// PresignOption configures PresignOptions
+type PresignOption func(*PresignOptions)
+
+// WithExpires sets how long the presigned request stays valid
+func WithExpires(expires time.Duration) PresignOption {
+	return func(o *PresignOptions) {
+		o.Expires = expires
+	}
+}
+
+// WithContentType sets the content type of the presigned request
+func WithContentType(contentType string) PresignOption {
+	return func(o *PresignOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// WithMaxSize limits the size of a presigned upload
+func WithMaxSize(maxSize int64) PresignOption {
+	return func(o *PresignOptions) {
+		o.MaxSize = maxSize
+	}
+}
+
+// WithContentDisposition sets the Content-Disposition of a presigned download, e.g. WithContentDisposition("attachment", "report.pdf"),
+// the filename is escaped, and omitted if empty
+func WithContentDisposition(disposition string, filename string) PresignOption {
+	return func(o *PresignOptions) {
+		var params map[string]string
+		if filename != "" {
+			params = map[string]string{"filename": filename}
+		}
+		o.ContentDisposition = mime.FormatMediaType(disposition, params)
+	}
+}
+
+// NewPresignOptions applies opts on top of the defaults
+func NewPresignOptions(opts ...PresignOption) *PresignOptions {
+	o := &PresignOptions{Expires: DefaultPresignExpires}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Expires <= 0 {
+		o.Expires = DefaultPresignExpires
+	}
+	return o
+}
+
+// PresignedRequest a request that can be performed by a client without credentials
+type PresignedRequest struct {
+	// Method is the HTTP method the client must use, PUT/GET or POST for form uploads
+	Method string
+	URL    string
+	// Header contains the headers the client must send along with the request
+	Header http.Header
+	// FormData contains the fields of a multipart/form-data upload when Method is POST,
+	// the file content must be sent as the last field named "file"
+	FormData  map[string]string
+	ExpiresAt time.Time
+}
+
+// Presigner is implemented by storages that can generate presigned upload and download requests
+type Presigner interface {
+	PresignPut(ctx context.Context, path string, opts ...PresignOption) (*PresignedRequest, error)
+	PresignGet(ctx context.Context, path string, opts ...PresignOption) (*PresignedRequest, error)
+}
+
+// PresignPut generates a presigned upload request, returns ErrUnsupported if storage is not a Presigner
+func PresignPut(ctx context.Context, storage StorageInterface, path string, opts ...PresignOption) (*PresignedRequest, error) {
+	presigner, ok := As[Presigner](storage)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return presigner.PresignPut(ctx, path, opts...)
+}
+
+// PresignGet generates a presigned download request, returns ErrUnsupported if storage is not a Presigner
+func PresignGet(ctx context.Context, storage StorageInterface, path string, opts ...PresignOption) (*PresignedRequest, error) {
+	presigner, ok := As[Presigner](storage)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return presigner.PresignGet(ctx, path, opts...)
+}
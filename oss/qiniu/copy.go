@@ -0,0 +1,53 @@
+package qiniu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/qiniu/api.v7/v7/storage"
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.Copier       = (*Client)(nil)
+	_ oss.Mover        = (*Client)(nil)
+	_ oss.BatchDeleter = (*Client)(nil)
+)
+
+// maxBatchOperations is the maximum number of operations of a batch request
+const maxBatchOperations = 1000
+
+// Copy copy an object inside the bucket, overwriting the destination
+func (client Client) Copy(ctx context.Context, from, to string) error {
+	return wrapNotFound(client.bucketManager.Copy(client.Config.Bucket, storageKey(from), client.Config.Bucket, storageKey(to), true))
+}
+
+// Move move an object inside the bucket, overwriting the destination
+func (client Client) Move(ctx context.Context, from, to string) error {
+	return wrapNotFound(client.bucketManager.Move(client.Config.Bucket, storageKey(from), client.Config.Bucket, storageKey(to), true))
+}
+
+// DeleteObjects delete objects with batch requests of 1000 operations, missing objects are ignored
+func (client Client) DeleteObjects(ctx context.Context, paths []string) error {
+	operations := make([]string, 0, len(paths))
+	for _, path := range paths {
+		operations = append(operations, storage.URIDelete(client.Config.Bucket, storageKey(path)))
+	}
+
+	offset := 0
+	for batch := range slices.Chunk(operations, maxBatchOperations) {
+		rets, err := client.bucketManager.Batch(batch)
+		if err != nil {
+			return err
+		}
+		for i, ret := range rets {
+			if ret.Code != http.StatusOK && ret.Code != errCodeNotFound {
+				return fmt.Errorf("delete %s: %d %s", paths[offset+i], ret.Code, ret.Data.Error)
+			}
+		}
+		offset += len(batch)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package qiniu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qiniu/api.v7/v7/storage"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.Presigner = (*Client)(nil)
+
+// PresignPut generate a form upload with an upload token, Qiniu only accepts browser uploads through POST
+func (client Client) PresignPut(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+	key := storageKey(path)
+
+	putPolicy := storage.PutPolicy{
+		Scope:      fmt.Sprintf("%s:%s", client.Config.Bucket, key),
+		Expires:    uint64(o.Expires.Seconds()),
+		FsizeLimit: o.MaxSize,
+		MimeLimit:  o.ContentType,
+	}
+
+	upHost, err := client.upHost()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PresignedRequest{
+		Method: http.MethodPost,
+		URL:    upHost,
+		FormData: map[string]string{
+			"token": putPolicy.UploadToken(client.mac),
+			"key":   key,
+		},
+		ExpiresAt: time.Now().Add(o.Expires),
+	}, nil
+}
+
+// PresignGet generate a private download URL
+func (client Client) PresignGet(ctx context.Context, path string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+	expiresAt := time.Now().Add(o.Expires)
+
+	return &oss.PresignedRequest{
+		Method:    http.MethodGet,
+		URL:       storage.MakePrivateURL(client.mac, client.Config.Endpoint, storageKey(path), expiresAt.Unix()),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (client Client) upHost() (string, error) {
+	zone := client.storageCfg.Zone
+	hosts := zone.SrcUpHosts
+	if client.Config.UseCdnDomains && len(zone.CdnUpHosts) > 0 {
+		hosts = zone.CdnUpHosts
+	}
+	if len(hosts) == 0 {
+		return "", fmt.Errorf("no upload host for zone %s", client.Config.Region)
+	}
+
+	scheme := "http://"
+	if client.Config.UseHTTPS {
+		scheme = "https://"
+	}
+	return scheme + hosts[0], nil
+}
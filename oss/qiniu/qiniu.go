@@ -3,23 +3,25 @@ package qiniu
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"mime"
 	"net/http"
 	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/qiniu/api.v7/v7/auth/qbox"
+	qiniuclient "github.com/qiniu/api.v7/v7/client"
 	"github.com/qiniu/api.v7/v7/storage"
 	"github.com/qor5/x/v3/oss"
 )
 
+var _ oss.PageLister = (*Client)(nil)
+
 // Client Qiniu storage
 type Client struct {
 	Config        *Config
@@ -95,7 +97,9 @@ func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser,
 
 	var res *http.Response
 	res, err = http.Get(purl)
-	if err == nil && res.StatusCode != http.StatusOK {
+	if err == nil && res.StatusCode == http.StatusNotFound {
+		err = fmt.Errorf("%w: file %s not found", oss.ErrNotFound, path)
+	} else if err == nil && res.StatusCode != http.StatusOK {
 		err = fmt.Errorf("file %s not found", path)
 	}
 
@@ -103,7 +107,8 @@ func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser,
 }
 
 // Put store a reader into given path
-func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader) (r *oss.Object, err error) {
+// Qiniu form uploads do not support Cache-Control and Content-Disposition, those options are ignored
+func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader, opts ...oss.PutOption) (r *oss.Object, err error) {
 	if seeker, ok := reader.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
@@ -115,10 +120,8 @@ func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader)
 		return
 	}
 
-	fileType := mime.TypeByExtension(path.Ext(urlPath))
-	if fileType == "" {
-		fileType = http.DetectContentType(buffer)
-	}
+	o := oss.NewPutOptions(opts...)
+	fileType := oss.DetectContentType(o.ContentType, urlPath, buffer)
 
 	putPolicy := storage.PutPolicy{
 		Scope: fmt.Sprintf("%s:%s", client.Config.Bucket, urlPath),
@@ -135,7 +138,11 @@ func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader)
 	dataLen := int64(len(buffer))
 
 	putExtra := storage.PutExtra{
-		Params: map[string]string{},
+		Params:   map[string]string{},
+		MimeType: fileType,
+	}
+	for k, v := range o.Metadata {
+		putExtra.Params[metaPrefix+k] = v
 	}
 	err = formUploader.Put(context.Background(), &ret, upToken, urlPath, bytes.NewReader(buffer), dataLen, &putExtra)
 	if err != nil {
@@ -147,10 +154,49 @@ func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader)
 		Path:             ret.Key,
 		Name:             filepath.Base(urlPath),
 		LastModified:     &now,
+		Size:             dataLen,
+		ContentType:      fileType,
+		ETag:             ret.Hash,
+		Metadata:         o.Metadata,
 		StorageInterface: client,
 	}, err
 }
 
+const (
+	metaPrefix = "x-qn-meta-"
+	// errCodeNotFound is the code Qiniu returns for missing objects
+	errCodeNotFound = 612
+)
+
+func wrapNotFound(err error) error {
+	var errInfo *qiniuclient.ErrorInfo
+	if errors.As(err, &errInfo) && errInfo.Code == errCodeNotFound {
+		return fmt.Errorf("%w: %w", oss.ErrNotFound, err)
+	}
+	return err
+}
+
+// Stat get object's metadata, the ETag is Qiniu's qetag hash
+func (client Client) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	key := storageKey(path)
+	info, err := client.bucketManager.Stat(client.Config.Bucket, key)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	// PutTime is in units of 100 nanoseconds
+	lastModified := time.Unix(0, info.PutTime*100)
+	return &oss.Object{
+		Path:             "/" + key,
+		Name:             filepath.Base(key),
+		LastModified:     &lastModified,
+		Size:             info.Fsize,
+		ContentType:      info.MimeType,
+		ETag:             info.Hash,
+		StorageInterface: client,
+	}, nil
+}
+
 // Delete delete file
 func (client Client) Delete(ctx context.Context, path string) error {
 	return client.bucketManager.Delete(client.Config.Bucket, storageKey(path))
@@ -158,31 +204,43 @@ func (client Client) Delete(ctx context.Context, path string) error {
 
 // List list all objects under current path
 func (client Client) List(ctx context.Context, path string) (objects []*oss.Object, err error) {
-	var prefix = storageKey(path)
-	var listItems []storage.ListItem
-	listItems, _, _, _, err = client.bucketManager.ListFiles(
+	return oss.ListAll(ctx, client, &oss.ListOptions{Prefix: oss.DirPrefix(path)})
+}
+
+// ListPage list a page of objects, Qiniu allows at most 1000 keys per page
+func (client Client) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	listItems, commonPrefixes, nextMarker, hasNext, err := client.bucketManager.ListFiles(
 		client.Config.Bucket,
-		prefix,
-		"",
-		"",
-		100,
+		storageKey(opts.Prefix),
+		opts.Delimiter,
+		opts.ContinuationToken,
+		min(opts.GetMaxKeys(), 1000),
 	)
-
 	if err != nil {
-		return
+		return nil, err
 	}
 
+	result := &oss.ListResult{IsTruncated: hasNext}
+	if hasNext {
+		result.NextContinuationToken = nextMarker
+	}
 	for _, content := range listItems {
-		t := time.Unix(content.PutTime, 0)
-		objects = append(objects, &oss.Object{
+		// PutTime is in units of 100 nanoseconds
+		t := time.Unix(0, content.PutTime*100)
+		result.Objects = append(result.Objects, &oss.Object{
 			Path:             "/" + storageKey(content.Key),
 			Name:             filepath.Base(content.Key),
 			LastModified:     &t,
+			Size:             content.Fsize,
+			ContentType:      content.MimeType,
+			ETag:             content.Hash,
 			StorageInterface: client,
 		})
 	}
-
-	return
+	for _, commonPrefix := range commonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, "/"+storageKey(commonPrefix))
+	}
+	return result, nil
 }
 
 // GetEndpoint get endpoint, FileSystem's endpoint is /
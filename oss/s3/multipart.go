@@ -0,0 +1,129 @@
+package s3
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.MultipartUploader = (*Client)(nil)
+
+// InitiateMultipartUpload start a multipart upload
+func (client Client) InitiateMultipartUpload(ctx context.Context, urlPath string, opts ...oss.PutOption) (string, error) {
+	key := client.ToS3Key(urlPath)
+	o := oss.NewPutOptions(opts...)
+	params := &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(client.Config.Bucket),
+		Key:      aws.String(key),
+		ACL:      types.ObjectCannedACL(client.Config.ACL),
+		Metadata: o.Metadata,
+	}
+	if fileType := oss.DetectContentType(o.ContentType, key, nil); fileType != "" {
+		params.ContentType = aws.String(fileType)
+	}
+	if cacheControl := cmp.Or(o.CacheControl, client.Config.CacheControl); cacheControl != "" {
+		params.CacheControl = aws.String(cacheControl)
+	}
+	if o.ContentDisposition != "" {
+		params.ContentDisposition = aws.String(o.ContentDisposition)
+	}
+	client.options(o).applyMultipart(params)
+
+	output, err := client.S3.CreateMultipartUpload(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.UploadId), nil
+}
+
+// UploadPart upload a part of a multipart upload
+func (client Client) UploadPart(ctx context.Context, urlPath string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	output, err := client.S3.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(client.Config.Bucket),
+		Key:           aws.String(client.ToS3Key(urlPath)),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(int32(partNumber)),
+		Body:          reader,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &oss.Part{PartNumber: partNumber, ETag: aws.ToString(output.ETag), Size: size}, nil
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (client Client) ListParts(ctx context.Context, urlPath string, uploadID string) ([]*oss.Part, error) {
+	var parts []*oss.Part
+
+	paginator := s3.NewListPartsPaginator(client.S3, &s3.ListPartsInput{
+		Bucket:   aws.String(client.Config.Bucket),
+		Key:      aws.String(client.ToS3Key(urlPath)),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range output.Parts {
+			parts = append(parts, &oss.Part{
+				PartNumber:   int(aws.ToInt32(part.PartNumber)),
+				ETag:         aws.ToString(part.ETag),
+				Size:         aws.ToInt64(part.Size),
+				LastModified: part.LastModified,
+			})
+		}
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload assemble the uploaded parts into an object
+func (client Client) CompleteMultipartUpload(ctx context.Context, urlPath string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int32(int32(part.PartNumber)),
+		})
+	}
+
+	key := client.ToS3Key(urlPath)
+	output, err := client.S3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(client.Config.Bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &oss.Object{
+		Path:             "/" + key,
+		Name:             filepath.Base(key),
+		LastModified:     &now,
+		ETag:             strings.Trim(aws.ToString(output.ETag), `"`),
+		VersionID:        aws.ToString(output.VersionId),
+		StorageInterface: client,
+	}, nil
+}
+
+// AbortMultipartUpload abort a multipart upload and remove its parts
+func (client Client) AbortMultipartUpload(ctx context.Context, urlPath string, uploadID string) error {
+	_, err := client.S3.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(client.Config.Bucket),
+		Key:      aws.String(client.ToS3Key(urlPath)),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
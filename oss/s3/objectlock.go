@@ -0,0 +1,37 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// PutRetention retain the object until the given time, versionID is optional.
+// Retention in COMPLIANCE mode can only be extended
+func (client Client) PutRetention(ctx context.Context, path string, versionID string, mode types.ObjectLockRetentionMode, until time.Time) error {
+	_, err := client.S3.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: optional(versionID),
+		Retention: &types.ObjectLockRetention{Mode: mode, RetainUntilDate: &until},
+	})
+	return wrapNotFound(err)
+}
+
+// PutLegalHold place or remove a legal hold on the object, versionID is optional
+func (client Client) PutLegalHold(ctx context.Context, path string, versionID string, on bool) error {
+	status := types.ObjectLockLegalHoldStatusOff
+	if on {
+		status = types.ObjectLockLegalHoldStatusOn
+	}
+	_, err := client.S3.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: optional(versionID),
+		LegalHold: &types.ObjectLockLegalHold{Status: status},
+	})
+	return wrapNotFound(err)
+}
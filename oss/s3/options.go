@@ -0,0 +1,199 @@
+package s3
+
+import (
+	"maps"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/qor5/x/v3/oss"
+)
+
+// Options are the S3 specific options of a single Put, they override the defaults of Config
+type Options struct {
+	ServerSideEncryption types.ServerSideEncryption
+	// SSEKMSKeyID is the KMS key of aws:kms encryption, the bucket's default key is used if empty
+	SSEKMSKeyID  string
+	StorageClass types.StorageClass
+	// Tags are merged with the tags of Config
+	Tags map[string]string
+	// ObjectLockMode and RetainUntil retain the object, the bucket must have object lock enabled
+	ObjectLockMode types.ObjectLockMode
+	RetainUntil    *time.Time
+	LegalHold      bool
+}
+
+// Option configures Options
+type Option func(*Options)
+
+// optionsKey is the key of the S3 options in oss.PutOptions.Extra
+type optionsKey struct{}
+
+// With passes S3 specific options to Put, other backends ignore them
+//
+//	storage.Put(ctx, path, reader, s3.With(s3.WithSSEKMS(keyID), s3.WithStorageClass(types.StorageClassStandardIa)))
+func With(opts ...Option) oss.PutOption {
+	return func(o *oss.PutOptions) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		previous, _ := o.Extra[optionsKey{}].([]Option)
+		o.Extra[optionsKey{}] = append(previous, opts...)
+	}
+}
+
+// WithSSEKMS encrypts the object with the KMS key, the bucket's default key is used if keyID is empty
+func WithSSEKMS(keyID string) Option {
+	return func(o *Options) {
+		o.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		o.SSEKMSKeyID = keyID
+	}
+}
+
+// WithSSES3 encrypts the object with S3 managed keys
+func WithSSES3() Option {
+	return func(o *Options) {
+		o.ServerSideEncryption = types.ServerSideEncryptionAes256
+		o.SSEKMSKeyID = ""
+	}
+}
+
+// WithStorageClass sets the storage class of the object
+func WithStorageClass(storageClass types.StorageClass) Option {
+	return func(o *Options) {
+		o.StorageClass = storageClass
+	}
+}
+
+// WithTags adds tags to the object
+func WithTags(tags map[string]string) Option {
+	return func(o *Options) {
+		if o.Tags == nil {
+			o.Tags = map[string]string{}
+		}
+		maps.Copy(o.Tags, tags)
+	}
+}
+
+// WithRetention retains the object until the given time
+func WithRetention(mode types.ObjectLockMode, until time.Time) Option {
+	return func(o *Options) {
+		o.ObjectLockMode = mode
+		o.RetainUntil = &until
+	}
+}
+
+// WithLegalHold places a legal hold on the object
+func WithLegalHold() Option {
+	return func(o *Options) {
+		o.LegalHold = true
+	}
+}
+
+// options returns the S3 options of a Put, defaulted by the config
+func (client Client) options(putOptions *oss.PutOptions) *Options {
+	config := client.Config
+	o := &Options{
+		ServerSideEncryption: config.ServerSideEncryption,
+		SSEKMSKeyID:          config.SSEKMSKeyID,
+		StorageClass:         config.StorageClass,
+		ObjectLockMode:       config.ObjectLockMode,
+	}
+	if len(config.Tags) > 0 {
+		o.Tags = maps.Clone(config.Tags)
+	}
+	if config.ObjectLockMode != "" && config.ObjectLockRetention > 0 {
+		until := time.Now().Add(config.ObjectLockRetention)
+		o.RetainUntil = &until
+	}
+
+	if putOptions != nil {
+		opts, _ := putOptions.Extra[optionsKey{}].([]Option)
+		for _, opt := range opts {
+			opt(o)
+		}
+	}
+	return o
+}
+
+func (o *Options) encryption() (types.ServerSideEncryption, *string) {
+	if o.ServerSideEncryption == types.ServerSideEncryptionAwsKms || o.ServerSideEncryption == types.ServerSideEncryptionAwsKmsDsse {
+		return o.ServerSideEncryption, optional(o.SSEKMSKeyID)
+	}
+	return o.ServerSideEncryption, nil
+}
+
+func (o *Options) tagging() *string {
+	if len(o.Tags) == 0 {
+		return nil
+	}
+	tags := url.Values{}
+	for k, v := range o.Tags {
+		tags.Set(k, v)
+	}
+	return aws.String(tags.Encode())
+}
+
+func (o *Options) legalHold() types.ObjectLockLegalHoldStatus {
+	if o.LegalHold {
+		return types.ObjectLockLegalHoldStatusOn
+	}
+	return ""
+}
+
+func (o *Options) applyPut(input *s3.PutObjectInput) {
+	input.ServerSideEncryption, input.SSEKMSKeyId = o.encryption()
+	input.StorageClass = o.StorageClass
+	input.Tagging = o.tagging()
+	if o.RetainUntil != nil {
+		input.ObjectLockMode = o.ObjectLockMode
+		input.ObjectLockRetainUntilDate = o.RetainUntil
+	}
+	input.ObjectLockLegalHoldStatus = o.legalHold()
+}
+
+func (o *Options) applyMultipart(input *s3.CreateMultipartUploadInput) {
+	input.ServerSideEncryption, input.SSEKMSKeyId = o.encryption()
+	input.StorageClass = o.StorageClass
+	input.Tagging = o.tagging()
+	if o.RetainUntil != nil {
+		input.ObjectLockMode = o.ObjectLockMode
+		input.ObjectLockRetainUntilDate = o.RetainUntil
+	}
+	input.ObjectLockLegalHoldStatus = o.legalHold()
+}
+
+// applyCopy keeps the tags of the source object, the encryption and storage class of the copy follow the config
+func (o *Options) applyCopy(input *s3.CopyObjectInput) {
+	input.ServerSideEncryption, input.SSEKMSKeyId = o.encryption()
+	input.StorageClass = o.StorageClass
+	if o.RetainUntil != nil {
+		input.ObjectLockMode = o.ObjectLockMode
+		input.ObjectLockRetainUntilDate = o.RetainUntil
+	}
+}
+
+// presignFields returns the signed form fields of a POST policy upload
+func (o *Options) presignFields() map[string]string {
+	fields := map[string]string{}
+	sse, keyID := o.encryption()
+	if sse != "" {
+		fields["x-amz-server-side-encryption"] = string(sse)
+	}
+	if keyID != nil {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = *keyID
+	}
+	if o.StorageClass != "" {
+		fields["x-amz-storage-class"] = string(o.StorageClass)
+	}
+	return fields
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
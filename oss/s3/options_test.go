@@ -0,0 +1,55 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/qor5/x/v3/oss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	client := Client{Config: &Config{
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+		SSEKMSKeyID:          "default-key",
+		StorageClass:         types.StorageClassStandardIa,
+		Tags:                 map[string]string{"team": "compliance"},
+		ObjectLockMode:       types.ObjectLockModeCompliance,
+		ObjectLockRetention:  time.Hour,
+	}}
+
+	input := &s3.PutObjectInput{}
+	client.options(oss.NewPutOptions()).applyPut(input)
+	assert.Equal(t, types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+	assert.Equal(t, "default-key", aws.ToString(input.SSEKMSKeyId))
+	assert.Equal(t, types.StorageClassStandardIa, input.StorageClass)
+	assert.Equal(t, "team=compliance", aws.ToString(input.Tagging))
+	assert.Equal(t, types.ObjectLockModeCompliance, input.ObjectLockMode)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), aws.ToTime(input.ObjectLockRetainUntilDate), time.Minute)
+	assert.Empty(t, input.ObjectLockLegalHoldStatus)
+
+	until := time.Now().Add(24 * time.Hour)
+	input = &s3.PutObjectInput{}
+	client.options(oss.NewPutOptions(
+		With(WithSSES3(), WithTags(map[string]string{"a b": "c&d"})),
+		With(WithStorageClass(types.StorageClassGlacierIr), WithRetention(types.ObjectLockModeGovernance, until), WithLegalHold()),
+	)).applyPut(input)
+	assert.Equal(t, types.ServerSideEncryptionAes256, input.ServerSideEncryption)
+	assert.Nil(t, input.SSEKMSKeyId)
+	assert.Equal(t, types.StorageClassGlacierIr, input.StorageClass)
+	assert.Equal(t, "a+b=c%26d&team=compliance", aws.ToString(input.Tagging))
+	assert.Equal(t, types.ObjectLockModeGovernance, input.ObjectLockMode)
+	assert.Equal(t, until, aws.ToTime(input.ObjectLockRetainUntilDate))
+	assert.Equal(t, types.ObjectLockLegalHoldStatusOn, input.ObjectLockLegalHoldStatus)
+
+	// the config is not changed by the options of a call
+	assert.Equal(t, map[string]string{"team": "compliance"}, client.Config.Tags)
+	assert.Equal(t, map[string]string{
+		"x-amz-server-side-encryption":                "aws:kms",
+		"x-amz-server-side-encryption-aws-kms-key-id": "default-key",
+		"x-amz-storage-class":                         "STANDARD_IA",
+	}, client.options(nil).presignFields())
+}
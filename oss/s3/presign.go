@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.Presigner = (*Client)(nil)
+
+// PresignPut generate a presigned upload request, uploads limited by MaxSize use a POST policy, which doesn't support the tags and object lock of the config
+func (client Client) PresignPut(ctx context.Context, urlPath string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+	options := client.options(nil)
+	key := client.ToS3Key(urlPath)
+	contentType := cmp.Or(o.ContentType, mime.TypeByExtension(path.Ext(key)))
+	presignClient := s3.NewPresignClient(client.S3)
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(client.Config.Bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if o.MaxSize > 0 {
+		conditions := []any{
+			[]any{"content-length-range", 0, o.MaxSize},
+			map[string]string{"acl": client.Config.ACL},
+		}
+		if contentType != "" {
+			conditions = append(conditions, []any{"eq", "$Content-Type", contentType})
+		}
+		if client.Config.CacheControl != "" {
+			conditions = append(conditions, []any{"eq", "$Cache-Control", client.Config.CacheControl})
+		}
+		fields := options.presignFields()
+		for k, v := range fields {
+			conditions = append(conditions, map[string]string{k: v})
+		}
+		post, err := presignClient.PresignPostObject(ctx, input, func(po *s3.PresignPostOptions) {
+			po.Expires = o.Expires
+			po.Conditions = conditions
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		formData := post.Values
+		formData["acl"] = client.Config.ACL
+		if contentType != "" {
+			formData["Content-Type"] = contentType
+		}
+		if client.Config.CacheControl != "" {
+			formData["Cache-Control"] = client.Config.CacheControl
+		}
+		maps.Copy(formData, fields)
+		return &oss.PresignedRequest{
+			Method:    http.MethodPost,
+			URL:       post.URL,
+			FormData:  formData,
+			ExpiresAt: time.Now().Add(o.Expires),
+		}, nil
+	}
+
+	input.ACL = types.ObjectCannedACL(client.Config.ACL)
+	if client.Config.CacheControl != "" {
+		input.CacheControl = aws.String(client.Config.CacheControl)
+	}
+	options.applyPut(input)
+	req, err := presignClient.PresignPutObject(ctx, input, func(po *s3.PresignOptions) {
+		po.Expires = o.Expires
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PresignedRequest{
+		Method:    req.Method,
+		URL:       req.URL,
+		Header:    signedHeader(req.SignedHeader),
+		ExpiresAt: time.Now().Add(o.Expires),
+	}, nil
+}
+
+// PresignGet generate a presigned download request
+func (client Client) PresignGet(ctx context.Context, urlPath string, opts ...oss.PresignOption) (*oss.PresignedRequest, error) {
+	o := oss.NewPresignOptions(opts...)
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(client.Config.Bucket),
+		Key:    aws.String(client.ToS3Key(urlPath)),
+	}
+	if o.ContentType != "" {
+		input.ResponseContentType = aws.String(o.ContentType)
+	}
+	if o.ContentDisposition != "" {
+		input.ResponseContentDisposition = aws.String(o.ContentDisposition)
+	}
+
+	req, err := s3.NewPresignClient(client.S3).PresignGetObject(ctx, input, func(po *s3.PresignOptions) {
+		po.Expires = o.Expires
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PresignedRequest{
+		Method:    req.Method,
+		URL:       req.URL,
+		Header:    signedHeader(req.SignedHeader),
+		ExpiresAt: time.Now().Add(o.Expires),
+	}, nil
+}
+
+// signedHeader drops the Host header, which is set by the http client from the URL
+func signedHeader(header http.Header) http.Header {
+	header = header.Clone()
+	header.Del("Host")
+	return header
+}
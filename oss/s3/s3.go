@@ -2,20 +2,22 @@ package s3
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"mime"
 	"net/http"
 	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
@@ -26,6 +28,15 @@ import (
 	"github.com/samber/lo"
 )
 
+var (
+	_ oss.PageLister   = (*Client)(nil)
+	_ oss.Copier       = (*Client)(nil)
+	_ oss.BatchDeleter = (*Client)(nil)
+)
+
+// maxDeleteObjects is the maximum number of keys of a DeleteObjects request
+const maxDeleteObjects = 1000
+
 // Client S3 storage
 type Client struct {
 	S3     *s3.Client
@@ -45,6 +56,21 @@ type Config struct {
 	S3ForcePathStyle bool
 	CacheControl     string
 
+	// ServerSideEncryption is the default encryption of stored objects, AES256 or aws:kms
+	ServerSideEncryption types.ServerSideEncryption
+	// SSEKMSKeyID is the KMS key of aws:kms encryption, the bucket's default key is used if empty
+	SSEKMSKeyID string
+	// StorageClass is the default storage class of stored objects
+	StorageClass types.StorageClass
+	// Tags are added to every stored object
+	Tags map[string]string
+	// ObjectLockMode and ObjectLockRetention retain every stored object for the duration, the bucket must have object lock enabled
+	ObjectLockMode      types.ObjectLockMode
+	ObjectLockRetention time.Duration
+
+	// Multipart makes Put upload large content in parallel parts
+	Multipart *oss.MultipartConfig
+
 	AWSConfig *aws.Config
 
 	RoleARN string
@@ -121,31 +147,46 @@ func (client Client) Get(ctx context.Context, path string) (file *os.File, err e
 
 // GetStream get file as stream
 func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	return client.getStream(ctx, path, "")
+}
+
+func (client Client) getStream(ctx context.Context, path string, versionID string) (io.ReadCloser, error) {
 	getResponse, err := client.S3.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(client.Config.Bucket),
-		Key:    aws.String(client.ToS3Key(path)),
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: optional(versionID),
 	})
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 
 	return getResponse.Body, err
 }
 
 // Put store a reader into given path
-func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader) (*oss.Object, error) {
+func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
 	if seeker, ok := reader.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
 
+	reader, multipart, err := oss.ExceedsMultipartThreshold(reader, client.Config.Multipart)
+	if err != nil {
+		return nil, err
+	}
+	if multipart {
+		return oss.PutMultipart(ctx, client, urlPath, reader, client.Config.Multipart, opts...)
+	}
+
 	urlPath = client.ToS3Key(urlPath)
 	buffer, err := io.ReadAll(reader)
-
-	fileType := mime.TypeByExtension(path.Ext(urlPath))
-	if fileType == "" {
-		fileType = http.DetectContentType(buffer)
+	if err != nil {
+		return nil, err
 	}
 
+	o := oss.NewPutOptions(opts...)
+	fileType := oss.DetectContentType(o.ContentType, urlPath, buffer)
+	cacheControl := cmp.Or(o.CacheControl, client.Config.CacheControl)
+
 	params := &s3.PutObjectInput{
 		Bucket:        aws.String(client.Config.Bucket), // required
 		Key:           aws.String(urlPath),              // required
@@ -153,20 +194,80 @@ func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader)
 		Body:          bytes.NewReader(buffer),
 		ContentLength: aws.Int64(int64(len(buffer))),
 		ContentType:   aws.String(fileType),
+		Metadata:      o.Metadata,
+	}
+	if cacheControl != "" {
+		params.CacheControl = aws.String(cacheControl)
 	}
-	if client.Config.CacheControl != "" {
-		params.CacheControl = aws.String(client.Config.CacheControl)
+	if o.ContentDisposition != "" {
+		params.ContentDisposition = aws.String(o.ContentDisposition)
 	}
+	client.options(o).applyPut(params)
 
-	_, err = client.S3.PutObject(ctx, params)
+	output, err := client.S3.PutObject(ctx, params)
 
 	now := time.Now()
+	object := &oss.Object{
+		Path:               "/" + urlPath,
+		Name:               filepath.Base(urlPath),
+		LastModified:       &now,
+		Size:               int64(len(buffer)),
+		ContentType:        fileType,
+		CacheControl:       cacheControl,
+		ContentDisposition: o.ContentDisposition,
+		Metadata:           o.Metadata,
+		StorageInterface:   client,
+	}
+	if output != nil {
+		object.ETag = strings.Trim(aws.ToString(output.ETag), `"`)
+		object.VersionID = aws.ToString(output.VersionId)
+	}
+	return object, err
+}
+
+// Stat get object's metadata with a HEAD request
+func (client Client) Stat(ctx context.Context, urlPath string) (*oss.Object, error) {
+	return client.stat(ctx, urlPath, "")
+}
+
+func (client Client) stat(ctx context.Context, urlPath string, versionID string) (*oss.Object, error) {
+	key := client.ToS3Key(urlPath)
+	output, err := client.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(key),
+		VersionId: optional(versionID),
+	})
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
 	return &oss.Object{
-		Path:             urlPath,
-		Name:             filepath.Base(urlPath),
-		LastModified:     &now,
-		StorageInterface: client,
-	}, err
+		Path:               "/" + key,
+		Name:               filepath.Base(key),
+		LastModified:       output.LastModified,
+		Size:               aws.ToInt64(output.ContentLength),
+		ContentType:        aws.ToString(output.ContentType),
+		CacheControl:       aws.ToString(output.CacheControl),
+		ContentDisposition: aws.ToString(output.ContentDisposition),
+		ETag:               strings.Trim(aws.ToString(output.ETag), `"`),
+		Metadata:           output.Metadata,
+		VersionID:          aws.ToString(output.VersionId),
+		StorageInterface:   client,
+	}, nil
+}
+
+// wrapNotFound wrap the errors of missing objects with oss.ErrNotFound
+func wrapNotFound(err error) error {
+	var (
+		notFound  *types.NotFound
+		noSuchKey *types.NoSuchKey
+		respErr   *awshttp.ResponseError
+	)
+	if errors.As(err, &notFound) || errors.As(err, &noSuchKey) ||
+		(errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound) {
+		return fmt.Errorf("%w: %w", oss.ErrNotFound, err)
+	}
+	return err
 }
 
 // Delete delete file
@@ -178,54 +279,80 @@ func (client Client) Delete(ctx context.Context, path string) error {
 	return err
 }
 
-// DeleteObjects delete files in bulk
+// DeleteObjects delete files in bulk, in batches of 1000, missing files are ignored
 func (client Client) DeleteObjects(ctx context.Context, paths []string) (err error) {
-	var objs []types.ObjectIdentifier
-	for _, v := range paths {
-		var obj types.ObjectIdentifier
-		obj.Key = aws.String(strings.TrimPrefix(client.ToS3Key(v), "/"))
-		objs = append(objs, obj)
-	}
-	input := &s3.DeleteObjectsInput{
-		Bucket: aws.String(client.Config.Bucket),
-		Delete: &types.Delete{
-			Objects: objs,
-		},
-	}
+	for batch := range slices.Chunk(paths, maxDeleteObjects) {
+		var objs []types.ObjectIdentifier
+		for _, v := range batch {
+			var obj types.ObjectIdentifier
+			obj.Key = aws.String(strings.TrimPrefix(client.ToS3Key(v), "/"))
+			objs = append(objs, obj)
+		}
+		input := &s3.DeleteObjectsInput{
+			Bucket: aws.String(client.Config.Bucket),
+			Delete: &types.Delete{
+				Objects: objs,
+				Quiet:   aws.Bool(true),
+			},
+		}
 
-	_, err = client.S3.DeleteObjects(ctx, input)
-	if err != nil {
-		return
+		output, err := client.S3.DeleteObjects(ctx, input)
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			var failures []string
+			for _, e := range output.Errors {
+				failures = append(failures, fmt.Sprintf("%s: %s %s", aws.ToString(e.Key), aws.ToString(e.Code), aws.ToString(e.Message)))
+			}
+			return fmt.Errorf("delete objects: %s", strings.Join(failures, "; "))
+		}
 	}
-	return
+	return nil
 }
 
 // List list all objects under current path
 func (client Client) List(ctx context.Context, path string) ([]*oss.Object, error) {
-	var objects []*oss.Object
-	var prefix string
+	return oss.ListAll(ctx, client, &oss.ListOptions{Prefix: oss.DirPrefix(path)})
+}
 
-	if path != "" {
-		prefix = strings.Trim(path, "/") + "/"
+// ListPage list a page of objects with ListObjectsV2
+func (client Client) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(client.Config.Bucket),
+		Prefix:  aws.String(strings.TrimPrefix(opts.Prefix, "/")),
+		MaxKeys: aws.Int32(int32(opts.GetMaxKeys())),
+	}
+	if opts.Delimiter != "" {
+		input.Delimiter = aws.String(opts.Delimiter)
+	}
+	if opts.ContinuationToken != "" {
+		input.ContinuationToken = aws.String(opts.ContinuationToken)
 	}
 
-	listObjectsResponse, err := client.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket: aws.String(client.Config.Bucket),
-		Prefix: aws.String(prefix),
-	})
-
-	if err == nil {
-		for _, content := range listObjectsResponse.Contents {
-			objects = append(objects, &oss.Object{
-				Path:             "/" + client.ToS3Key(*content.Key),
-				Name:             filepath.Base(*content.Key),
-				LastModified:     content.LastModified,
-				StorageInterface: client,
-			})
-		}
+	output, err := client.S3.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return objects, err
+	result := &oss.ListResult{
+		IsTruncated:           aws.ToBool(output.IsTruncated),
+		NextContinuationToken: aws.ToString(output.NextContinuationToken),
+	}
+	for _, content := range output.Contents {
+		result.Objects = append(result.Objects, &oss.Object{
+			Path:             "/" + client.ToS3Key(*content.Key),
+			Name:             filepath.Base(*content.Key),
+			LastModified:     content.LastModified,
+			Size:             aws.ToInt64(content.Size),
+			ETag:             strings.Trim(aws.ToString(content.ETag), `"`),
+			StorageInterface: client,
+		})
+	}
+	for _, commonPrefix := range output.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, "/"+client.ToS3Key(aws.ToString(commonPrefix.Prefix)))
+	}
+	return result, nil
 }
 
 // GetEndpoint get endpoint, FileSystem's endpoint is /
@@ -282,14 +409,18 @@ func (client Client) GetURL(ctx context.Context, path string) (url string, err e
 	return path, nil
 }
 
-// Copy copy s3 file from "from" to "to"
+// Copy copy s3 file from "from" to "to", both are paths in the bucket, the copy is encrypted and stored by the config
 func (client Client) Copy(ctx context.Context, from, to string) (err error) {
-	_, err = client.S3.CopyObject(ctx, &s3.CopyObjectInput{
+	source := &url.URL{Path: client.Config.Bucket + "/" + client.ToS3Key(from)}
+	params := &s3.CopyObjectInput{
 		Bucket:     aws.String(client.Config.Bucket),
-		CopySource: aws.String(from),
-		Key:        aws.String(to),
-	})
-	return
+		CopySource: aws.String(source.EscapedPath()),
+		Key:        aws.String(client.ToS3Key(to)),
+		ACL:        types.ObjectCannedACL(client.Config.ACL),
+	}
+	client.options(nil).applyCopy(params)
+	_, err = client.S3.CopyObject(ctx, params)
+	return wrapNotFound(err)
 }
 
 func (client Client) getS3Endpoint(ctx context.Context) string {
@@ -1,13 +1,20 @@
 package s3_test
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/jinzhu/configor"
 	"github.com/qor5/x/v3/oss/s3"
 	"github.com/qor5/x/v3/oss/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type Config struct {
@@ -81,3 +88,22 @@ func TestToS3KeyWithS3ForcePathStyle(t *testing.T) {
 		}
 	}
 }
+
+func TestPutPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Content-Length", "5")
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	client := s3.New(&s3.Config{AccessID: "id", AccessKey: "key", Region: "us-east-1", Bucket: "bucket", S3Endpoint: server.URL, S3ForcePathStyle: true})
+	ctx := context.Background()
+	// the paths start with the bucket in the path style
+	object, err := client.Put(ctx, "/bucket/dir/a.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+	stat, err := client.Stat(ctx, "/bucket/dir/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/dir/a.txt", object.Path)
+	assert.Equal(t, stat.Path, object.Path)
+}
@@ -0,0 +1,42 @@
+package s3
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// GetTags get the tags of the object, versionID is optional
+func (client Client) GetTags(ctx context.Context, path string, versionID string) (map[string]string, error) {
+	output, err := client.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: optional(versionID),
+	})
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	tags := map[string]string{}
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutTags replace the tags of the object, versionID is optional
+func (client Client) PutTags(ctx context.Context, path string, versionID string, tags map[string]string) error {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	_, err := client.S3.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: optional(versionID),
+		Tagging:   &types.Tagging{TagSet: tagSet},
+	})
+	return wrapNotFound(err)
+}
@@ -0,0 +1,97 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/qor5/x/v3/oss"
+)
+
+// ObjectVersion is a version of an object in a versioned bucket
+type ObjectVersion struct {
+	*oss.Object
+	IsLatest bool
+	// IsDeleteMarker reports the version marks the object as deleted, it has no content
+	IsDeleteMarker bool
+}
+
+// SetVersioning enable or suspend the versioning of the bucket
+func (client Client) SetVersioning(ctx context.Context, enabled bool) error {
+	status := types.BucketVersioningStatusSuspended
+	if enabled {
+		status = types.BucketVersioningStatusEnabled
+	}
+	_, err := client.S3.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(client.Config.Bucket),
+		VersioningConfiguration: &types.VersioningConfiguration{Status: status},
+	})
+	return err
+}
+
+// ListVersions list all versions and delete markers of the objects under prefix, IsLatest marks the current version of each object
+func (client Client) ListVersions(ctx context.Context, prefix string) ([]*ObjectVersion, error) {
+	var versions []*ObjectVersion
+
+	paginator := s3.NewListObjectVersionsPaginator(client.S3, &s3.ListObjectVersionsInput{
+		Bucket: aws.String(client.Config.Bucket),
+		Prefix: aws.String(strings.TrimPrefix(prefix, "/")),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range output.Versions {
+			versions = append(versions, &ObjectVersion{
+				Object:   client.versionObject(version.Key, version.VersionId, version.LastModified, aws.ToInt64(version.Size), version.ETag),
+				IsLatest: aws.ToBool(version.IsLatest),
+			})
+		}
+		for _, marker := range output.DeleteMarkers {
+			versions = append(versions, &ObjectVersion{
+				Object:         client.versionObject(marker.Key, marker.VersionId, marker.LastModified, 0, nil),
+				IsLatest:       aws.ToBool(marker.IsLatest),
+				IsDeleteMarker: true,
+			})
+		}
+	}
+	return versions, nil
+}
+
+func (client Client) versionObject(key *string, versionID *string, lastModified *time.Time, size int64, etag *string) *oss.Object {
+	return &oss.Object{
+		Path:             "/" + client.ToS3Key(aws.ToString(key)),
+		Name:             filepath.Base(aws.ToString(key)),
+		LastModified:     lastModified,
+		Size:             size,
+		ETag:             strings.Trim(aws.ToString(etag), `"`),
+		VersionID:        aws.ToString(versionID),
+		StorageInterface: client,
+	}
+}
+
+// GetVersionStream get a version of the object as stream
+func (client Client) GetVersionStream(ctx context.Context, path string, versionID string) (io.ReadCloser, error) {
+	return client.getStream(ctx, path, versionID)
+}
+
+// StatVersion get the metadata of a version of the object
+func (client Client) StatVersion(ctx context.Context, path string, versionID string) (*oss.Object, error) {
+	return client.stat(ctx, path, versionID)
+}
+
+// DeleteVersion permanently delete a version of the object
+func (client Client) DeleteVersion(ctx context.Context, path string, versionID string) error {
+	_, err := client.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(client.Config.Bucket),
+		Key:       aws.String(client.ToS3Key(path)),
+		VersionId: aws.String(versionID),
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	stdsync "sync"
+)
+
+// checkpoint records the finished source paths, one per line, so an interrupted run can be resumed
+type checkpoint struct {
+	mu   stdsync.Mutex
+	file *os.File
+	done map[string]bool
+}
+
+// openCheckpoint loads the paths finished by previous runs, a dry run reads the file without writing to it
+func openCheckpoint(path string, readOnly bool) (*checkpoint, error) {
+	c := &checkpoint{done: map[string]bool{}}
+	if path == "" {
+		return c, nil
+	}
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				c.done[line] = true
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if readOnly {
+		return c, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c.file = f
+	return c, nil
+}
+
+func (c *checkpoint) IsDone(path string) bool {
+	return c.done[path]
+}
+
+func (c *checkpoint) Done(path string) error {
+	if c.file == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.file.WriteString(path + "\n")
+	return err
+}
+
+func (c *checkpoint) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
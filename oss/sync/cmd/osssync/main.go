@@ -0,0 +1,85 @@
+// Command osssync copies or mirrors a prefix between storages:
+//
+//	osssync -from aliyun://old-bucket -to s3://new-bucket?region=us-east-1 -prefix /uploads -compare size -checkpoint uploads.ckpt
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	stdsync "sync"
+
+	"github.com/qor5/x/v3/oss/sync"
+)
+
+func main() {
+	var (
+		from        = flag.String("from", "", "source storage URL")
+		to          = flag.String("to", "", "destination storage URL")
+		prefix      = flag.String("prefix", "", "source directory")
+		destPrefix  = flag.String("dest-prefix", "", "destination directory, defaults to -prefix")
+		mirror      = flag.Bool("mirror", false, "delete destination objects missing from the source")
+		concurrency = flag.Int("concurrency", sync.DefaultConcurrency, "objects copied in parallel")
+		compare     = flag.String("compare", string(sync.CompareSizeAndETag), "skip up to date objects by etag, size or none")
+		dryRun      = flag.Bool("dry-run", false, "report what would be done without writing anything")
+		checkpoint  = flag.String("checkpoint", "", "file recording finished objects, reuse it to resume an interrupted run")
+		verbose     = flag.Bool("v", false, "print every object")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -from URL -to URL [flags]\n\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s", storageUsage)
+	}
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	src, err := openStorage(*from)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	dst, err := openStorage(*to)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mu stdsync.Mutex
+	report, err := sync.Run(ctx, src, dst, &sync.Options{
+		Prefix:      *prefix,
+		DestPrefix:  *destPrefix,
+		Mirror:      *mirror,
+		Concurrency: *concurrency,
+		Compare:     sync.Compare(*compare),
+		DryRun:      *dryRun,
+		Checkpoint:  *checkpoint,
+		OnResult: func(result *sync.Result) {
+			if !*verbose && result.Action != sync.ActionFailed {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Err != nil {
+				fmt.Printf("%-8s %s -> %s: %v\n", result.Action, result.Path, result.Target, result.Err)
+				return
+			}
+			fmt.Printf("%-8s %s -> %s\n", result.Action, result.Path, result.Target)
+		},
+	})
+	if report != nil {
+		fmt.Println(report)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
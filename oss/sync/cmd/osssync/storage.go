@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	aliyunsdk "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/aliyun"
+	"github.com/qor5/x/v3/oss/filesystem"
+	"github.com/qor5/x/v3/oss/qiniu"
+	"github.com/qor5/x/v3/oss/s3"
+	"github.com/qor5/x/v3/oss/tencent"
+)
+
+const storageUsage = `Storages are given as URLs, credentials are read from the environment:
+
+  file:///var/assets
+  s3://bucket?region=us-east-1&endpoint=&s3_endpoint=&path_style=false&acl=private
+      AWS default credential chain
+  aliyun://bucket?endpoint=oss-cn-hangzhou.aliyuncs.com&acl=private
+      ALIYUN_ACCESS_KEY_ID, ALIYUN_ACCESS_KEY_SECRET
+  qiniu://bucket?region=huadong&endpoint=https://cdn.example.com&https=true
+      QINIU_ACCESS_KEY, QINIU_SECRET_KEY
+  tencent://bucket-appid?region=ap-shanghai&acl=private
+      TENCENT_SECRET_ID, TENCENT_SECRET_KEY
+`
+
+// openStorage builds a storage from its URL
+func openStorage(rawURL string) (oss.StorageInterface, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+
+	switch u.Scheme {
+	case "file":
+		return filesystem.New(u.Host + u.Path), nil
+	case "s3":
+		pathStyle, _ := strconv.ParseBool(q.Get("path_style"))
+		return s3.New(&s3.Config{
+			Bucket:           u.Host,
+			Region:           q.Get("region"),
+			Endpoint:         q.Get("endpoint"),
+			S3Endpoint:       q.Get("s3_endpoint"),
+			S3ForcePathStyle: pathStyle,
+			ACL:              q.Get("acl"),
+		}), nil
+	case "aliyun":
+		return aliyun.New(&aliyun.Config{
+			AccessID:  os.Getenv("ALIYUN_ACCESS_KEY_ID"),
+			AccessKey: os.Getenv("ALIYUN_ACCESS_KEY_SECRET"),
+			Bucket:    u.Host,
+			Endpoint:  q.Get("endpoint"),
+			ACL:       aliyunsdk.ACLType(q.Get("acl")),
+		}), nil
+	case "qiniu":
+		useHTTPS, _ := strconv.ParseBool(q.Get("https"))
+		return qiniu.New(&qiniu.Config{
+			AccessID:  os.Getenv("QINIU_ACCESS_KEY"),
+			AccessKey: os.Getenv("QINIU_SECRET_KEY"),
+			Bucket:    u.Host,
+			Region:    q.Get("region"),
+			Endpoint:  q.Get("endpoint"),
+			UseHTTPS:  useHTTPS,
+		}), nil
+	case "tencent":
+		return tencent.New(&tencent.Config{
+			AccessID:  os.Getenv("TENCENT_SECRET_ID"),
+			AccessKey: os.Getenv("TENCENT_SECRET_KEY"),
+			Bucket:    u.Host,
+			Region:    q.Get("region"),
+			ACL:       q.Get("acl"),
+		}), nil
+	}
+	return nil, fmt.Errorf("unsupported storage %q", rawURL)
+}
@@ -0,0 +1,256 @@
+// Package sync copies or mirrors a prefix from one oss.StorageInterface to another,
+// for example to migrate assets between cloud providers.
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	stdsync "sync"
+	"sync/atomic"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// DefaultConcurrency is used when Options.Concurrency is not set
+const DefaultConcurrency = 8
+
+// Compare decides when an existing destination object is up to date
+type Compare string
+
+const (
+	// CompareSizeAndETag skips objects with the same size and ETag, use it between storages computing ETags the same way
+	CompareSizeAndETag Compare = "etag"
+	// CompareSize skips objects with the same size, use it between providers with different ETag algorithms
+	CompareSize Compare = "size"
+	// CompareNone copies every object
+	CompareNone Compare = "none"
+)
+
+// Options options of Run
+type Options struct {
+	// Prefix is the source directory, the leading and trailing "/" are optional, /a doesn't select /ab/c
+	Prefix string
+	// DestPrefix is the destination directory replacing Prefix in the destination paths, defaults to Prefix
+	DestPrefix string
+	// Mirror deletes the destination objects under DestPrefix that are missing from the source
+	Mirror bool
+	// Concurrency is the number of objects copied in parallel, defaults to DefaultConcurrency
+	Concurrency int
+	// Compare defaults to CompareSizeAndETag
+	Compare Compare
+	// DryRun reports what would be done without writing anything
+	DryRun bool
+	// Checkpoint is a file recording the finished source paths, a later run with the same file skips them
+	Checkpoint string
+	// OnResult is called for every object, it may be called concurrently
+	OnResult func(result *Result)
+}
+
+// Action what happened to an object
+type Action string
+
+const (
+	ActionCopied  Action = "copied"
+	ActionSkipped Action = "skipped"
+	ActionDeleted Action = "deleted"
+	ActionFailed  Action = "failed"
+)
+
+// Result the outcome for an object
+type Result struct {
+	Action Action
+	// Path is the source path, empty for deleted objects
+	Path string
+	// Target is the destination path
+	Target string
+	Size   int64
+	Err    error
+}
+
+// Report summary of a run
+type Report struct {
+	Copied  int64
+	Skipped int64
+	Deleted int64
+	Failed  int64
+	// Bytes is the size of the copied objects
+	Bytes int64
+	// Failures are the results of the failed objects
+	Failures []*Result
+}
+
+func (report *Report) String() string {
+	return fmt.Sprintf("copied %d (%d bytes), skipped %d, deleted %d, failed %d", report.Copied, report.Bytes, report.Skipped, report.Deleted, report.Failed)
+}
+
+func normalize(path string) string {
+	return "/" + strings.TrimPrefix(path, "/")
+}
+
+// dirPrefix normalizes a prefix to a directory boundary, so that mirroring /a never touches /ab
+func dirPrefix(prefix string) string {
+	return "/" + oss.DirPrefix(prefix)
+}
+
+// Run copy the objects under opts.Prefix from src to dst. Failed objects are reported and do not stop the run,
+// the returned error is only set when listing fails or ctx is done.
+func Run(ctx context.Context, src, dst oss.StorageInterface, opts *Options) (*Report, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	switch o.Compare {
+	case "":
+		o.Compare = CompareSizeAndETag
+	case CompareSizeAndETag, CompareSize, CompareNone:
+	default:
+		return nil, fmt.Errorf("unknown compare %q", o.Compare)
+	}
+	prefix := dirPrefix(o.Prefix)
+	destPrefix := prefix
+	if o.DestPrefix != "" {
+		destPrefix = dirPrefix(o.DestPrefix)
+	}
+
+	checkpoint, err := openCheckpoint(o.Checkpoint, o.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer checkpoint.Close()
+
+	existing := map[string]*oss.Object{}
+	for object, err := range oss.ListSeq(ctx, dst, &oss.ListOptions{Prefix: destPrefix}) {
+		if err != nil {
+			return nil, fmt.Errorf("list destination: %w", err)
+		}
+		existing[normalize(object.Path)] = object
+	}
+
+	var (
+		report = &Report{}
+		mu     stdsync.Mutex
+		seen   = map[string]bool{}
+		wg     stdsync.WaitGroup
+		sem    = make(chan struct{}, o.Concurrency)
+		runErr error
+	)
+	record := func(result *Result) {
+		switch result.Action {
+		case ActionCopied:
+			atomic.AddInt64(&report.Copied, 1)
+			atomic.AddInt64(&report.Bytes, result.Size)
+		case ActionSkipped:
+			atomic.AddInt64(&report.Skipped, 1)
+		case ActionDeleted:
+			atomic.AddInt64(&report.Deleted, 1)
+		case ActionFailed:
+			atomic.AddInt64(&report.Failed, 1)
+			mu.Lock()
+			report.Failures = append(report.Failures, result)
+			mu.Unlock()
+		}
+		if result.Path != "" && result.Action != ActionFailed && !checkpoint.IsDone(result.Path) {
+			if err := checkpoint.Done(result.Path); err != nil {
+				mu.Lock()
+				runErr = errors.Join(runErr, err)
+				mu.Unlock()
+			}
+		}
+		if o.OnResult != nil {
+			o.OnResult(result)
+		}
+	}
+
+	for object, err := range oss.ListSeq(ctx, src, &oss.ListOptions{Prefix: prefix}) {
+		if err != nil {
+			mu.Lock()
+			runErr = errors.Join(runErr, fmt.Errorf("list source: %w", err))
+			mu.Unlock()
+			break
+		}
+
+		path := normalize(object.Path)
+		target := destPrefix + strings.TrimPrefix(path, prefix)
+		seen[target] = true
+
+		if checkpoint.IsDone(path) {
+			record(&Result{Action: ActionSkipped, Path: path, Target: target, Size: object.Size})
+			continue
+		}
+		if o.Compare.same(object, existing[target]) {
+			record(&Result{Action: ActionSkipped, Path: path, Target: target, Size: object.Size})
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(object *oss.Object, path, target string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &Result{Action: ActionCopied, Path: path, Target: target, Size: object.Size}
+			if !o.DryRun {
+				copied, err := oss.StreamCopy(ctx, src, path, dst, target)
+				if err != nil {
+					result.Action, result.Err = ActionFailed, err
+				} else if copied != nil && copied.Size > 0 {
+					result.Size = copied.Size
+				}
+			}
+			record(result)
+		}(object, path, target)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, errors.Join(runErr, err)
+	}
+	if runErr != nil {
+		return report, runErr
+	}
+
+	if o.Mirror {
+		var extra []string
+		for target := range existing {
+			if !seen[target] {
+				extra = append(extra, target)
+			}
+		}
+		if len(extra) > 0 && !o.DryRun {
+			if err := oss.DeleteObjects(ctx, dst, extra); err != nil {
+				return report, fmt.Errorf("delete extra objects: %w", err)
+			}
+		}
+		for _, target := range extra {
+			record(&Result{Action: ActionDeleted, Target: target, Size: existing[target].Size})
+		}
+	}
+	return report, nil
+}
+
+// same reports whether dst is an up to date copy of src
+func (compare Compare) same(src, dst *oss.Object) bool {
+	if dst == nil || compare == CompareNone || src.Size != dst.Size {
+		return false
+	}
+	if compare == CompareSize {
+		return true
+	}
+	return src.ETag != "" && normalizeETag(src.ETag) == normalizeETag(dst.ETag)
+}
+
+func normalizeETag(etag string) string {
+	return strings.ToLower(strings.Trim(etag, `"`))
+}
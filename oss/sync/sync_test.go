@@ -0,0 +1,107 @@
+package sync_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/faulty"
+	"github.com/qor5/x/v3/oss/memory"
+	"github.com/qor5/x/v3/oss/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func put(t *testing.T, storage oss.StorageInterface, paths ...string) {
+	for _, path := range paths {
+		_, err := storage.Put(context.Background(), path, strings.NewReader(path))
+		require.NoError(t, err)
+	}
+}
+
+func paths(t *testing.T, storage oss.StorageInterface) []string {
+	objects, err := storage.List(context.Background(), "")
+	require.NoError(t, err)
+	var paths []string
+	for _, object := range objects {
+		paths = append(paths, object.Path)
+	}
+	return paths
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	src, dst := memory.New(), memory.New()
+	put(t, src, "/assets/a.txt", "/assets/b/c.txt", "/other/d.txt")
+	put(t, dst, "/backup/old.txt")
+	_, err := dst.Put(ctx, "/backup/a.txt", strings.NewReader("/assets/a.txt"))
+	require.NoError(t, err)
+
+	opts := &sync.Options{Prefix: "assets/", DestPrefix: "/backup/", Mirror: true, DryRun: true}
+	report, err := sync.Run(ctx, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "copied 1 (15 bytes), skipped 1, deleted 1, failed 0", report.String())
+	assert.Equal(t, []string{"/backup/a.txt", "/backup/old.txt"}, paths(t, dst))
+
+	opts.DryRun = false
+	report, err = sync.Run(ctx, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Copied)
+	assert.Equal(t, int64(1), report.Skipped)
+	assert.Equal(t, int64(1), report.Deleted)
+	assert.Equal(t, []string{"/backup/a.txt", "/backup/b/c.txt"}, paths(t, dst))
+
+	report, err = sync.Run(ctx, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Skipped)
+	assert.Zero(t, report.Copied)
+
+	_, err = sync.Run(ctx, src, dst, &sync.Options{Compare: "md5"})
+	assert.Error(t, err)
+}
+
+func TestMirrorPrefix(t *testing.T) {
+	ctx := context.Background()
+	src, dst := memory.New(), memory.New()
+	put(t, src, "/a/1.txt", "/ab/2.txt")
+	put(t, dst, "/a/old.txt", "/ab/keep.txt", "/b/3.txt")
+
+	report, err := sync.Run(ctx, src, dst, &sync.Options{Prefix: "/a", Mirror: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Copied)
+	assert.Equal(t, int64(1), report.Deleted)
+	assert.Equal(t, []string{"/a/1.txt", "/ab/keep.txt", "/b/3.txt"}, paths(t, dst))
+
+	// the destination prefix is a directory too
+	report, err = sync.Run(ctx, src, dst, &sync.Options{Prefix: "a", DestPrefix: "/b", Mirror: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Copied)
+	assert.Equal(t, int64(1), report.Deleted)
+	assert.Equal(t, []string{"/a/1.txt", "/ab/keep.txt", "/b/1.txt"}, paths(t, dst))
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	checkpoint := filepath.Join(t.TempDir(), "sync.ckpt")
+	src := memory.New()
+	put(t, src, "/a.txt", "/b.txt", "/c.txt")
+
+	dst := faulty.New(memory.New()).Inject(faulty.Fault{Fail: true, Match: func(path string) bool { return path == "/b.txt" }}, faulty.OpPut)
+	report, err := sync.Run(ctx, src, dst, &sync.Options{Checkpoint: checkpoint, Concurrency: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Copied)
+	assert.Equal(t, int64(1), report.Failed)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, "/b.txt", report.Failures[0].Path)
+	assert.ErrorIs(t, report.Failures[0].Err, faulty.ErrInjected)
+
+	// resume with a destination that lost everything, only the failed object is copied again
+	fresh := memory.New()
+	report, err = sync.Run(ctx, src, fresh, &sync.Options{Checkpoint: checkpoint, Compare: sync.CompareNone})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Copied)
+	assert.Equal(t, int64(2), report.Skipped)
+	assert.Equal(t, []string{"/b.txt"}, paths(t, fresh))
+}
@@ -0,0 +1,97 @@
+package tencent
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+var (
+	_ oss.Copier       = (*Client)(nil)
+	_ oss.BatchDeleter = (*Client)(nil)
+)
+
+// maxDeleteObjects is the maximum number of keys of a multi-object delete request
+const maxDeleteObjects = 1000
+
+// Copy copy an object inside the bucket, a missing object returns an error wrapping oss.ErrNotFound
+func (client Client) Copy(ctx context.Context, from, to string) error {
+	source := &url.URL{Path: "/" + client.ToRelativePath(from)}
+	header := http.Header{}
+	header.Set("X-Cos-Copy-Source", client.GetEndpoint(ctx)+source.EscapedPath())
+	if client.Config.ACL != "" {
+		header.Set("X-Cos-Acl", client.Config.ACL)
+	}
+
+	resp, err := client.do(ctx, http.MethodPut, to, nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type deleteRequest struct {
+	XMLName xml.Name       `xml:"Delete"`
+	Quiet   bool           `xml:"Quiet"`
+	Objects []deleteObject `xml:"Object"`
+}
+
+type deleteObject struct {
+	Key string `xml:"Key"`
+}
+
+type deleteResult struct {
+	Errors []struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+// DeleteObjects delete objects in batches of 1000, missing objects are ignored
+func (client Client) DeleteObjects(ctx context.Context, paths []string) error {
+	for batch := range slices.Chunk(paths, maxDeleteObjects) {
+		request := deleteRequest{Quiet: true}
+		for _, path := range batch {
+			request.Objects = append(request.Objects, deleteObject{Key: client.ToRelativePath(path)})
+		}
+		body, err := xml.Marshal(request)
+		if err != nil {
+			return err
+		}
+
+		sum := md5.Sum(body)
+		header := http.Header{}
+		header.Set("Content-Type", "application/xml")
+		header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+		resp, err := client.do(ctx, http.MethodPost, "", url.Values{"delete": {""}}, header, bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return err
+		}
+		var result deleteResult
+		if err := decodeXML(resp, &result); err != nil {
+			return err
+		}
+
+		var failures []string
+		for _, e := range result.Errors {
+			if e.Code != "NoSuchKey" {
+				failures = append(failures, fmt.Sprintf("%s: %s %s", e.Key, e.Code, e.Message))
+			}
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("delete objects: %s", strings.Join(failures, "; "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package tencent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClient_Copy(t *testing.T) {
+	var source string
+	client := New(&Config{Bucket: "bucket-1250000000", Region: "ap-shanghai", Endpoint: "files.example.com"})
+	client.Client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		source = req.Header.Get("X-Cos-Copy-Source")
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(strings.NewReader("<Error><Code>NoSuchKey</Code></Error>")),
+		}, nil
+	})
+
+	err := client.Copy(context.Background(), "/a b.txt", "/b.txt")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	assert.Equal(t, "files.example.com/a%20b.txt", source)
+}
@@ -0,0 +1,219 @@
+package tencent
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+var _ oss.MultipartUploader = (*Client)(nil)
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type listPartsResult struct {
+	XMLName              xml.Name `xml:"ListPartsResult"`
+	IsTruncated          bool     `xml:"IsTruncated"`
+	NextPartNumberMarker string   `xml:"NextPartNumberMarker"`
+	Parts                []struct {
+		PartNumber   int       `xml:"PartNumber"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// do send a signed request to the object at path, and return an error for non 2xx responses
+func (client Client) do(ctx context.Context, method string, path string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := fmt.Sprintf("%s%s", client.getUrl(), client.ToRelativePath(path))
+	if len(query) > 0 {
+		// COS sub resources such as ?uploads have no value
+		u += "?" + encodeQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && body != nil {
+		req.ContentLength = size
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("Authorization", client.authorization(req))
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		d, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", oss.ErrNotFound, d)
+		}
+		return nil, errors.New(string(d))
+	}
+	return resp, nil
+}
+
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range query[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(url.QueryEscape(k))
+			if v != "" {
+				buf.WriteByte('=')
+				buf.WriteString(url.QueryEscape(v))
+			}
+		}
+	}
+	return buf.String()
+}
+
+func decodeXML(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// InitiateMultipartUpload start a multipart upload
+func (client Client) InitiateMultipartUpload(ctx context.Context, path string, opts ...oss.PutOption) (string, error) {
+	o := oss.NewPutOptions(opts...)
+	header := http.Header{}
+	setPutHeaders(header, oss.DetectContentType(o.ContentType, path, nil), o)
+
+	resp, err := client.do(ctx, http.MethodPost, path, url.Values{"uploads": {""}}, header, nil, -1)
+	if err != nil {
+		return "", err
+	}
+
+	var result initiateMultipartUploadResult
+	if err := decodeXML(resp, &result); err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart upload a part of a multipart upload
+func (client Client) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, reader io.Reader, size int64) (*oss.Part, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	resp, err := client.do(ctx, http.MethodPut, path, query, nil, reader, size)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return &oss.Part{PartNumber: partNumber, ETag: resp.Header.Get("ETag"), Size: size}, nil
+}
+
+// ListParts list the uploaded parts of a multipart upload
+func (client Client) ListParts(ctx context.Context, path string, uploadID string) ([]*oss.Part, error) {
+	var (
+		parts  []*oss.Part
+		marker string
+	)
+
+	for {
+		query := url.Values{"uploadId": {uploadID}}
+		if marker != "" {
+			query.Set("part-number-marker", marker)
+		}
+		resp, err := client.do(ctx, http.MethodGet, path, query, nil, nil, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listPartsResult
+		if err := decodeXML(resp, &result); err != nil {
+			return nil, err
+		}
+		for _, part := range result.Parts {
+			lastModified := part.LastModified
+			parts = append(parts, &oss.Part{
+				PartNumber:   part.PartNumber,
+				ETag:         part.ETag,
+				Size:         part.Size,
+				LastModified: &lastModified,
+			})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// CompleteMultipartUpload assemble the uploaded parts into an object
+func (client Client) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []*oss.Part) (*oss.Object, error) {
+	complete := completeMultipartUpload{}
+	for _, part := range parts {
+		complete.Parts = append(complete.Parts, completePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.do(ctx, http.MethodPost, path, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	now := time.Now()
+	return &oss.Object{
+		Path:             path,
+		Name:             filepath.Base(path),
+		LastModified:     &now,
+		StorageInterface: client,
+	}, nil
+}
+
+// AbortMultipartUpload abort a multipart upload and remove its parts
+func (client Client) AbortMultipartUpload(ctx context.Context, path string, uploadID string) error {
+	resp, err := client.do(ctx, http.MethodDelete, path, url.Values{"uploadId": {uploadID}}, nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
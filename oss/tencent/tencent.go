@@ -3,6 +3,7 @@ package tencent
 import (
 	"bytes"
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -12,13 +13,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/qor5/x/v3/oss"
 )
 
-var _ oss.StorageInterface = (*Client)(nil)
+var (
+	_ oss.StorageInterface = (*Client)(nil)
+	_ oss.PageLister       = (*Client)(nil)
+)
 
 type Config struct {
 	AppID     string
@@ -29,6 +34,8 @@ type Config struct {
 	ACL       string
 	CORS      string
 	Endpoint  string
+	// Multipart makes Put upload large content in parallel parts
+	Multipart *oss.MultipartConfig
 }
 
 type Client struct {
@@ -74,38 +81,52 @@ func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser,
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: get file fail", oss.ErrNotFound)
+	}
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, errors.New("get file fail")
 	}
 	return resp.Body, nil
 }
 
-func (client Client) Put(ctx context.Context, path string, body io.Reader) (*oss.Object, error) {
+func (client Client) Put(ctx context.Context, path string, body io.Reader, opts ...oss.PutOption) (*oss.Object, error) {
 	if seeker, ok := body.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
-	switch body.(type) {
-	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
-	default:
-		if body != nil {
-			b, err := ioutil.ReadAll(body)
-			if err != nil {
-				return nil, err
-			}
-			body = bytes.NewReader(b)
+
+	body, multipart, err := oss.ExceedsMultipartThreshold(body, client.Config.Multipart)
+	if err != nil {
+		return nil, err
+	}
+	if multipart {
+		return oss.PutMultipart(ctx, client, path, body, client.Config.Multipart, opts...)
+	}
+
+	var data []byte
+	if body != nil {
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, err
 		}
 	}
 
-	req, err := http.NewRequest("PUT", fmt.Sprintf("%s%s", client.getUrl(), client.ToRelativePath(path)), body)
+	o := oss.NewPutOptions(opts...)
+	contentType := oss.DetectContentType(o.ContentType, path, data)
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s%s", client.getUrl(), client.ToRelativePath(path)), bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Host", client.GetEndpoint(ctx))
+	setPutHeaders(req.Header, contentType, o)
 	req.Header.Set("Authorization", client.authorization(req))
 	result, err := client.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer result.Body.Close()
 	if result.StatusCode != http.StatusOK {
 		d, err := ioutil.ReadAll(ioutil.NopCloser(result.Body))
 		if err != nil {
@@ -115,13 +136,69 @@ func (client Client) Put(ctx context.Context, path string, body io.Reader) (*oss
 	}
 	now := time.Now()
 	return &oss.Object{
-		Path:             path,
-		Name:             filepath.Base(path),
-		LastModified:     &now,
-		StorageInterface: client,
+		Path:               path,
+		Name:               filepath.Base(path),
+		LastModified:       &now,
+		Size:               int64(len(data)),
+		ContentType:        contentType,
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		ETag:               strings.Trim(result.Header.Get("ETag"), `"`),
+		Metadata:           o.Metadata,
+		StorageInterface:   client,
 	}, nil
 }
 
+const metaPrefix = "X-Cos-Meta-"
+
+func setPutHeaders(header http.Header, contentType string, o *oss.PutOptions) {
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if o.CacheControl != "" {
+		header.Set("Cache-Control", o.CacheControl)
+	}
+	if o.ContentDisposition != "" {
+		header.Set("Content-Disposition", o.ContentDisposition)
+	}
+	for k, v := range o.Metadata {
+		header.Set(metaPrefix+k, v)
+	}
+}
+
+// Stat get object's metadata with a HEAD request
+func (client Client) Stat(ctx context.Context, path string) (*oss.Object, error) {
+	resp, err := client.do(ctx, http.MethodHead, path, nil, nil, nil, -1)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	key := client.ToRelativePath(path)
+	object := &oss.Object{
+		Path:               "/" + key,
+		Name:               filepath.Base(key),
+		Size:               resp.ContentLength,
+		ContentType:        resp.Header.Get("Content-Type"),
+		CacheControl:       resp.Header.Get("Cache-Control"),
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+		ETag:               strings.Trim(resp.Header.Get("ETag"), `"`),
+		StorageInterface:   client,
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.LastModified = &lastModified
+	}
+	for k := range resp.Header {
+		if name, ok := strings.CutPrefix(k, metaPrefix); ok {
+			if object.Metadata == nil {
+				object.Metadata = map[string]string{}
+			}
+			object.Metadata[strings.ToLower(name)] = resp.Header.Get(k)
+		}
+	}
+	return object, nil
+}
+
 func (client Client) Delete(ctx context.Context, path string) error {
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s%s", client.getUrl(), client.ToRelativePath(path)), nil)
 	if err != nil {
@@ -143,22 +220,70 @@ func (client Client) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
-// todo not found api
+// List list all objects under current path
 func (client Client) List(ctx context.Context, path string) ([]*oss.Object, error) {
-	var objects []*oss.Object
+	return oss.ListAll(ctx, client, &oss.ListOptions{Prefix: oss.DirPrefix(path)})
+}
 
-	results, err := client.Get(ctx, path)
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	NextMarker  string   `xml:"NextMarker"`
+	Contents    []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []string `xml:"CommonPrefixes>Prefix"`
+}
 
-	if err == nil {
-		objects = append(objects, &oss.Object{
-			Path: client.ToRelativePath(path),
-			Name: results.Name(),
-			//LastModified:     &obj.LastModified,
+// ListPage list a page of objects with the GET Bucket API
+func (client Client) ListPage(ctx context.Context, opts *oss.ListOptions) (*oss.ListResult, error) {
+	query := url.Values{"max-keys": {strconv.Itoa(opts.GetMaxKeys())}}
+	if prefix := client.ToRelativePath(opts.Prefix); prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if opts.Delimiter != "" {
+		query.Set("delimiter", opts.Delimiter)
+	}
+	if opts.ContinuationToken != "" {
+		query.Set("marker", opts.ContinuationToken)
+	}
+
+	resp, err := client.do(ctx, http.MethodGet, "", query, nil, nil, -1)
+	if err != nil {
+		return nil, err
+	}
+	var results listBucketResult
+	if err := decodeXML(resp, &results); err != nil {
+		return nil, err
+	}
+
+	result := &oss.ListResult{IsTruncated: results.IsTruncated}
+	for _, content := range results.Contents {
+		lastModified := content.LastModified
+		result.Objects = append(result.Objects, &oss.Object{
+			Path:             "/" + content.Key,
+			Name:             filepath.Base(content.Key),
+			LastModified:     &lastModified,
+			Size:             content.Size,
+			ETag:             strings.Trim(content.ETag, `"`),
 			StorageInterface: client,
 		})
+	}
+	for _, commonPrefix := range results.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, "/"+commonPrefix)
+	}
 
+	if results.IsTruncated {
+		// NextMarker is only returned when a delimiter is given, otherwise the last key is the marker
+		result.NextContinuationToken = results.NextMarker
+		if result.NextContinuationToken == "" && len(results.Contents) > 0 {
+			result.NextContinuationToken = results.Contents[len(results.Contents)-1].Key
+		}
 	}
-	return objects, err
+	return result, nil
 }
 
 func (client Client) GetEndpoint(ctx context.Context) string {
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -48,7 +49,7 @@ func TestAll(storage oss.StorageInterface, t *testing.T) {
 	}
 
 	if file, err := os.Open(sampleFile); err == nil {
-		if object, err := storage.Put(ctx, fileName2, file); err != nil {
+		if object, err := storage.Put(ctx, fileName2, file, oss.PutContentType("text/plain"), oss.PutMetadata(map[string]string{"Author": "qor"})); err != nil {
 			t.Errorf("No error should happen when save sample file, but got %v", err)
 		} else if object.Path == "" || object.StorageInterface == nil {
 			t.Errorf("returned object should necessary information")
@@ -57,6 +58,23 @@ func TestAll(storage oss.StorageInterface, t *testing.T) {
 		t.Errorf("No error should happen when opem sample file, but got %v", err)
 	}
 
+	// Stat
+	if sampleInfo, err := os.Stat(sampleFile); err != nil {
+		t.Errorf("No error should happen when stat sample file, but got %v", err)
+	} else if object, err := storage.Stat(ctx, fileName2); err != nil {
+		t.Errorf("No error should happen when stat sample file, but got %v", err)
+	} else {
+		if object.Size != sampleInfo.Size() {
+			t.Errorf("Stat should return size %v, but got %v", sampleInfo.Size(), object.Size)
+		}
+		if !strings.HasPrefix(object.ContentType, "text/plain") {
+			t.Errorf("Stat should return the content type given to Put, but got %v", object.ContentType)
+		}
+		if object.Metadata["author"] != "qor" {
+			t.Errorf("Stat should return the metadata given to Put, but got %v", object.Metadata)
+		}
+	}
+
 	// Get file
 	if file, err := storage.Get(ctx, fileName); err != nil {
 		t.Errorf("No error should happen when get sample file, but got %v", err)
@@ -122,6 +140,42 @@ func TestAll(storage oss.StorageInterface, t *testing.T) {
 		}
 	}
 
+	// List doesn't return the objects of a sibling directory sharing the prefix
+	if objects, err := storage.List(ctx, randomPath[:len(randomPath)-1]); err != nil {
+		t.Errorf("No error should happen when list objects, but got %v", err)
+	} else {
+		for _, object := range objects {
+			if object.Path == fileName || object.Path == fileName2 {
+				t.Errorf("Should not found %v when listing a sibling directory", object.Path)
+			}
+		}
+	}
+
+	// ListPage with delimiter
+	if result, err := oss.ListPage(ctx, storage, &oss.ListOptions{Prefix: randomPath + "/", Delimiter: "/"}); err != nil {
+		t.Errorf("No error should happen when list a page of objects, but got %v", err)
+	} else {
+		if len(result.Objects) != 1 || result.Objects[0].Path != fileName {
+			t.Errorf("Should found only %v in the directory, but got %v", fileName, result.Objects)
+		}
+		if len(result.CommonPrefixes) != 1 || result.CommonPrefixes[0] != "/"+randomPath+"/sample2/" {
+			t.Errorf("Should found common prefix %v, but got %v", "/"+randomPath+"/sample2/", result.CommonPrefixes)
+		}
+	}
+
+	// ListSeq follows pages
+	var listed int
+	for _, err := range oss.ListSeq(ctx, storage, &oss.ListOptions{Prefix: randomPath + "/", MaxKeys: 1}) {
+		if err != nil {
+			t.Errorf("No error should happen when stream objects, but got %v", err)
+			break
+		}
+		listed++
+	}
+	if listed != exceptObjects {
+		t.Errorf("Should stream %v objects, but got %v", exceptObjects, listed)
+	}
+
 	// Delete
 	if err := storage.Delete(ctx, fileName); err != nil {
 		t.Errorf("No error should happen when delete sample file, but got %v", err)
@@ -132,6 +186,11 @@ func TestAll(storage oss.StorageInterface, t *testing.T) {
 		t.Errorf("There should be an error when get deleted sample file")
 	}
 
+	// Stat file after delete
+	if _, err := storage.Stat(ctx, fileName); !errors.Is(err, oss.ErrNotFound) {
+		t.Errorf("Stat deleted sample file should return oss.ErrNotFound, but got %v", err)
+	}
+
 	// Get file after delete
 	if _, err := storage.Get(ctx, fileName2); err != nil {
 		t.Errorf("Sample file 2 should no been deleted")
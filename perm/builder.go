@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"strings"
@@ -34,12 +35,28 @@ type (
 	Conditions   = ladon.Conditions
 	SubjectsFunc func(r *http.Request) []string
 	ContextFunc  func(r *http.Request, objs []interface{}) Context
+	// CtxSubjectsFunc returns the subjects of the calls without *http.Request, e.g. of gRPC, from the context
+	CtxSubjectsFunc func(ctx context.Context) []string
 )
 
+// DBPolicy loads the policies stored in the database, all of them without startFrom, otherwise the ones
+// updated or deleted since startFrom.
 type DBPolicy interface {
 	LoadDBPolicies(db *gorm.DB, startFrom *time.Time) ([]*PolicyBuilder, []*PolicyBuilder)
 }
 
+// DBPolicyTryLoader can be implemented by DBPolicy to report the load errors,
+// the policies loaded before are then kept if loading fails.
+type DBPolicyTryLoader interface {
+	TryLoadDBPolicies(db *gorm.DB, startFrom *time.Time) (toUpdateOrCreate []*PolicyBuilder, toDelete []*PolicyBuilder, err error)
+}
+
+// DBRole loads all the roles and groups stored in the database, they replace the ones loaded before.
+// The ones loaded before are kept if loading fails.
+type DBRole interface {
+	LoadDBRoles(db *gorm.DB) ([]*RoleBuilder, []*GroupBuilder, error)
+}
+
 type permRNer interface {
 	PermissionRN() []string
 }
@@ -61,12 +78,26 @@ func ToPermissionRN(v interface{}) []string {
 }
 
 type Builder struct {
-	m            sync.Mutex
-	policies     []*PolicyBuilder
-	ladon        *ladon.Ladon
-	subjectsFunc SubjectsFunc
-	contextFunc  ContextFunc
-	dbPolicy     *DBPolicyBuilder
+	m               sync.Mutex
+	policies        []*PolicyBuilder
+	ladon           *ladon.Ladon
+	subjectsFunc    SubjectsFunc
+	ctxSubjectsFunc CtxSubjectsFunc
+	contextFunc     ContextFunc
+	dbPolicy        *DBPolicyBuilder
+	dbPolicyIDs     map[string]bool
+	onDenied        DecisionFunc
+	catalog         catalog
+
+	// loadMu serializes the loads of DBPolicy, so a load never applies rows older than the previous one
+	loadMu sync.Mutex
+
+	rm        sync.RWMutex
+	roles     []*RoleBuilder
+	groups    []*GroupBuilder
+	dbRoles   []*RoleBuilder
+	dbGroups  []*GroupBuilder
+	roleGraph roleGraph
 }
 
 func New() *Builder {
@@ -155,6 +186,17 @@ func (b *Builder) GetSubjectsFunc() SubjectsFunc {
 	return b.subjectsFunc
 }
 
+// CtxSubjectsFunc sets the func giving the subjects from the context of the verifier, see Verifier.WithContext.
+// It's used instead of SubjectsFunc when the verifier has no *http.Request.
+func (b *Builder) CtxSubjectsFunc(v CtxSubjectsFunc) (r *Builder) {
+	b.ctxSubjectsFunc = v
+	return b
+}
+
+func (b *Builder) GetCtxSubjectsFunc() CtxSubjectsFunc {
+	return b.ctxSubjectsFunc
+}
+
 func (b *Builder) ContextFunc(v ContextFunc) (r *Builder) {
 	b.contextFunc = v
 	return b
@@ -164,9 +206,19 @@ func (b *Builder) GetContextFunc() ContextFunc {
 	return b.contextFunc
 }
 
+// OnDenied sets a func called with the explained decision of every denied request, e.g. LogDenied.
+func (b *Builder) OnDenied(f DecisionFunc) (r *Builder) {
+	b.onDenied = f
+	return b
+}
+
 func (b *Builder) DBPolicy(dpb *DBPolicyBuilder) (r *Builder) {
 	b.dbPolicy = dpb
 
+	if dpb.watcher != nil {
+		go b.watchDBPolicies(context.Background(), dpb)
+		return b
+	}
 	go b.loopLoadDBPolicies(dpb.db, dpb.loadFrequency)
 	return b
 }
@@ -203,22 +255,103 @@ func (b *Builder) DeletePolicies(toDelete ...*PolicyBuilder) {
 	}
 }
 
+// LoadDBPoliciesToMemory loads the policies of DBPolicy, and its roles if any, see DBPolicy for startFrom.
+// The policies and roles loaded before are kept if loading them fails, the error is logged.
 func (b *Builder) LoadDBPoliciesToMemory(db *gorm.DB, startFrom *time.Time) {
-	toUpdateOrCreate, toDelete := b.dbPolicy.model.LoadDBPolicies(db, startFrom)
-	b.DeletePolicies(toDelete...)
-	b.UpdateOrCreatePolicies(toUpdateOrCreate...)
+	if err := b.loadDBPoliciesToMemory(db, startFrom); err != nil {
+		slog.Error("Failed to load permission policies", "error", err)
+	}
+}
+
+func (b *Builder) loadDBPoliciesToMemory(db *gorm.DB, startFrom *time.Time) error {
+	b.loadMu.Lock()
+	defer b.loadMu.Unlock()
+
+	var toUpdateOrCreate, toDelete []*PolicyBuilder
+	if l, ok := b.dbPolicy.model.(DBPolicyTryLoader); ok {
+		var err error
+		toUpdateOrCreate, toDelete, err = l.TryLoadDBPolicies(db, startFrom)
+		if err != nil {
+			return err
+		}
+	} else {
+		toUpdateOrCreate, toDelete = b.dbPolicy.model.LoadDBPolicies(db, startFrom)
+	}
+	b.setDBPolicies(toUpdateOrCreate, toDelete, startFrom == nil || startFrom.IsZero())
+	if b.dbPolicy.roleModel != nil {
+		roles, groups, err := b.dbPolicy.roleModel.LoadDBRoles(db)
+		if err != nil {
+			return err
+		}
+		b.setDBRoles(roles, groups)
+	}
 	if Verbose {
 		b.printPolices()
 	}
+	return nil
 }
 
+// ReloadDBPolicies reloads all the policies and roles of DBPolicy, e.g. right after changing them.
+// It does nothing without DBPolicy.
+func (b *Builder) ReloadDBPolicies() {
+	if err := b.TryReloadDBPolicies(); err != nil {
+		slog.Error("Failed to reload permission policies", "error", err)
+	}
+}
+
+// TryReloadDBPolicies is ReloadDBPolicies returning the load error, the policies and roles loaded before are kept.
+func (b *Builder) TryReloadDBPolicies() error {
+	if b.dbPolicy == nil {
+		return nil
+	}
+	return b.loadDBPoliciesToMemory(b.dbPolicy.db, nil)
+}
+
+// setDBPolicies applies the policies loaded from the database. A full load, without startFrom, also deletes
+// the policies loaded before but not anymore, e.g. deleted from the database, even without soft delete.
+func (b *Builder) setDBPolicies(toUpdateOrCreate []*PolicyBuilder, toDelete []*PolicyBuilder, full bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if full {
+		loaded := make(map[string]bool)
+		for _, p := range toUpdateOrCreate {
+			p.setIDIfEmpty()
+			loaded[p.GetID()] = true
+		}
+		for id := range b.dbPolicyIDs {
+			if !loaded[id] {
+				toDelete = append(toDelete, PolicyFor().ID(id))
+			}
+		}
+	}
+	if b.dbPolicyIDs == nil {
+		b.dbPolicyIDs = make(map[string]bool)
+	}
+	for _, p := range toDelete {
+		b.deletePolicy(p)
+		delete(b.dbPolicyIDs, p.GetID())
+	}
+	for _, p := range toUpdateOrCreate {
+		b.updateOrCreatePolicy(p)
+		b.dbPolicyIDs[p.GetID()] = true
+	}
+}
+
+// loopLoadDBPolicies loads all the policies, then the changes since the last successful load every duration.
 func (b *Builder) loopLoadDBPolicies(db *gorm.DB, duration time.Duration) {
-	now := time.Now()
-	b.LoadDBPoliciesToMemory(db, nil)
+	// nil loads all the policies, until it succeeds
+	var startFrom *time.Time
+	load := func(now time.Time) {
+		if err := b.loadDBPoliciesToMemory(db, startFrom); err != nil {
+			slog.Error("Failed to load permission policies", "error", err)
+			return
+		}
+		startFrom = &now
+	}
 
+	load(time.Now())
 	for next := range time.Tick(duration) {
-		b.LoadDBPoliciesToMemory(db, &now)
-		now = next
+		load(next)
 	}
 }
 
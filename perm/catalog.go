@@ -0,0 +1,85 @@
+package perm
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxCatalogSize bounds the actions and resources kept for autocompletion, in case they are built from data.
+const maxCatalogSize = 1000
+
+// catalog is the modules of the verifiers, and the actions and resources they verified,
+// so that policies can be edited with autocompletion.
+type catalog struct {
+	mu        sync.RWMutex
+	modules   map[string]bool
+	actions   map[string]bool
+	resources map[string]bool
+}
+
+func (c *catalog) addModule(module string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.modules == nil {
+		c.modules = make(map[string]bool)
+	}
+	c.modules[module] = true
+}
+
+// add records the action and the pattern of the resource, made of the module and the first resource part,
+// e.g. *:presets:posts:* for :presets:posts:1:.
+func (c *catalog) add(action string, resourceParts []string) {
+	resource := "*:" + strings.Join(resourceParts[:min(2, len(resourceParts))], ":") + ":*"
+
+	c.mu.RLock()
+	known := c.actions[action] && c.resources[resource]
+	c.mu.RUnlock()
+	if known {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.actions == nil {
+		c.actions = make(map[string]bool)
+		c.resources = make(map[string]bool)
+	}
+	if action != "" && len(c.actions) < maxCatalogSize {
+		c.actions[action] = true
+	}
+	if len(c.resources) < maxCatalogSize {
+		c.resources[resource] = true
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// GetModules returns the modules of the verifiers created with the builder.
+func (b *Builder) GetModules() []string {
+	b.catalog.mu.RLock()
+	defer b.catalog.mu.RUnlock()
+	return sortedKeys(b.catalog.modules)
+}
+
+// GetActions returns the actions verified so far, e.g. to autocomplete the actions of policies.
+func (b *Builder) GetActions() []string {
+	b.catalog.mu.RLock()
+	defer b.catalog.mu.RUnlock()
+	return sortedKeys(b.catalog.actions)
+}
+
+// GetResources returns the patterns of the resources verified so far, like *:presets:posts:*,
+// e.g. to autocomplete the resources of policies.
+func (b *Builder) GetResources() []string {
+	b.catalog.mu.RLock()
+	defer b.catalog.mu.RUnlock()
+	return sortedKeys(b.catalog.resources)
+}
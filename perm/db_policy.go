@@ -1,6 +1,8 @@
 package perm
 
 import (
+	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +14,8 @@ import (
 type DBPolicyBuilder struct {
 	db            *gorm.DB
 	model         DBPolicy
+	roleModel     DBRole
+	watcher       PolicyWatcher
 	loadFrequency time.Duration
 }
 
@@ -28,6 +32,13 @@ func (dpb *DBPolicyBuilder) Model(m DBPolicy) *DBPolicyBuilder {
 	return dpb
 }
 
+// RoleModel loads the roles and groups from the database with the policies, e.g. DefaultDBRole{}.
+// Roles are not loaded from the database by default.
+func (dpb *DBPolicyBuilder) RoleModel(m DBRole) *DBPolicyBuilder {
+	dpb.roleModel = m
+	return dpb
+}
+
 func (dpb *DBPolicyBuilder) LoadFrequency(d time.Duration) *DBPolicyBuilder {
 	dpb.loadFrequency = d
 	return dpb
@@ -43,12 +54,24 @@ type DefaultDBPolicy struct {
 	Resources pq.StringArray `gorm:"type:text[]"`
 }
 
-func (p DefaultDBPolicy) LoadDBPolicies(db *gorm.DB, startFrom *time.Time) (toUpdateOrCreate []*PolicyBuilder, toDelete []*PolicyBuilder) {
+// LoadDBPolicies is TryLoadDBPolicies logging the error.
+func (p DefaultDBPolicy) LoadDBPolicies(db *gorm.DB, startFrom *time.Time) ([]*PolicyBuilder, []*PolicyBuilder) {
+	toUpdateOrCreate, toDelete, err := p.TryLoadDBPolicies(db, startFrom)
+	if err != nil {
+		slog.Error("Failed to load permission policies", "error", err)
+	}
+	return toUpdateOrCreate, toDelete
+}
+
+func (p DefaultDBPolicy) TryLoadDBPolicies(db *gorm.DB, startFrom *time.Time) (toUpdateOrCreate []*PolicyBuilder, toDelete []*PolicyBuilder, err error) {
 	var ps []DefaultDBPolicy
 	if startFrom == nil || startFrom.IsZero() {
-		db.Find(&ps)
+		err = db.Find(&ps).Error
 	} else {
-		db.Unscoped().Where("updated_at >= ? or deleted_at >= ?", startFrom, startFrom).Find(&ps)
+		err = db.Unscoped().Where("updated_at >= ? or deleted_at >= ?", startFrom, startFrom).Find(&ps).Error
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load policies: %w", err)
 	}
 
 	for _, p := range ps {
@@ -65,3 +88,44 @@ func (p DefaultDBPolicy) ToPolicy() *PolicyBuilder {
 	res := strings.Split(strings.Join(p.Resources, ","), ",")
 	return PolicyFor(p.Subject).WhoAre(p.Effect).ToDo(p.Actions...).On(res...).ID(strconv.Itoa(int(p.ID)))
 }
+
+const (
+	DBRoleKindRole  = "role"
+	DBRoleKindGroup = "group"
+)
+
+// DefaultDBRole stores a role or a group, so that admins can manage the hierarchy at runtime.
+type DefaultDBRole struct {
+	gorm.Model
+
+	Name string `gorm:"uniqueIndex"`
+	// Kind is DBRoleKindRole or DBRoleKindGroup
+	Kind string
+	// Roles are the inherited roles of a role, or the roles of a group
+	Roles   pq.StringArray `gorm:"type:text[]"`
+	Members pq.StringArray `gorm:"type:text[]"`
+}
+
+func (p DefaultDBRole) LoadDBRoles(db *gorm.DB) (roles []*RoleBuilder, groups []*GroupBuilder, err error) {
+	var rs []DefaultDBRole
+	if err := db.Find(&rs).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	for _, r := range rs {
+		if r.Kind == DBRoleKindGroup {
+			groups = append(groups, r.ToGroup())
+		} else {
+			roles = append(roles, r.ToRole())
+		}
+	}
+	return
+}
+
+func (p DefaultDBRole) ToRole() *RoleBuilder {
+	return Role(p.Name).Inherits(p.Roles...)
+}
+
+func (p DefaultDBRole) ToGroup() *GroupBuilder {
+	return Group(p.Name).Roles(p.Roles...).Members(p.Members...)
+}
@@ -0,0 +1,219 @@
+package perm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ory/ladon"
+)
+
+// PolicyResult is why a policy decided or not a request.
+type PolicyResult string
+
+const (
+	PolicyMatched          PolicyResult = "matched"
+	PolicyActionMismatch   PolicyResult = "action_mismatch"
+	PolicySubjectMismatch  PolicyResult = "subject_mismatch"
+	PolicyResourceMismatch PolicyResult = "resource_mismatch"
+	PolicyConditionFailed  PolicyResult = "condition_failed"
+)
+
+// PolicyTrace is how a policy was evaluated for a subject.
+type PolicyTrace struct {
+	ID     string
+	Effect string
+	Result PolicyResult
+	// Condition is the key of the failed condition
+	Condition string
+}
+
+// SubjectTrace is how the policies were evaluated for a subject.
+type SubjectTrace struct {
+	Subject  string
+	Policies []PolicyTrace
+	// Effect is Allowed or Denied if a policy matched, empty otherwise
+	Effect string
+	// PolicyID is the policy deciding the effect, the first matching deny or allow policy
+	PolicyID string
+}
+
+// Decision is the decision of Verifier.IsAllowed with every subject tried.
+type Decision struct {
+	Action   string
+	Resource string
+	Subjects []string
+	Traces   []SubjectTrace
+	Allowed  bool
+	// Effect is the winning effect, empty if no policy matched, which denies
+	Effect string
+	// Subject and PolicyID are what decided the effect
+	Subject  string
+	PolicyID string
+	// Err is the error of IsAllowed, e.g. of a policy with an invalid pattern
+	Err error
+}
+
+var _ slog.LogValuer = (*Decision)(nil)
+
+// Explain evaluates the request like IsAllowed, and returns how each subject and policy was evaluated.
+func (b *Verifier) Explain() *Decision {
+	if b.builder == nil {
+		return &Decision{Allowed: true, Effect: Allowed}
+	}
+
+	b.prepare()
+	d := &Decision{
+		Action:   b.vr.req.Action,
+		Resource: b.vr.req.Resource,
+		Subjects: append([]string{}, b.vr.subjects...),
+	}
+	ctx := b.context()
+	for _, sub := range b.vr.subjects {
+		req := *b.vr.req
+		req.Subject = sub
+		st, err := b.builder.explain(ctx, &req)
+		d.Traces = append(d.Traces, st)
+		if err != nil {
+			d.Err = err
+			return d
+		}
+		if st.Effect == "" {
+			continue
+		}
+		// any of the subjects have permission, then have permission
+		d.Effect, d.Subject, d.PolicyID = st.Effect, st.Subject, st.PolicyID
+		if st.Effect == Allowed {
+			d.Allowed = true
+			return d
+		}
+	}
+
+	if !d.Allowed {
+		if d.Effect == Denied {
+			d.Err = ladon.ErrRequestForcefullyDenied
+		} else {
+			d.Err = ladon.ErrRequestDenied
+		}
+	}
+	return d
+}
+
+// explain evaluates all the policies for the request, deciding like ladon.Ladon.DoPoliciesAllow.
+func (b *Builder) explain(ctx context.Context, r *ladon.Request) (st SubjectTrace, err error) {
+	st.Subject = r.Subject
+	policies, err := b.ladon.Manager.FindRequestCandidates(ctx, r)
+	if err != nil {
+		return st, err
+	}
+
+	matcher := b.ladon.Matcher
+	for _, p := range policies {
+		pt := PolicyTrace{ID: p.GetID(), Effect: p.GetEffect()}
+		st.Policies = append(st.Policies, pt)
+		trace := &st.Policies[len(st.Policies)-1]
+
+		if ok, err := matcher.Matches(p, p.GetActions(), r.Action); err != nil {
+			return st, err
+		} else if !ok {
+			trace.Result = PolicyActionMismatch
+			continue
+		}
+		if ok, err := matcher.Matches(p, p.GetSubjects(), r.Subject); err != nil {
+			return st, err
+		} else if !ok {
+			trace.Result = PolicySubjectMismatch
+			continue
+		}
+		if ok, err := matcher.Matches(p, p.GetResources(), r.Resource); err != nil {
+			return st, err
+		} else if !ok {
+			trace.Result = PolicyResourceMismatch
+			continue
+		}
+		if key := failedCondition(ctx, p, r); key != "" {
+			trace.Result = PolicyConditionFailed
+			trace.Condition = key
+			continue
+		}
+
+		trace.Result = PolicyMatched
+		switch {
+		case !p.AllowAccess() && st.Effect != Denied:
+			// a deny overrides all allow policies, the others are still traced
+			st.Effect, st.PolicyID = Denied, p.GetID()
+		case p.AllowAccess() && st.Effect == "":
+			st.Effect, st.PolicyID = Allowed, p.GetID()
+		}
+	}
+	return st, nil
+}
+
+func failedCondition(ctx context.Context, p ladon.Policy, r *ladon.Request) string {
+	for key, condition := range p.GetConditions() {
+		if !condition.Fulfills(ctx, r.Context[key], r) {
+			return key
+		}
+	}
+	return ""
+}
+
+// LogValue logs the decision as a group, with the policies evaluated for each subject.
+func (d *Decision) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Bool("allowed", d.Allowed),
+		slog.String("action", d.Action),
+		slog.String("resource", d.Resource),
+		slog.Any("subjects", d.Subjects),
+		slog.String("effect", d.Effect),
+	}
+	if d.Subject != "" {
+		attrs = append(attrs, slog.String("subject", d.Subject), slog.String("policy_id", d.PolicyID))
+	}
+	if d.Err != nil {
+		attrs = append(attrs, slog.String("error", d.Err.Error()))
+	}
+	attrs = append(attrs, slog.Any("trace", d.trace()))
+	return slog.GroupValue(attrs...)
+}
+
+// KVs returns the decision as key values prefixed with perm., e.g. for the attributes of a logtracing span.
+func (d *Decision) KVs() []any {
+	var kvs []any
+	for _, attr := range d.LogValue().Group() {
+		kvs = append(kvs, "perm."+attr.Key, attr.Value.Any())
+	}
+	return kvs
+}
+
+// trace returns the evaluated policies as subject/policy=result, with the failed condition.
+func (d *Decision) trace() []string {
+	var r []string
+	for _, st := range d.Traces {
+		for _, pt := range st.Policies {
+			s := fmt.Sprintf("%s/%s=%s", st.Subject, pt.ID, pt.Result)
+			if pt.Condition != "" {
+				s += ":" + pt.Condition
+			}
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// String formats the decision for debugging.
+func (d *Decision) String() string {
+	return fmt.Sprintf("allowed=%v effect=%q action=%q resource=%q subject=%q policy=%q trace=[%s]",
+		d.Allowed, d.Effect, d.Action, d.Resource, d.Subject, d.PolicyID, strings.Join(d.trace(), " "))
+}
+
+// DecisionFunc is called with the decisions of the denied requests, see Builder.OnDenied.
+type DecisionFunc func(ctx context.Context, d *Decision)
+
+// LogDenied returns a DecisionFunc logging the denials with logger, e.g. to audit them in production.
+func LogDenied(logger *slog.Logger) DecisionFunc {
+	return func(ctx context.Context, d *Decision) {
+		logger.InfoContext(ctx, "permission denied", slog.Any("perm", d))
+	}
+}
@@ -0,0 +1,106 @@
+package perm_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/ladon"
+	"github.com/qor5/x/v3/perm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	var denied []*perm.Decision
+	p := perm.New().Policies(
+		perm.PolicyFor("viewer").WhoAre(perm.Allowed).ToDo("read").On("*:posts:*").ID("viewer_read"),
+		perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*").ID("editor_update"),
+		perm.PolicyFor("editor").WhoAre(perm.Denied).ToDo("update").On("*:posts:locked:*").ID("editor_locked"),
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Allowed).ToDo("delete").On("*:posts:*").ID("owner_delete").Given(perm.Conditions{
+			"owner": &ladon.EqualsSubjectCondition{},
+		}),
+	).Roles(
+		perm.Role("editor").Inherits("viewer"),
+	).OnDenied(func(ctx context.Context, d *perm.Decision) {
+		denied = append(denied, d)
+	})
+	verifier := perm.NewVerifier("presets", p)
+
+	d := verifier.Do("read").On("posts").From("editor").Explain()
+	assert.True(t, d.Allowed)
+	assert.NoError(t, d.Err)
+	assert.Equal(t, []string{"editor", "viewer"}, d.Subjects)
+	assert.Equal(t, perm.Allowed, d.Effect)
+	assert.Equal(t, "viewer", d.Subject)
+	assert.Equal(t, "viewer_read", d.PolicyID)
+	require.Len(t, d.Traces, 2)
+	assert.Empty(t, d.Traces[0].Effect)
+
+	d = verifier.Do("update").On("posts", "locked").From("editor").Explain()
+	assert.False(t, d.Allowed)
+	assert.ErrorIs(t, d.Err, ladon.ErrRequestForcefullyDenied)
+	assert.Equal(t, perm.Denied, d.Effect)
+	assert.Equal(t, "editor_locked", d.PolicyID)
+	results := map[string]perm.PolicyResult{}
+	for _, pt := range d.Traces[0].Policies {
+		results[pt.ID] = pt.Result
+	}
+	assert.Equal(t, perm.PolicyActionMismatch, results["viewer_read"])
+	assert.Equal(t, perm.PolicyMatched, results["editor_update"])
+	assert.Equal(t, perm.PolicyMatched, results["editor_locked"])
+
+	d = verifier.Do("delete").On("posts").From("user_1").Given(perm.Context{"owner": "user_2"}).Explain()
+	assert.False(t, d.Allowed)
+	assert.ErrorIs(t, d.Err, ladon.ErrRequestDenied)
+	assert.Empty(t, d.Effect)
+	for _, pt := range d.Traces[0].Policies {
+		if pt.ID == "owner_delete" {
+			assert.Equal(t, perm.PolicyConditionFailed, pt.Result)
+			assert.Equal(t, "owner", pt.Condition)
+		} else {
+			assert.Equal(t, perm.PolicyActionMismatch, pt.Result)
+		}
+	}
+
+	// explained like IsAllowed decides
+	for _, c := range []struct {
+		subject, action string
+		on              []string
+	}{
+		{"viewer", "read", []string{"posts"}},
+		{"viewer", "update", []string{"posts"}},
+		{"editor", "update", []string{"posts", "locked"}},
+		{"editor", "update", []string{"posts", "1"}},
+		{"anonymous", "delete", []string{"posts"}},
+	} {
+		err := verifier.Do(c.action).On(c.on...).From(c.subject).IsAllowed()
+		d := verifier.Do(c.action).On(c.on...).From(c.subject).Explain()
+		assert.Equal(t, err == nil, d.Allowed, "%s %s", c.subject, c.action)
+	}
+
+	// denials are explained to OnDenied
+	denied = nil
+	err := verifier.Do("update").On("posts").WithReq(httptest.NewRequest("GET", "/", nil)).IsAllowed()
+	assert.Error(t, err)
+	require.Len(t, denied, 1)
+	assert.Equal(t, []string{perm.Anonymous}, denied[0].Subjects)
+	assert.Equal(t, ":presets:posts:", denied[0].Resource)
+
+	buf := bytes.Buffer{}
+	perm.LogDenied(slog.New(slog.NewJSONHandler(&buf, nil)))(context.Background(), denied[0])
+	var logged map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logged))
+	assert.Equal(t, "permission denied", logged["msg"])
+	attrs := logged["perm"].(map[string]interface{})
+	assert.Equal(t, false, attrs["allowed"])
+	assert.Equal(t, "update", attrs["action"])
+	assert.Contains(t, attrs["trace"], "anonymous/editor_update=subject_mismatch")
+
+	kvs := denied[0].KVs()
+	assert.Equal(t, "perm.allowed", kvs[0])
+	assert.Equal(t, false, kvs[1])
+}
@@ -0,0 +1,317 @@
+package permadmin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qor5/web/v3"
+	v "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	h "github.com/theplant/htmlgo"
+
+	"github.com/qor5/x/v3/perm"
+)
+
+const (
+	// EventEdit is the event opening the form of a policy, a new one without the id query
+	EventEdit = "permadmin_edit"
+	// EventSave is the event saving the posted form of a policy
+	EventSave = "permadmin_save"
+	// EventDelete is the event deleting the policy of the id query
+	EventDelete = "permadmin_delete"
+	// EventTestAccess is the event simulating the posted access request
+	EventTestAccess = "permadmin_test_access"
+
+	listPortal = "permadminList"
+	formPortal = "permadminForm"
+	testPortal = "permadminTest"
+
+	testSubjectField  = "test.Subject"
+	testActionField   = "test.Action"
+	testResourceField = "test.Resource"
+)
+
+// Builder builds an admin page listing the policies per subject, editing them with the actions and
+// resources of the verifiers as suggestions, and testing the access of a subject.
+// The changes are made through the API, the flow behind its handlers.
+//
+// Example:
+//
+//	mux.Handle("/admin/policies", permadmin.New(permadmin.NewAPI(db, builder)).Page().Wrap(layout))
+type Builder struct {
+	api   *API
+	title string
+}
+
+// Option is a function that configures a Builder.
+type Option func(*Builder)
+
+// WithTitle sets the title of the page.
+func WithTitle(title string) Option {
+	return func(b *Builder) {
+		b.title = title
+	}
+}
+
+// New creates a Builder managing the policies with api.
+func New(api *API, opts ...Option) *Builder {
+	b := &Builder{
+		api:   api,
+		title: "Permissions",
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Page returns the page rendering the policies and handling their events.
+func (b *Builder) Page() *web.PageBuilder {
+	return web.Page(b.page).
+		EventFunc(EventEdit, b.edit).
+		EventFunc(EventSave, b.save).
+		EventFunc(EventDelete, b.delete).
+		EventFunc(EventTestAccess, b.testAccess)
+}
+
+func (b *Builder) page(ctx *web.EventContext) (r web.PageResponse, err error) {
+	list, err := b.list(ctx)
+	if err != nil {
+		return r, err
+	}
+	suggestions, err := b.api.Suggest(ctx.R.Context())
+	if err != nil {
+		return r, err
+	}
+
+	r.PageTitle = b.title
+	r.Body = v.VContainer(
+		h.Div(
+			h.H1(b.title).Class("text-h5"),
+			vx.VXBtn("New policy").Color("primary").
+				Attr("@click", web.Plaid().EventFunc(EventEdit).Go()),
+		).Class("d-flex justify-space-between align-center mb-4"),
+		web.Portal().Name(formPortal),
+		v.VRow(
+			v.VCol(web.Portal(list).Name(listPortal)).Cols(12).Md(8),
+			v.VCol(b.testPanel(suggestions)).Cols(12).Md(4),
+		),
+	)
+	return r, nil
+}
+
+// list renders the policies grouped by subject, of the subject query only if any.
+func (b *Builder) list(ctx *web.EventContext) (h.HTMLComponent, error) {
+	groups, err := b.api.List(ctx.R.Context(), ctx.R.FormValue("subject"))
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return v.VAlert().Type("info").Text("No policies yet"), nil
+	}
+
+	var cards []h.HTMLComponent
+	for _, g := range groups {
+		var rows []h.HTMLComponent
+		for _, p := range g.Policies {
+			color := "success"
+			if p.Effect == perm.Denied {
+				color = "error"
+			}
+			id := strconv.FormatUint(uint64(p.ID), 10)
+			rows = append(rows, h.Tr(
+				h.Td(v.VChip().Text(p.Effect).Color(color).Size(v.SizeSmall)),
+				h.Td(h.Text(strings.Join(p.Actions, ", "))),
+				h.Td(h.Text(strings.Join(p.Resources, ", "))),
+				h.Td(
+					vx.VXBtn("Edit").Variant(v.VariantText).Size(v.SizeSmall).
+						Attr("@click", web.Plaid().EventFunc(EventEdit).Query("id", id).Go()),
+					vx.VXBtn("Delete").Variant(v.VariantText).Size(v.SizeSmall).Color("error").
+						Attr("@click", web.Plaid().EventFunc(EventDelete).Query("id", id).Go()),
+				).Class("text-right"),
+			))
+		}
+		cards = append(cards, v.VCard(
+			v.VCardTitle(h.Text(g.Subject)),
+			v.VCardText(v.VTable(
+				h.Thead(h.Tr(h.Th("Effect"), h.Th("Actions"), h.Th("Resources"), h.Th(""))),
+				h.Tbody(rows...),
+			)),
+		).Class("mb-4"))
+	}
+	return h.Components(cards...), nil
+}
+
+func (b *Builder) edit(ctx *web.EventContext) (r web.EventResponse, err error) {
+	p := &Policy{Effect: perm.Allowed}
+	if id := ctx.R.FormValue("id"); id != "" {
+		pid, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return r, errors.Wrap(err, "invalid id")
+		}
+		if p, err = b.api.Get(ctx.R.Context(), uint(pid)); err != nil {
+			return r, err
+		}
+	}
+	suggestions, err := b.api.Suggest(ctx.R.Context())
+	if err != nil {
+		return r, err
+	}
+
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+		Name: formPortal,
+		Body: b.form(p, suggestions, ""),
+	})
+	return r, nil
+}
+
+func (b *Builder) form(p *Policy, suggestions *Suggestions, errorMessage string) h.HTMLComponent {
+	title := "New policy"
+	if p.ID != 0 {
+		title = fmt.Sprintf("Policy %d", p.ID)
+	}
+	var alert h.HTMLComponent
+	if errorMessage != "" {
+		alert = v.VAlert().Type("error").Text(errorMessage).Class("mb-4")
+	}
+
+	return v.VCard(
+		v.VCardTitle(h.Text(title)),
+		v.VCardText(
+			alert,
+			v.VCombobox().Label("Subject").Items(suggestions.Subjects).
+				Attr(web.VField("Subject", p.Subject)...),
+			vx.VXSelect().Label("Effect").Items([]string{perm.Allowed, perm.Denied}).
+				Attr(web.VField("Effect", p.Effect)...),
+			v.VCombobox().Label("Actions").Items(suggestions.Actions).
+				Multiple(true).Chips(true).ClosableChips(true).
+				Attr(web.VField("Actions", p.Actions)...),
+			v.VCombobox().Label("Resources").Items(suggestions.Resources).
+				Multiple(true).Chips(true).ClosableChips(true).
+				Attr(web.VField("Resources", p.Resources)...),
+			h.Div(
+				vx.VXBtn("Save").Color("primary").
+					Attr("@click", web.Plaid().EventFunc(EventSave).Query("id", strconv.FormatUint(uint64(p.ID), 10)).Go()),
+			).Class("d-flex justify-end mt-4"),
+		),
+	).Class("mb-4")
+}
+
+func (b *Builder) save(ctx *web.EventContext) (r web.EventResponse, err error) {
+	id, _ := strconv.ParseUint(ctx.R.FormValue("id"), 10, 64)
+	p := &Policy{
+		ID:        uint(id),
+		Subject:   ctx.R.FormValue("Subject"),
+		Effect:    ctx.R.FormValue("Effect"),
+		Actions:   ctx.R.Form["Actions"],
+		Resources: ctx.R.Form["Resources"],
+	}
+	if p.ID != 0 {
+		// keep the refer id, which the form doesn't edit
+		current, err := b.api.Get(ctx.R.Context(), p.ID)
+		if err != nil {
+			return r, err
+		}
+		p.ReferID = current.ReferID
+	}
+
+	if _, err := b.api.Save(ctx.R.Context(), p); err != nil {
+		if !errors.Is(err, ErrInvalidPolicy) {
+			return r, err
+		}
+		suggestions, serr := b.api.Suggest(ctx.R.Context())
+		if serr != nil {
+			return r, serr
+		}
+		r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+			Name: formPortal,
+			Body: b.form(p, suggestions, err.Error()),
+		})
+		return r, nil
+	}
+
+	if r, err = b.updateList(ctx, r); err != nil {
+		return r, err
+	}
+	// closes the form
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{Name: formPortal, Body: h.Text("")})
+	return r, nil
+}
+
+func (b *Builder) delete(ctx *web.EventContext) (r web.EventResponse, err error) {
+	id, err := strconv.ParseUint(ctx.R.FormValue("id"), 10, 64)
+	if err != nil {
+		return r, errors.Wrap(err, "invalid id")
+	}
+	if err := b.api.Delete(ctx.R.Context(), uint(id)); err != nil {
+		return r, err
+	}
+	return b.updateList(ctx, r)
+}
+
+func (b *Builder) updateList(ctx *web.EventContext, r web.EventResponse) (web.EventResponse, error) {
+	list, err := b.list(ctx)
+	if err != nil {
+		return r, err
+	}
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{Name: listPortal, Body: list})
+	return r, nil
+}
+
+// testPanel renders the form simulating an access request, its result is rendered in the test portal.
+func (b *Builder) testPanel(suggestions *Suggestions) h.HTMLComponent {
+	return v.VCard(
+		v.VCardTitle(h.Text("Test access")),
+		v.VCardText(
+			v.VCombobox().Label("Subject").Items(suggestions.Subjects).
+				Attr(web.VField(testSubjectField, "")...),
+			v.VCombobox().Label("Action").Items(suggestions.Actions).
+				Attr(web.VField(testActionField, "")...),
+			vx.VXField().Label("Resource").Tips(fmt.Sprintf("The module first, e.g. %s:posts:1", strings.Join(suggestions.Modules, "|"))).
+				Attr(web.VField(testResourceField, "")...),
+			h.Div(
+				vx.VXBtn("Test").Color("primary").OnClick(EventTestAccess),
+			).Class("d-flex justify-end mt-4"),
+			web.Portal().Name(testPortal),
+		),
+	)
+}
+
+func (b *Builder) testAccess(ctx *web.EventContext) (r web.EventResponse, err error) {
+	result := b.api.TestAccess(ctx.R, &AccessRequest{
+		Subject:  ctx.R.FormValue(testSubjectField),
+		Action:   ctx.R.FormValue(testActionField),
+		Resource: ctx.R.FormValue(testResourceField),
+	})
+	r.UpdatePortals = append(r.UpdatePortals, &web.PortalUpdate{
+		Name: testPortal,
+		Body: accessResult(result),
+	})
+	return r, nil
+}
+
+func accessResult(result *AccessResult) h.HTMLComponent {
+	alert := v.VAlert().Type("success").Text("Allowed")
+	if !result.Allowed {
+		alert = v.VAlert().Type("error").Text("Denied")
+	}
+	var lines []h.HTMLComponent
+	if result.PolicyID != "" {
+		lines = append(lines, h.Div(h.Text(fmt.Sprintf("By policy %s of %s (%s)", result.PolicyID, result.Subject, result.Effect))))
+	} else {
+		lines = append(lines, h.Div(h.Text("No policy matched")))
+	}
+	lines = append(lines, h.Div(h.Text("Subjects: "+strings.Join(result.Subjects, ", "))))
+	for _, st := range result.Traces {
+		for _, pt := range st.Policies {
+			line := fmt.Sprintf("%s / policy %s: %s", st.Subject, pt.ID, pt.Result)
+			if pt.Condition != "" {
+				line += " (" + pt.Condition + ")"
+			}
+			lines = append(lines, h.Div(h.Text(line)).Class("text-caption"))
+		}
+	}
+	return h.Div(alert, h.Div(lines...).Class("mt-2")).Class("mt-4")
+}
@@ -0,0 +1,398 @@
+// Package permadmin manages the perm.DefaultDBPolicy rows with a REST API and an admin page.
+package permadmin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/qor5/x/v3/httpx"
+	"github.com/qor5/x/v3/perm"
+)
+
+// ErrInvalidPolicy is returned when a policy can't be saved as is.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// API provides HTTP handlers to manage the perm.DefaultDBPolicy rows, and to test the access they give.
+// The changes are reloaded by the builder at once, other instances reload them with their DBPolicy,
+// see perm.DBPolicyBuilder.Listen.
+type API struct {
+	db      *gorm.DB
+	builder *perm.Builder
+}
+
+// NewAPI creates an API managing the policies in db, which are verified by builder.
+func NewAPI(db *gorm.DB, builder *perm.Builder) *API {
+	return &API{
+		db:      db,
+		builder: builder,
+	}
+}
+
+// Policy is a policy as managed by the API.
+type Policy struct {
+	ID        uint      `json:"id"`
+	ReferID   string    `json:"referID"`
+	Subject   string    `json:"subject"`
+	Effect    string    `json:"effect"`
+	Actions   []string  `json:"actions"`
+	Resources []string  `json:"resources"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func policyOf(p *perm.DefaultDBPolicy) *Policy {
+	return &Policy{
+		ID:        p.ID,
+		ReferID:   p.ReferID,
+		Subject:   p.Subject,
+		Effect:    p.Effect,
+		Actions:   p.Actions,
+		Resources: p.Resources,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// SubjectPolicies are the policies of a subject.
+type SubjectPolicies struct {
+	Subject  string    `json:"subject"`
+	Policies []*Policy `json:"policies"`
+}
+
+// Suggestions are the values to autocomplete the policies with.
+type Suggestions struct {
+	Modules   []string `json:"modules"`
+	Subjects  []string `json:"subjects"`
+	Actions   []string `json:"actions"`
+	Resources []string `json:"resources"`
+}
+
+// AccessRequest is the request simulated by TestAccess.
+type AccessRequest struct {
+	Subject string `json:"subject"`
+	Action  string `json:"action"`
+	// Resource starts with the module of the verifier, e.g. presets:posts:1
+	Resource string `json:"resource"`
+}
+
+// AccessResult is the decision of a simulated request.
+type AccessResult struct {
+	Allowed  bool                `json:"allowed"`
+	Effect   string              `json:"effect"`
+	Subject  string              `json:"subject"`
+	PolicyID string              `json:"policyID"`
+	Subjects []string            `json:"subjects"`
+	Traces   []perm.SubjectTrace `json:"traces"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// List returns the policies grouped by subject, of the subject only if not empty.
+func (api *API) List(ctx context.Context, subject string) ([]*SubjectPolicies, error) {
+	var ps []*perm.DefaultDBPolicy
+	db := api.db.WithContext(ctx).Order("subject, id")
+	if subject != "" {
+		db = db.Where("subject = ?", subject)
+	}
+	if err := db.Find(&ps).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list policies")
+	}
+
+	var r []*SubjectPolicies
+	for _, p := range ps {
+		if len(r) == 0 || r[len(r)-1].Subject != p.Subject {
+			r = append(r, &SubjectPolicies{Subject: p.Subject})
+		}
+		r[len(r)-1].Policies = append(r[len(r)-1].Policies, policyOf(p))
+	}
+	return r, nil
+}
+
+// Get returns the policy of id, the error is gorm.ErrRecordNotFound if there is none.
+func (api *API) Get(ctx context.Context, id uint) (*Policy, error) {
+	var p perm.DefaultDBPolicy
+	if err := api.db.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get policy %d", id)
+	}
+	return policyOf(&p), nil
+}
+
+// Save creates the policy if its ID is 0, or updates it, and reloads the policies of the builder.
+// It returns an error wrapping ErrInvalidPolicy if the policy is invalid.
+func (api *API) Save(ctx context.Context, p *Policy) (*Policy, error) {
+	if err := validate(p); err != nil {
+		return nil, err
+	}
+
+	row := &perm.DefaultDBPolicy{
+		ReferID:   p.ReferID,
+		Subject:   p.Subject,
+		Effect:    p.Effect,
+		Actions:   p.Actions,
+		Resources: p.Resources,
+	}
+	db := api.db.WithContext(ctx)
+	if p.ID == 0 {
+		if err := db.Create(row).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to create policy")
+		}
+	} else {
+		row.ID = p.ID
+		result := db.Model(row).Select("ReferID", "Subject", "Effect", "Actions", "Resources").Updates(row)
+		if result.Error != nil {
+			return nil, errors.Wrapf(result.Error, "failed to update policy %d", p.ID)
+		}
+		if result.RowsAffected == 0 {
+			return nil, errors.Wrapf(gorm.ErrRecordNotFound, "failed to update policy %d", p.ID)
+		}
+	}
+
+	if err := api.builder.TryReloadDBPolicies(); err != nil {
+		return nil, errors.Wrap(err, "failed to reload policies")
+	}
+	return api.Get(ctx, row.ID)
+}
+
+// Delete deletes the policy of id and reloads the policies of the builder.
+func (api *API) Delete(ctx context.Context, id uint) error {
+	result := api.db.WithContext(ctx).Delete(&perm.DefaultDBPolicy{}, id)
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to delete policy %d", id)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrapf(gorm.ErrRecordNotFound, "failed to delete policy %d", id)
+	}
+	return errors.Wrap(api.builder.TryReloadDBPolicies(), "failed to reload policies")
+}
+
+// Suggest returns the modules of the verifiers, the subjects of the policies, and the actions and resources
+// verified so far merged with the ones of the policies.
+func (api *API) Suggest(ctx context.Context) (*Suggestions, error) {
+	var ps []*perm.DefaultDBPolicy
+	if err := api.db.WithContext(ctx).Select("subject", "actions", "resources").Find(&ps).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list policies")
+	}
+
+	s := &Suggestions{
+		Modules:   api.builder.GetModules(),
+		Actions:   api.builder.GetActions(),
+		Resources: api.builder.GetResources(),
+	}
+	for _, p := range ps {
+		s.Subjects = append(s.Subjects, p.Subject)
+		s.Actions = append(s.Actions, p.Actions...)
+		s.Resources = append(s.Resources, p.Resources...)
+	}
+	s.Subjects = compact(s.Subjects)
+	s.Actions = compact(s.Actions)
+	s.Resources = compact(s.Resources)
+	return s, nil
+}
+
+func compact(vs []string) []string {
+	slices.Sort(vs)
+	return slices.Compact(vs)
+}
+
+// TestAccess simulates IsAllowed for the subject, action and resource, with the roles and groups of the subject.
+// The ContextFunc of the builder is called with r, as for the requests of its user.
+// The simulated module, action and resource are not suggested afterwards.
+func (api *API) TestAccess(r *http.Request, req *AccessRequest) *AccessResult {
+	parts := strings.Split(strings.Trim(req.Resource, ":"), ":")
+	d := perm.NewSimulationVerifier(parts[0], api.builder).Do(req.Action).On(parts[1:]...).From(req.Subject).WithReq(r).Explain()
+
+	result := &AccessResult{
+		Allowed:  d.Allowed,
+		Effect:   d.Effect,
+		Subject:  d.Subject,
+		PolicyID: d.PolicyID,
+		Subjects: d.Subjects,
+		Traces:   d.Traces,
+	}
+	if d.Err != nil {
+		result.Error = d.Err.Error()
+	}
+	return result
+}
+
+// validate checks the policy has a subject, an effect, and actions and resources that are valid patterns.
+func validate(p *Policy) error {
+	p.Subject = strings.TrimSpace(p.Subject)
+	p.Actions = nonEmpty(p.Actions)
+	p.Resources = nonEmpty(p.Resources)
+
+	switch {
+	case p.Subject == "":
+		return errors.Wrap(ErrInvalidPolicy, "subject is required")
+	case p.Effect != perm.Allowed && p.Effect != perm.Denied:
+		return errors.Wrapf(ErrInvalidPolicy, "effect must be %s or %s", perm.Allowed, perm.Denied)
+	case len(p.Actions) == 0:
+		return errors.Wrap(ErrInvalidPolicy, "actions are required")
+	case len(p.Resources) == 0:
+		return errors.Wrap(ErrInvalidPolicy, "resources are required")
+	}
+	for _, pattern := range slices.Concat(p.Actions, p.Resources) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(ErrInvalidPolicy, "%q is not a valid pattern", pattern)
+		}
+	}
+	return nil
+}
+
+func nonEmpty(vs []string) []string {
+	var r []string
+	for _, v := range vs {
+		if v = strings.TrimSpace(v); v != "" {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// ListPolicies handles GET requests listing the policies grouped by subject, filtered by the subject query.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /api/policies", api.ListPolicies)
+func (api *API) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	ps, err := api.List(r.Context(), r.URL.Query().Get("subject"))
+	if err != nil {
+		httpx.WriteJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, ps)
+}
+
+// GetPolicy handles GET requests to get the policy of the id path value.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /api/policies/{id}", api.GetPolicy)
+func (api *API) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	p, err := api.Get(r.Context(), id)
+	if err != nil {
+		respondAPIError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, p)
+}
+
+// CreatePolicy handles POST requests to create a policy.
+//
+// Example:
+//
+//	mux.HandleFunc("POST /api/policies", api.CreatePolicy)
+func (api *API) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var p Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		httpx.WriteJSONError(w, http.StatusBadRequest, errors.Wrap(err, "failed to decode request body"))
+		return
+	}
+	p.ID = 0
+	saved, err := api.Save(r.Context(), &p)
+	if err != nil {
+		respondAPIError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, saved)
+}
+
+// UpdatePolicy handles PUT requests to replace the policy of the id path value.
+//
+// Example:
+//
+//	mux.HandleFunc("PUT /api/policies/{id}", api.UpdatePolicy)
+func (api *API) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var p Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		httpx.WriteJSONError(w, http.StatusBadRequest, errors.Wrap(err, "failed to decode request body"))
+		return
+	}
+	p.ID = id
+	saved, err := api.Save(r.Context(), &p)
+	if err != nil {
+		respondAPIError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, saved)
+}
+
+// DeletePolicy handles DELETE requests to delete the policy of the id path value.
+//
+// Example:
+//
+//	mux.HandleFunc("DELETE /api/policies/{id}", api.DeletePolicy)
+func (api *API) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := api.Delete(r.Context(), id); err != nil {
+		respondAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSuggestions handles GET requests to get the values to autocomplete the policies with.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /api/policies/suggestions", api.GetSuggestions)
+func (api *API) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	s, err := api.Suggest(r.Context())
+	if err != nil {
+		httpx.WriteJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, s)
+}
+
+// PostTestAccess handles POST requests to simulate an AccessRequest.
+//
+// Example:
+//
+//	mux.HandleFunc("POST /api/policies/test", api.PostTestAccess)
+func (api *API) PostTestAccess(w http.ResponseWriter, r *http.Request) {
+	var req AccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteJSONError(w, http.StatusBadRequest, errors.Wrap(err, "failed to decode request body"))
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, api.TestAccess(r, &req))
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httpx.WriteJSONError(w, http.StatusBadRequest, errors.Wrap(err, "invalid id"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondAPIError writes the error of the API, invalid policies are 400 and missing ones 404.
+func respondAPIError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidPolicy):
+		httpx.WriteJSONError(w, http.StatusBadRequest, err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		httpx.WriteJSONError(w, http.StatusNotFound, err)
+	default:
+		httpx.WriteJSONError(w, http.StatusInternalServerError, err)
+	}
+}
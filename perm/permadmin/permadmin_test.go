@@ -0,0 +1,210 @@
+package permadmin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qor5/web/v3/multipartestutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/qor5/x/v3/gormx"
+	"github.com/qor5/x/v3/perm"
+	"github.com/qor5/x/v3/perm/permadmin"
+)
+
+var suite *gormx.TestSuite
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	suite = gormx.MustStartTestSuite(ctx)
+	defer func() {
+		if err := suite.Stop(context.Background()); err != nil {
+			fmt.Printf("Error during teardown: %v\n", err)
+		}
+	}()
+
+	os.Exit(m.Run())
+}
+
+func TestAPI(t *testing.T) {
+	ctx := context.Background()
+	db := suite.DB()
+	require.NoError(t, suite.ResetDB(ctx, &perm.DefaultDBPolicy{}))
+
+	builder := perm.New().DBPolicy(perm.NewDBPolicy(db))
+	verifier := perm.NewVerifier("presets", builder)
+	assert.Error(t, verifier.Do("update").On("posts", "1").From("editor").IsAllowed())
+
+	api := permadmin.NewAPI(db, builder)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /policies", api.ListPolicies)
+	mux.HandleFunc("POST /policies", api.CreatePolicy)
+	mux.HandleFunc("GET /policies/suggestions", api.GetSuggestions)
+	mux.HandleFunc("POST /policies/test", api.PostTestAccess)
+	mux.HandleFunc("GET /policies/{id}", api.GetPolicy)
+	mux.HandleFunc("PUT /policies/{id}", api.UpdatePolicy)
+	mux.HandleFunc("DELETE /policies/{id}", api.DeletePolicy)
+
+	serve := func(method, path string, body any, out any) int {
+		var r *http.Request
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			r = httptest.NewRequest(method, path, strings.NewReader(string(data)))
+		} else {
+			r = httptest.NewRequest(method, path, nil)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		}
+		return w.Code
+	}
+	testAccess := func() *permadmin.AccessResult {
+		var result permadmin.AccessResult
+		req := &permadmin.AccessRequest{Subject: "editor", Action: "update", Resource: "presets:posts:1"}
+		require.Equal(t, http.StatusOK, serve(http.MethodPost, "/policies/test", req, &result))
+		return &result
+	}
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/policies", &permadmin.Policy{Subject: "editor", Effect: perm.Allowed}, nil))
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/policies", &permadmin.Policy{
+		Subject: "editor", Effect: perm.Allowed, Actions: []string{"update"}, Resources: []string{"[posts"},
+	}, nil))
+
+	var created permadmin.Policy
+	require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/policies", &permadmin.Policy{
+		Subject: "editor", Effect: perm.Allowed, Actions: []string{"update"}, Resources: []string{"*:presets:posts:*"},
+	}, &created))
+	assert.NotZero(t, created.ID)
+	assert.NoError(t, verifier.Do("update").On("posts", "1").From("editor").IsAllowed())
+
+	result := testAccess()
+	assert.True(t, result.Allowed)
+	assert.Equal(t, fmt.Sprint(created.ID), result.PolicyID)
+
+	var groups []*permadmin.SubjectPolicies
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/policies?subject=editor", nil, &groups))
+	require.Len(t, groups, 1)
+	assert.Equal(t, "editor", groups[0].Subject)
+	assert.Equal(t, []string{"update"}, groups[0].Policies[0].Actions)
+
+	var suggestions permadmin.Suggestions
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/policies/suggestions", nil, &suggestions))
+	assert.Equal(t, []string{"presets"}, suggestions.Modules)
+	assert.Equal(t, []string{"editor"}, suggestions.Subjects)
+	assert.Contains(t, suggestions.Actions, "update")
+	assert.Contains(t, suggestions.Resources, "*:presets:posts:*")
+
+	// simulated requests are not suggested
+	var simulated permadmin.AccessResult
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/policies/test", &permadmin.AccessRequest{
+		Subject: "editor", Action: "export", Resource: "reports:sales:1",
+	}, &simulated))
+	assert.False(t, simulated.Allowed)
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/policies/suggestions", nil, &suggestions))
+	assert.Equal(t, []string{"presets"}, suggestions.Modules)
+	assert.NotContains(t, suggestions.Actions, "export")
+	assert.NotContains(t, suggestions.Resources, "*:reports:sales:*")
+
+	created.Effect = perm.Denied
+	path := fmt.Sprintf("/policies/%d", created.ID)
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, path, &created, nil))
+	result = testAccess()
+	assert.False(t, result.Allowed)
+	assert.Equal(t, perm.Denied, result.Effect)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, path, nil, nil))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, path, nil, nil))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPut, path, &created, nil))
+	result = testAccess()
+	assert.False(t, result.Allowed)
+	assert.Empty(t, result.Effect)
+}
+
+func TestPage(t *testing.T) {
+	ctx := context.Background()
+	db := suite.DB()
+	require.NoError(t, suite.ResetDB(ctx, &perm.DefaultDBPolicy{}))
+
+	builder := perm.New().DBPolicy(perm.NewDBPolicy(db))
+	perm.NewVerifier("presets", builder)
+	page := permadmin.New(permadmin.NewAPI(db, builder), permadmin.WithTitle("Policies")).Page()
+
+	cases := []multipartestutils.TestCase{
+		{
+			Name: "render",
+			ReqFunc: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/", nil)
+			},
+			ExpectPageBodyContainsInOrder: []string{"Policies", "No policies yet", "Test access"},
+		},
+		{
+			Name: "invalid policy",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(permadmin.EventSave).
+					AddField("Subject", "editor").
+					AddField("Effect", perm.Allowed).
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"actions are required"},
+		},
+		{
+			Name: "save",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(permadmin.EventSave).
+					AddField("Subject", "editor").
+					AddField("Effect", perm.Allowed).
+					AddField("Actions", "update").
+					AddField("Actions", "publish").
+					AddField("Resources", "*:presets:posts:*").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"editor", "allow", "update, publish", "*:presets:posts:*"},
+		},
+		{
+			Name: "test access",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(permadmin.EventTestAccess).
+					AddField("test.Subject", "editor").
+					AddField("test.Action", "publish").
+					AddField("test.Resource", "presets:posts:1").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"Allowed", "of editor (allow)"},
+		},
+		{
+			Name: "test access denied",
+			ReqFunc: func() *http.Request {
+				return multipartestutils.NewMultipartBuilder().
+					EventFunc(permadmin.EventTestAccess).
+					AddField("test.Subject", "editor").
+					AddField("test.Action", "delete").
+					AddField("test.Resource", "presets:posts:1").
+					BuildEventFuncRequest()
+			},
+			ExpectPortalUpdate0ContainsInOrder: []string{"Denied", "No policy matched", "action_mismatch"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			multipartestutils.RunCase(t, c, page)
+		})
+	}
+
+	var p perm.DefaultDBPolicy
+	require.NoError(t, db.First(&p).Error)
+	assert.Equal(t, []string{"update", "publish"}, []string(p.Actions))
+}
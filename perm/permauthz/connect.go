@@ -0,0 +1,22 @@
+package permauthz
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+
+	"github.com/qor5/x/v3/normalize"
+)
+
+// UnaryConnectInterceptor creates a Connect unary interceptor authorizing the calls,
+// it must be chained after normalize.UnaryConnectInterceptor, e.g. by connectx.NewHandler.
+func (a *Authorizer) UnaryConnectInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if err := a.Authorize(ctx, normalize.MustCallMetaFromContext(ctx)); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package permauthz
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/qor5/x/v3/normalize"
+)
+
+// UnaryServerInterceptor creates a gRPC unary server interceptor authorizing the calls,
+// it must be chained after normalize.GRPCUnaryServerInterceptor.
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.Authorize(ctx, normalize.MustCallMetaFromContext(ctx)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,37 @@
+package permauthz
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/qor5/x/v3/statusx"
+)
+
+// HTTPMiddleware authorizes the HTTP requests, the method is the action and the segments of the path
+// are the resources, e.g. GET /posts/1 is the action GET on :module:posts:1:.
+// The unescaped segments are escaped by EscapeResource, e.g. /posts/a%3Ab gives :module:posts:a%3Ab:.
+// The subjects are given by perm.Builder.SubjectsFunc, denials are written by statusx.WriteVProtoHTTPError.
+func (a *Authorizer) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resources []string
+		for _, s := range strings.Split(r.URL.EscapedPath(), "/") {
+			if s == "" {
+				continue
+			}
+			if u, err := url.PathUnescape(s); err == nil {
+				s = u
+			}
+			resources = append(resources, EscapeResource(s))
+		}
+		err := a.verifier.Do(r.Method).On(resources...).WithReq(r).IsAllowed()
+		if err = denied(r.Method, err); err != nil {
+			if werr := statusx.WriteVProtoHTTPError(err, w, r); werr != nil {
+				slog.ErrorContext(r.Context(), "Failed to write permission error", "error", werr)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
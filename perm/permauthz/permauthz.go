@@ -0,0 +1,154 @@
+// Package permauthz authorizes gRPC, Connect and HTTP calls with a perm.Verifier.
+// The full method of a call is the action, and the fields of its request message are the resources, e.g.
+//
+//	perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("/blog.v1.PostService/*").On("*:post_id:*")
+//
+// allows editors to call the methods of PostService with any post_id.
+package permauthz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/qor5/x/v3/normalize"
+	"github.com/qor5/x/v3/perm"
+	"github.com/qor5/x/v3/statusx"
+	statusv1 "github.com/qor5/x/v3/statusx/gen/status/v1"
+)
+
+// ResourcesFunc returns the resources of a call, appended to the module of the verifier.
+type ResourcesFunc func(ctx context.Context, callMeta *normalize.CallMeta) ([]string, error)
+
+type Authorizer struct {
+	verifier  *perm.Verifier
+	resources ResourcesFunc
+}
+
+// New returns an Authorizer verifying the calls with verifier, whose builder gives the subjects
+// from the context with perm.Builder.CtxSubjectsFunc.
+func New(verifier *perm.Verifier) *Authorizer {
+	return &Authorizer{
+		verifier:  verifier,
+		resources: MessageResources(),
+	}
+}
+
+// Resources sets the func giving the resources of a call, MessageResources by default.
+func (a *Authorizer) Resources(f ResourcesFunc) (r *Authorizer) {
+	a.resources = f
+	return a
+}
+
+// Fields makes the resources the fields of names of the request message, see MessageResources.
+func (a *Authorizer) Fields(names ...string) (r *Authorizer) {
+	return a.Resources(MessageResources(names...))
+}
+
+// Authorize verifies the call, it returns a statusx error with codes.PermissionDenied if it's denied.
+func (a *Authorizer) Authorize(ctx context.Context, callMeta *normalize.CallMeta) error {
+	resources, err := a.resources(ctx, callMeta)
+	if err != nil {
+		return statusx.Wrap(err, codes.Internal, statusv1.ErrorReason_INTERNAL.String(), "failed to get the resources of the call").Err()
+	}
+	err = a.verifier.Do(callMeta.FullMethod).On(resources...).WithContext(ctx).IsAllowed()
+	return denied(callMeta.FullMethod, err)
+}
+
+// denied converts the error of perm.Verifier.IsAllowed for the action.
+func denied(action string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ladon.ErrRequestDenied) || errors.Is(err, ladon.ErrRequestForcefullyDenied) {
+		return statusx.Wrap(err, codes.PermissionDenied, statusv1.ErrorReason_PERMISSION_DENIED.String(), "permission denied").
+			WithMetadata(map[string]string{"action": action}).
+			Err()
+	}
+	return statusx.Wrapf(err, codes.Internal, statusv1.ErrorReason_INTERNAL.String(), "failed to verify the permission of %s", action).Err()
+}
+
+type permRNer interface {
+	PermissionRN() []string
+}
+
+// MessageResources returns a ResourcesFunc giving the name and the value of each populated field of the
+// request message, e.g. post_id:1, in the order of names, or of the declaration of the scalar fields if no names.
+// The values are escaped by EscapeResource, so a value can't pass for several parts of the resource.
+// A request with PermissionRN() []string gives its own resources, and a request not being a proto message
+// gives perm.ToPermissionRN.
+func MessageResources(names ...string) ResourcesFunc {
+	return func(_ context.Context, callMeta *normalize.CallMeta) ([]string, error) {
+		if rn, ok := callMeta.Req.(permRNer); ok {
+			return rn.PermissionRN(), nil
+		}
+		m, ok := callMeta.Req.(proto.Message)
+		if !ok {
+			if callMeta.Req == nil {
+				return nil, nil
+			}
+			return perm.ToPermissionRN(callMeta.Req), nil
+		}
+		return messageResources(m.ProtoReflect(), names)
+	}
+}
+
+func messageResources(msg protoreflect.Message, names []string) ([]string, error) {
+	fields := msg.Descriptor().Fields()
+	var fds []protoreflect.FieldDescriptor
+	if len(names) == 0 {
+		for i := 0; i < fields.Len(); i++ {
+			if fd := fields.Get(i); isScalar(fd) {
+				fds = append(fds, fd)
+			}
+		}
+	}
+	for _, name := range names {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, errors.Errorf("field %s not found in %s", name, msg.Descriptor().FullName())
+		}
+		if !isScalar(fd) {
+			return nil, errors.Errorf("field %s of %s is not a scalar", name, msg.Descriptor().FullName())
+		}
+		fds = append(fds, fd)
+	}
+
+	var r []string
+	for _, fd := range fds {
+		if !msg.Has(fd) {
+			continue
+		}
+		r = append(r, string(fd.Name()), EscapeResource(valueString(fd, msg.Get(fd))))
+	}
+	return r, nil
+}
+
+func isScalar(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() || fd.IsMap() {
+		return false
+	}
+	return fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind
+}
+
+func valueString(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	if fd.Kind() == protoreflect.EnumKind {
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	}
+	return v.String()
+}
+
+var resourceEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "/", "%2F")
+
+// EscapeResource escapes the % and the separators : and / of a part of a resource, since the * of a policy
+// matches : and not /, e.g. a value a:b would match the policy of a resource having b after a.
+func EscapeResource(v string) string {
+	return resourceEscaper.Replace(v)
+}
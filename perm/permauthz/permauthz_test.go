@@ -0,0 +1,203 @@
+package permauthz_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/qor5/x/v3/healthz/testdata/gen"
+	"github.com/qor5/x/v3/normalize"
+	"github.com/qor5/x/v3/perm"
+	"github.com/qor5/x/v3/perm/permauthz"
+	"github.com/qor5/x/v3/statusx"
+	statusv1 "github.com/qor5/x/v3/statusx/gen/status/v1"
+)
+
+const echoMethod = "/healthz.testdata.TestService/Echo"
+
+type testGRPCService struct {
+	gen.UnimplementedTestServiceServer
+}
+
+func (s *testGRPCService) Echo(ctx context.Context, req *gen.EchoRequest) (*gen.EchoResponse, error) {
+	return &gen.EchoResponse{Message: req.GetMessage()}, nil
+}
+
+func newAuthorizer() *permauthz.Authorizer {
+	builder := perm.New().Policies(
+		perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("/healthz.testdata.TestService/*").On("*"),
+		perm.PolicyFor("editor").WhoAre(perm.Denied).ToDo(echoMethod).On("*:message:secret:*"),
+		perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo(http.MethodGet).On("*:posts:*"),
+	).SubjectsFunc(func(r *http.Request) []string {
+		return r.Header.Values("X-Subject")
+	}).CtxSubjectsFunc(func(ctx context.Context) []string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return md.Get("x-subject")
+	})
+	return permauthz.New(perm.NewVerifier("test", builder))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			normalize.GRPCUnaryServerInterceptor(),
+			newAuthorizer().UnaryServerInterceptor(),
+		),
+	)
+	gen.RegisterTestServiceServer(server, &testGRPCService{})
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.GracefulStop()
+
+	conn, err := grpc.NewClient("passthrough://bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := gen.NewTestServiceClient(conn)
+
+	editorCtx := metadata.AppendToOutgoingContext(context.Background(), "x-subject", "editor")
+	resp, err := client.Echo(editorCtx, &gen.EchoRequest{Message: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.GetMessage())
+
+	_, err = client.Echo(editorCtx, &gen.EchoRequest{Message: "secret"})
+	require.Error(t, err)
+	st := statusx.Convert(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+	assert.Equal(t, statusv1.ErrorReason_PERMISSION_DENIED.String(), st.Reason())
+	assert.Equal(t, map[string]string{"action": echoMethod}, st.Metadata())
+
+	_, err = client.Echo(context.Background(), &gen.EchoRequest{Message: "hello"})
+	assert.Equal(t, codes.PermissionDenied, statusx.Code(err))
+}
+
+func TestUnaryConnectInterceptor(t *testing.T) {
+	svc := &testGRPCService{}
+	convertError := connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			res, err := next(ctx, req)
+			if err != nil {
+				return nil, statusx.ConvertToConnectError(err)
+			}
+			return res, nil
+		}
+	})
+	handler := connect.NewUnaryHandler(echoMethod,
+		func(ctx context.Context, req *connect.Request[gen.EchoRequest]) (*connect.Response[gen.EchoResponse], error) {
+			resp, err := svc.Echo(ctx, req.Msg)
+			if err != nil {
+				return nil, err
+			}
+			return connect.NewResponse(resp), nil
+		},
+		connect.WithInterceptors(convertError, normalize.UnaryConnectInterceptor(svc), newAuthorizer().UnaryConnectInterceptor()),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := connect.NewClient[gen.EchoRequest, gen.EchoResponse](server.Client(), server.URL+echoMethod)
+	echo := func(subject, message string) (*connect.Response[gen.EchoResponse], error) {
+		req := connect.NewRequest(&gen.EchoRequest{Message: message})
+		if subject != "" {
+			req.Header().Set("X-Subject", subject)
+		}
+		return client.CallUnary(context.Background(), req)
+	}
+
+	resp, err := echo("editor", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Msg.GetMessage())
+
+	_, err = echo("editor", "secret")
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	_, err = echo("", "hello")
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	h := newAuthorizer().HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method, path, subject string) int {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Content-Type", "application/json")
+		if subject != "" {
+			r.Header.Set("X-Subject", subject)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "/posts/1", "editor"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/posts/1", "editor"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/posts/1", ""))
+}
+
+func TestMessageResources(t *testing.T) {
+	ctx := context.Background()
+	resources, err := permauthz.MessageResources()(ctx, &normalize.CallMeta{Req: &gen.EchoRequest{Message: "hello"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"message", "hello"}, resources)
+
+	resources, err = permauthz.MessageResources()(ctx, &normalize.CallMeta{Req: &gen.EchoRequest{}})
+	require.NoError(t, err)
+	assert.Empty(t, resources)
+
+	_, err = permauthz.MessageResources("post_id")(ctx, &normalize.CallMeta{Req: &gen.EchoRequest{}})
+	assert.EqualError(t, err, "field post_id not found in healthz.testdata.EchoRequest")
+}
+
+func TestInjectedResourceValues(t *testing.T) {
+	builder := perm.New().Policies(
+		perm.PolicyFor("viewer").WhoAre(perm.Allowed).ToDo(echoMethod).On("*:message:hello:"),
+		perm.PolicyFor("viewer").WhoAre(perm.Allowed).ToDo(http.MethodGet).On("*:public:"),
+	).SubjectsFunc(func(r *http.Request) []string {
+		return r.Header.Values("X-Subject")
+	}).CtxSubjectsFunc(func(ctx context.Context) []string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return md.Get("x-subject")
+	})
+	a := permauthz.New(perm.NewVerifier("test", builder))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-subject", "viewer"))
+	authorize := func(message string) error {
+		return a.Authorize(ctx, &normalize.CallMeta{FullMethod: echoMethod, Req: &gen.EchoRequest{Message: message}})
+	}
+	require.NoError(t, authorize("hello"))
+	assert.Equal(t, codes.PermissionDenied, statusx.Code(authorize("x:message:hello")))
+	assert.Equal(t, codes.PermissionDenied, statusx.Code(authorize("x/message:hello")))
+
+	h := a.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(path string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Subject", "viewer")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve("/docs/public"))
+	assert.Equal(t, http.StatusForbidden, serve("/docs/secret:public"))
+	assert.Equal(t, http.StatusForbidden, serve("/docs/secret%3Apublic"))
+	assert.Equal(t, http.StatusForbidden, serve("/docs/secret%2Fpublic"))
+}
@@ -0,0 +1,24 @@
+// Package permtracing records perm decisions on logtracing spans, it lives apart from
+// package perm so that depending on perm does not pull in appkit.
+package permtracing
+
+import (
+	"context"
+
+	"github.com/qor5/x/v3/perm"
+	"github.com/theplant/appkit/logtracing"
+)
+
+var _ perm.DecisionFunc = Record
+
+// Record appends the attributes of the decision to the span of ctx, if any.
+// It's a perm.DecisionFunc, so the denials are recorded with:
+//
+//	perm.New().OnDenied(permtracing.Record)
+func Record(ctx context.Context, d *perm.Decision) {
+	span := logtracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.AppendKVs(d.KVs()...)
+}
@@ -0,0 +1,160 @@
+package perm
+
+import "net/http"
+
+// RoleBuilder defines a role, which has the policies of its own subject and of the roles it inherits.
+type RoleBuilder struct {
+	name     string
+	inherits []string
+}
+
+// Role defines the role of name, which is the subject of its policies.
+func Role(name string) *RoleBuilder {
+	return &RoleBuilder{
+		name: name,
+	}
+}
+
+// Inherits makes the role have the policies of the roles, e.g. editor inherits viewer.
+func (b *RoleBuilder) Inherits(roles ...string) (r *RoleBuilder) {
+	b.inherits = append(b.inherits, roles...)
+	return b
+}
+
+func (b *RoleBuilder) GetName() string {
+	return b.name
+}
+
+func (b *RoleBuilder) GetInherits() []string {
+	return b.inherits
+}
+
+// GroupBuilder defines a group of subjects, e.g. users, which have the policies of the group and of its roles.
+type GroupBuilder struct {
+	name    string
+	roles   []string
+	members []string
+}
+
+// Group defines the group of name, which is the subject of its policies.
+func Group(name string) *GroupBuilder {
+	return &GroupBuilder{
+		name: name,
+	}
+}
+
+// Roles gives the roles to the members of the group.
+func (b *GroupBuilder) Roles(roles ...string) (r *GroupBuilder) {
+	b.roles = append(b.roles, roles...)
+	return b
+}
+
+// Members adds the subjects to the group, a member can be a user, a role or another group.
+func (b *GroupBuilder) Members(subjects ...string) (r *GroupBuilder) {
+	b.members = append(b.members, subjects...)
+	return b
+}
+
+func (b *GroupBuilder) GetName() string {
+	return b.name
+}
+
+func (b *GroupBuilder) GetRoles() []string {
+	return b.roles
+}
+
+func (b *GroupBuilder) GetMembers() []string {
+	return b.members
+}
+
+// roleGraph is the subjects each subject gets, by role inheritance and group membership.
+type roleGraph map[string][]string
+
+func newRoleGraph(roles []*RoleBuilder, groups []*GroupBuilder) roleGraph {
+	g := make(roleGraph)
+	for _, r := range roles {
+		g[r.name] = append(g[r.name], r.inherits...)
+	}
+	for _, gr := range groups {
+		g[gr.name] = append(g[gr.name], gr.roles...)
+		for _, m := range gr.members {
+			g[m] = append(g[m], gr.name)
+		}
+	}
+	return g
+}
+
+// expand returns the subjects followed by the subjects they get in order, without duplicates.
+// Cycles are ignored, so a role inheriting itself does no harm.
+func (g roleGraph) expand(subjects []string) []string {
+	if len(g) == 0 {
+		return subjects
+	}
+
+	seen := make(map[string]bool)
+	var r []string
+	queue := append([]string{}, subjects...)
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		r = append(r, s)
+		queue = append(queue, g[s]...)
+	}
+	return r
+}
+
+// Roles sets the roles defined in code, the roles of DBPolicy are kept.
+func (b *Builder) Roles(rs ...*RoleBuilder) (r *Builder) {
+	b.rm.Lock()
+	defer b.rm.Unlock()
+	b.roles = rs
+	b.buildRoleGraph()
+	return b
+}
+
+// Groups sets the groups defined in code, the groups of DBPolicy are kept.
+func (b *Builder) Groups(gs ...*GroupBuilder) (r *Builder) {
+	b.rm.Lock()
+	defer b.rm.Unlock()
+	b.groups = gs
+	b.buildRoleGraph()
+	return b
+}
+
+func (b *Builder) setDBRoles(roles []*RoleBuilder, groups []*GroupBuilder) {
+	b.rm.Lock()
+	defer b.rm.Unlock()
+	b.dbRoles = roles
+	b.dbGroups = groups
+	b.buildRoleGraph()
+}
+
+func (b *Builder) buildRoleGraph() {
+	roles := append(append([]*RoleBuilder{}, b.roles...), b.dbRoles...)
+	groups := append(append([]*GroupBuilder{}, b.groups...), b.dbGroups...)
+	b.roleGraph = newRoleGraph(roles, groups)
+}
+
+// EffectiveSubjects returns the subjects with the groups they are members of and the roles they have,
+// including the inherited roles. The given subjects come first.
+func (b *Builder) EffectiveSubjects(subjects ...string) []string {
+	b.rm.RLock()
+	defer b.rm.RUnlock()
+	return b.roleGraph.expand(subjects)
+}
+
+// EffectiveSubjectsOf returns the effective subjects of the subjects of the request, given by SubjectsFunc.
+func (b *Builder) EffectiveSubjectsOf(r *http.Request) []string {
+	var subjects []string
+	if b.subjectsFunc != nil {
+		subjects = b.subjectsFunc(r)
+	}
+	if len(subjects) == 0 {
+		subjects = []string{Anonymous}
+	}
+	return b.EffectiveSubjects(subjects...)
+}
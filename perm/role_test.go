@@ -0,0 +1,114 @@
+package perm_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/perm"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestRoles(t *testing.T) {
+	p := perm.New().Policies(
+		perm.PolicyFor("viewer").WhoAre(perm.Allowed).ToDo("read").On("*:posts:*"),
+		perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*"),
+		perm.PolicyFor("reviewers").WhoAre(perm.Allowed).ToDo("approve").On("*:posts:*"),
+	).Roles(
+		perm.Role("viewer"),
+		perm.Role("editor").Inherits("viewer"),
+		perm.Role("admin").Inherits("editor", "admin"),
+	).Groups(
+		perm.Group("reviewers").Roles("viewer").Members("user_1"),
+		perm.Group("staff").Roles("editor").Members("user_2", "reviewers"),
+	).SubjectsFunc(sf("user_1"))
+
+	assert.Equal(t, []string{"admin", "editor", "viewer"}, p.EffectiveSubjects("admin"))
+	assert.Equal(t, []string{"user_1", "reviewers", "viewer", "staff", "editor"}, p.EffectiveSubjects("user_1"))
+	assert.Equal(t, []string{"user_3"}, p.EffectiveSubjects("user_3"))
+	assert.Equal(t, p.EffectiveSubjects("user_1"), p.EffectiveSubjectsOf(httptest.NewRequest("GET", "/", nil)))
+
+	verifier := perm.NewVerifier("presets", p)
+	for _, c := range []struct {
+		subject string
+		action  string
+		allowed bool
+	}{
+		{"viewer", "read", true},
+		{"viewer", "update", false},
+		{"editor", "read", true},
+		{"editor", "update", true},
+		{"admin", "update", true},
+		{"admin", "approve", false},
+		{"user_1", "approve", true},
+		{"user_1", "update", true},
+		{"user_2", "update", true},
+		{"user_2", "approve", false},
+		{"user_3", "read", false},
+	} {
+		err := verifier.Do(c.action).On("posts").From(c.subject).IsAllowed()
+		if c.allowed {
+			assert.NoError(t, err, "%s %s", c.subject, c.action)
+		} else {
+			assert.Error(t, err, "%s %s", c.subject, c.action)
+		}
+	}
+}
+
+func TestDefaultDBRole(t *testing.T) {
+	r := perm.DefaultDBRole{Name: "editor", Kind: perm.DBRoleKindRole, Roles: []string{"viewer"}}.ToRole()
+	assert.Equal(t, "editor", r.GetName())
+	assert.Equal(t, []string{"viewer"}, r.GetInherits())
+
+	g := perm.DefaultDBRole{Name: "staff", Kind: perm.DBRoleKindGroup, Roles: []string{"editor"}, Members: []string{"user_1"}}.ToGroup()
+	assert.Equal(t, "staff", g.GetName())
+	assert.Equal(t, []string{"editor"}, g.GetRoles())
+	assert.Equal(t, []string{"user_1"}, g.GetMembers())
+}
+
+// fakeDBRole keeps the roles in memory, as if they were in the database, failing to load them while err is set.
+type fakeDBRole struct {
+	mu    sync.Mutex
+	roles []*perm.RoleBuilder
+	err   error
+}
+
+func (r *fakeDBRole) set(err error, roles ...*perm.RoleBuilder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+	r.roles = roles
+}
+
+func (r *fakeDBRole) LoadDBRoles(db *gorm.DB) ([]*perm.RoleBuilder, []*perm.GroupBuilder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return append([]*perm.RoleBuilder{}, r.roles...), nil, nil
+}
+
+func TestDBRoleLoadFailure(t *testing.T) {
+	model := &fakeDBPolicy{}
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*").ID("1"))
+	roles := &fakeDBRole{}
+	roles.set(nil, perm.Role("admin").Inherits("editor"))
+
+	p := perm.New().DBPolicy(perm.NewDBPolicy(nil).Model(model).RoleModel(roles).LoadFrequency(time.Hour))
+	verifier := perm.NewVerifier("presets", p)
+	assert.NoError(t, p.TryReloadDBPolicies())
+	assert.NoError(t, verifier.Do("update").On("posts").From("admin").IsAllowed())
+
+	// the roles loaded before are kept when loading fails
+	roles.set(errors.New("connection lost"))
+	assert.Error(t, p.TryReloadDBPolicies())
+	assert.NoError(t, verifier.Do("update").On("posts").From("admin").IsAllowed())
+
+	roles.set(nil)
+	assert.NoError(t, p.TryReloadDBPolicies())
+	assert.Error(t, verifier.Do("update").On("posts").From("admin").IsAllowed())
+}
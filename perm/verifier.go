@@ -16,14 +16,19 @@ type verReq struct {
 	subjects       []string
 	objs           []interface{}
 	r              *http.Request
+	ctx            context.Context
 	req            *ladon.Request
 	resourcesParts []string
+	// prepared is whether the subjects and the context are set, so Explain after IsAllowed evaluates the same request
+	prepared bool
 }
 
 type Verifier struct {
 	builder *Builder
 	module  string
 	vr      *verReq
+	// simulation is whether the module, actions and resources are kept out of the catalog
+	simulation bool
 }
 
 func NewVerifier(module string, b *Builder) (r *Verifier) {
@@ -36,17 +41,29 @@ func NewVerifier(module string, b *Builder) (r *Verifier) {
 	}
 
 	r.builder = b
+	b.catalog.addModule(module)
 	return
 }
 
+// NewSimulationVerifier returns a Verifier like NewVerifier, for simulating requests, e.g. typed by users,
+// so its module and the actions and resources it verifies are not added to the catalog.
+func NewSimulationVerifier(module string, b *Builder) *Verifier {
+	return &Verifier{
+		module:     module,
+		builder:    b,
+		simulation: true,
+	}
+}
+
 func (b *Verifier) Spawn() (r *Verifier) {
 	if b.builder == nil {
 		return b
 	}
 
 	r = &Verifier{
-		module:  b.module,
-		builder: b.builder,
+		module:     b.module,
+		builder:    b.builder,
+		simulation: b.simulation,
 	}
 
 	resourceParts := []string{b.module}
@@ -136,6 +153,15 @@ func (b *Verifier) WithReq(v *http.Request) (r *Verifier) {
 	return b
 }
 
+// WithContext sets the context of the request, e.g. of a gRPC call, giving the subjects with Builder.CtxSubjectsFunc.
+func (b *Verifier) WithContext(ctx context.Context) (r *Verifier) {
+	if b.builder == nil {
+		return b
+	}
+	b.vr.ctx = ctx
+	return b
+}
+
 func (b *Verifier) From(v string) (r *Verifier) {
 	if b.builder == nil {
 		return b
@@ -158,15 +184,52 @@ func (b *Verifier) IsAllowed() error {
 		return nil
 	}
 
+	b.prepare()
+
+	var err error
+	// any of the subjects have permission, then have permission
+	for _, sub := range b.vr.subjects {
+		b.vr.req.Subject = sub
+
+		err = b.builder.ladon.IsAllowed(b.context(), b.vr.req)
+		if Verbose {
+			fmt.Printf("have permission: %+v, req: %#+v\n", err == nil, b.vr.req)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+
+	if b.builder.onDenied != nil {
+		b.builder.onDenied(b.context(), b.Explain())
+	}
+	return err
+}
+
+// prepare sets the resource, the effective subjects and the context of the request.
+func (b *Verifier) prepare() {
 	b.vr.req.Resource = ":" + strings.Join(b.vr.resourcesParts, ":") + ":"
+	if b.vr.prepared {
+		return
+	}
+	b.vr.prepared = true
+	if !b.simulation {
+		b.builder.catalog.add(b.vr.req.Action, b.vr.resourcesParts)
+	}
 
-	if len(b.vr.subjects) == 0 && b.builder.subjectsFunc != nil {
-		b.vr.subjects = b.builder.subjectsFunc(b.vr.r)
+	if len(b.vr.subjects) == 0 {
+		switch {
+		case b.vr.r == nil && b.builder.ctxSubjectsFunc != nil:
+			b.vr.subjects = b.builder.ctxSubjectsFunc(b.context())
+		case b.builder.subjectsFunc != nil:
+			b.vr.subjects = b.builder.subjectsFunc(b.vr.r)
+		}
 	}
 
 	if len(b.vr.subjects) == 0 {
 		b.vr.subjects = []string{Anonymous}
 	}
+	b.vr.subjects = b.builder.EffectiveSubjects(b.vr.subjects...)
 
 	if b.builder.contextFunc != nil {
 		newContext := b.builder.contextFunc(b.vr.r, b.vr.objs)
@@ -177,20 +240,14 @@ func (b *Verifier) IsAllowed() error {
 			b.vr.req.Context = newContext
 		}
 	}
+}
 
-	var err error
-	// any of the subjects have permission, then have permission
-	for _, sub := range b.vr.subjects {
-		b.vr.req.Subject = sub
-
-		err = b.builder.ladon.IsAllowed(context.TODO(), b.vr.req)
-		if Verbose {
-			fmt.Printf("have permission: %+v, req: %#+v\n", err == nil, b.vr.req)
-		}
-		if err == nil {
-			return nil
-		}
+func (b *Verifier) context() context.Context {
+	if b.vr.ctx != nil {
+		return b.vr.ctx
 	}
-
-	return err
+	if b.vr.r != nil {
+		return b.vr.r.Context()
+	}
+	return context.Background()
 }
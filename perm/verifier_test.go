@@ -0,0 +1,58 @@
+package perm_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qor5/x/v3/perm"
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKeySubject struct{}
+
+func TestCtxSubjects(t *testing.T) {
+	p := perm.New().Policies(
+		perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*"),
+	).SubjectsFunc(sf("viewer")).CtxSubjectsFunc(func(ctx context.Context) []string {
+		if sub, ok := ctx.Value(ctxKeySubject{}).(string); ok {
+			return []string{sub}
+		}
+		return nil
+	})
+
+	verifier := perm.NewVerifier("presets", p)
+	editorCtx := context.WithValue(context.Background(), ctxKeySubject{}, "editor")
+	assert.NoError(t, verifier.Do("update").On("posts").WithContext(editorCtx).IsAllowed())
+	assert.Error(t, verifier.Do("update").On("posts").WithContext(context.Background()).IsAllowed())
+
+	// the request gives the subjects by SubjectsFunc
+	r := httptest.NewRequest("GET", "/", nil).WithContext(editorCtx)
+	assert.Error(t, verifier.Do("update").On("posts").WithReq(r).IsAllowed())
+	assert.Error(t, verifier.Do("update").On("posts").WithReq(r).WithContext(editorCtx).IsAllowed())
+
+	d := verifier.Do("update").On("posts").WithContext(editorCtx).Explain()
+	assert.True(t, d.Allowed)
+	assert.Equal(t, []string{"editor"}, d.Subjects)
+}
+
+func TestCatalog(t *testing.T) {
+	p := perm.New()
+	verifier := perm.NewVerifier("presets", p)
+	perm.NewVerifier("media", p)
+
+	_ = verifier.Do("update").On("posts", "1").From("editor").IsAllowed()
+	_ = verifier.Do("update").On("posts", "2").From("editor").IsAllowed()
+	_ = verifier.Do("list").From("editor").IsAllowed()
+
+	assert.Equal(t, []string{"media", "presets"}, p.GetModules())
+	assert.Equal(t, []string{"list", "update"}, p.GetActions())
+	assert.Equal(t, []string{"*:presets:*", "*:presets:posts:*"}, p.GetResources())
+
+	// simulated requests are not added
+	d := perm.NewSimulationVerifier("reports", p).Do("export").On("sales", "1").From("editor").Explain()
+	assert.False(t, d.Allowed)
+	assert.Equal(t, []string{"media", "presets"}, p.GetModules())
+	assert.Equal(t, []string{"list", "update"}, p.GetActions())
+	assert.Equal(t, []string{"*:presets:*", "*:presets:posts:*"}, p.GetResources())
+}
@@ -0,0 +1,161 @@
+package perm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/qor5/x/v3/gormx/postgresx"
+)
+
+// DefaultNotifyChannel is the Postgres channel the changes of the policies are notified on.
+const DefaultNotifyChannel = "perm_policies"
+
+// WatchRetryInterval is the interval watching or reloading the policies is retried at after failures.
+var WatchRetryInterval = time.Second
+
+// ErrWatchNotSupported is returned by PolicyWatcher when the database can't push changes.
+var ErrWatchNotSupported = errors.New("watching policies is not supported")
+
+// PolicyWatcher pushes the changes of the policies and roles made by any instance, e.g. with
+// Postgres LISTEN/NOTIFY, or with the messages of a gobusx bus published on every change.
+type PolicyWatcher interface {
+	// WatchPolicies calls onChange for each change until ctx is done or watching fails.
+	// onReady is called once watching started, changes made before it may have been missed.
+	WatchPolicies(ctx context.Context, onReady func(), onChange func()) error
+}
+
+// WatchPolicyFunc is a func implementing PolicyWatcher.
+type WatchPolicyFunc func(ctx context.Context, onReady func(), onChange func()) error
+
+func (f WatchPolicyFunc) WatchPolicies(ctx context.Context, onReady func(), onChange func()) error {
+	return f(ctx, onReady, onChange)
+}
+
+// Watcher makes the builder reload all the policies and roles whenever w pushes a change, and once it
+// (re)connects, instead of polling the changes. The database is still polled every LoadFrequency while
+// watching is unavailable.
+func (dpb *DBPolicyBuilder) Watcher(w PolicyWatcher) *DBPolicyBuilder {
+	dpb.watcher = w
+	return dpb
+}
+
+// Listen watches the changes with Postgres LISTEN/NOTIFY on channel, e.g. DefaultNotifyChannel,
+// which the tables notify with the triggers created by MigrateNotifyTriggers.
+func (dpb *DBPolicyBuilder) Listen(channel string) *DBPolicyBuilder {
+	return dpb.Watcher(WatchPolicyFunc(func(ctx context.Context, onReady func(), onChange func()) error {
+		if !postgresx.IsPostgres(dpb.db) {
+			return ErrWatchNotSupported
+		}
+		return postgresx.Listen(ctx, dpb.db, channel, onReady, func(string) {
+			onChange()
+		})
+	}))
+}
+
+// MigrateNotifyTriggers creates the triggers notifying channel on every change of the tables of models,
+// e.g. of DefaultDBPolicy and DefaultDBRole, notifications are delivered once the changes are committed.
+func MigrateNotifyTriggers(ctx context.Context, db *gorm.DB, channel string, models ...any) error {
+	if !postgresx.IsPostgres(db) {
+		return ErrWatchNotSupported
+	}
+
+	db = db.WithContext(ctx)
+	err := db.Exec(`CREATE OR REPLACE FUNCTION perm_notify() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify(TG_ARGV[0], TG_TABLE_NAME);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create the notify function: %w", err)
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+		table := stmt.Quote(stmt.Schema.Table)
+		literal := "'" + strings.ReplaceAll(channel, "'", "''") + "'"
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("DROP TRIGGER IF EXISTS perm_notify ON " + table).Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE TRIGGER perm_notify AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON " + table +
+				" FOR EACH STATEMENT EXECUTE FUNCTION perm_notify(" + literal + ")").Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create the notify trigger of %s: %w", stmt.Schema.Table, err)
+		}
+	}
+	return nil
+}
+
+// watchDBPolicies reloads all the policies whenever the watcher pushes a change, reconnecting after failures.
+// A failed reload keeps the policies loaded before and is retried after WatchRetryInterval.
+// Reloads are made one at a time by this goroutine, and a change pushed during a reload makes another one,
+// so the last reload always reads the last committed change.
+func (b *Builder) watchDBPolicies(ctx context.Context, dpb *DBPolicyBuilder) {
+	reload := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	}
+
+	var watching atomic.Bool
+	go func() {
+		for ctx.Err() == nil {
+			err := dpb.watcher.WatchPolicies(ctx, func() {
+				watching.Store(true)
+				// changes may have been missed while not watching
+				notify()
+			}, notify)
+			watching.Store(false)
+			if errors.Is(err, ErrWatchNotSupported) {
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to watch permission policies", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(WatchRetryInterval):
+			}
+		}
+	}()
+
+	load := func() {
+		if err := b.loadDBPoliciesToMemory(dpb.db, nil); err != nil {
+			slog.ErrorContext(ctx, "Failed to load permission policies", "error", err)
+			// the pushed change is not applied yet, so it is retried even while watching
+			time.AfterFunc(WatchRetryInterval, notify)
+		}
+	}
+
+	load()
+	ticker := time.NewTicker(dpb.loadFrequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-ticker.C:
+			if watching.Load() {
+				continue
+			}
+		}
+		load()
+	}
+}
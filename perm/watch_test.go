@@ -0,0 +1,161 @@
+package perm_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qor5/x/v3/perm"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeDBPolicy keeps the policies in memory, as if they were in the database, failing to load them while err is set.
+type fakeDBPolicy struct {
+	mu       sync.Mutex
+	policies []*perm.PolicyBuilder
+	err      error
+}
+
+func (p *fakeDBPolicy) set(ps ...*perm.PolicyBuilder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policies = ps
+}
+
+func (p *fakeDBPolicy) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *fakeDBPolicy) LoadDBPolicies(db *gorm.DB, startFrom *time.Time) ([]*perm.PolicyBuilder, []*perm.PolicyBuilder) {
+	toUpdateOrCreate, toDelete, _ := p.TryLoadDBPolicies(db, startFrom)
+	return toUpdateOrCreate, toDelete
+}
+
+func (p *fakeDBPolicy) TryLoadDBPolicies(db *gorm.DB, startFrom *time.Time) ([]*perm.PolicyBuilder, []*perm.PolicyBuilder, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+	return append([]*perm.PolicyBuilder{}, p.policies...), nil, nil
+}
+
+func TestWatchDBPolicies(t *testing.T) {
+	retryInterval := perm.WatchRetryInterval
+	perm.WatchRetryInterval = 10 * time.Millisecond
+	defer func() { perm.WatchRetryInterval = retryInterval }()
+
+	model := &fakeDBPolicy{}
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*").ID("1"))
+
+	changes := make(chan struct{})
+	disconnects := make(chan struct{})
+	var connects int
+	var mu sync.Mutex
+	watcher := perm.WatchPolicyFunc(func(ctx context.Context, onReady func(), onChange func()) error {
+		mu.Lock()
+		connects++
+		mu.Unlock()
+		onReady()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changes:
+				onChange()
+			case <-disconnects:
+				return errors.New("connection lost")
+			}
+		}
+	})
+
+	p := perm.New().DBPolicy(perm.NewDBPolicy(nil).Model(model).Watcher(watcher).LoadFrequency(time.Hour))
+	verifier := perm.NewVerifier("presets", p)
+	allowed := func(action string) func() bool {
+		return func() bool {
+			return verifier.Do(action).On("posts").From("editor").IsAllowed() == nil
+		}
+	}
+	assert.Eventually(t, allowed("update"), time.Second, 5*time.Millisecond)
+
+	// a deleted policy is removed by the reload
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("delete").On("*:posts:*").ID("2"))
+	changes <- struct{}{}
+	assert.Eventually(t, allowed("delete"), time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return !allowed("update")() }, time.Second, 5*time.Millisecond)
+
+	// changes missed while disconnected are resynced on reconnect
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("publish").On("*:posts:*").ID("3"))
+	disconnects <- struct{}{}
+	assert.Eventually(t, allowed("publish"), time.Second, 5*time.Millisecond)
+	assert.False(t, allowed("delete")())
+	mu.Lock()
+	assert.Equal(t, 2, connects)
+	mu.Unlock()
+}
+
+func TestWatchDBPoliciesLoadFailure(t *testing.T) {
+	retryInterval := perm.WatchRetryInterval
+	perm.WatchRetryInterval = 10 * time.Millisecond
+	defer func() { perm.WatchRetryInterval = retryInterval }()
+
+	model := &fakeDBPolicy{}
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*").ID("1"))
+
+	changes := make(chan struct{})
+	watcher := perm.WatchPolicyFunc(func(ctx context.Context, onReady func(), onChange func()) error {
+		onReady()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changes:
+				onChange()
+			}
+		}
+	})
+
+	p := perm.New().DBPolicy(perm.NewDBPolicy(nil).Model(model).Watcher(watcher).LoadFrequency(time.Hour))
+	verifier := perm.NewVerifier("presets", p)
+	allowed := func(action string) func() bool {
+		return func() bool {
+			return verifier.Do(action).On("posts").From("editor").IsAllowed() == nil
+		}
+	}
+	assert.Eventually(t, allowed("update"), time.Second, 5*time.Millisecond)
+
+	// the policies loaded before are kept while loading fails
+	model.fail(errors.New("connection lost"))
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("delete").On("*:posts:*").ID("2"))
+	changes <- struct{}{}
+	assert.Error(t, p.TryReloadDBPolicies())
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, allowed("update")())
+	assert.False(t, allowed("delete")())
+
+	// the failed reload is retried without another change
+	model.fail(nil)
+	assert.Eventually(t, allowed("delete"), time.Second, 5*time.Millisecond)
+	assert.False(t, allowed("update")())
+}
+
+func TestDBPolicyWithoutTryLoader(t *testing.T) {
+	model := &fakeDBPolicy{}
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("update").On("*:posts:*").ID("1"))
+
+	// only LoadDBPolicies is implemented
+	p := perm.New().DBPolicy(perm.NewDBPolicy(nil).Model(struct{ perm.DBPolicy }{model}).LoadFrequency(time.Hour))
+	verifier := perm.NewVerifier("presets", p)
+	p.ReloadDBPolicies()
+	assert.NoError(t, verifier.Do("update").On("posts").From("editor").IsAllowed())
+
+	model.set(perm.PolicyFor("editor").WhoAre(perm.Allowed).ToDo("delete").On("*:posts:*").ID("2"))
+	assert.NoError(t, p.TryReloadDBPolicies())
+	assert.NoError(t, verifier.Do("delete").On("posts").From("editor").IsAllowed())
+	assert.Error(t, verifier.Do("update").On("posts").From("editor").IsAllowed())
+}
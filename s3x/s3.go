@@ -2,6 +2,7 @@ package s3x
 
 import (
 	"cmp"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -14,14 +15,33 @@ type Config struct {
 	Bucket   string `confx:"bucket" usage:"AWS bucket" validate:"required"`
 	Endpoint string `confx:"endpoint" usage:"AWS endpoint"`
 	ACL      string `confx:"-"`
+
+	ServerSideEncryption string            `confx:"serverSideEncryption" usage:"Server side encryption of stored objects, AES256, aws:kms or aws:kms:dsse" validate:"omitempty,oneof=AES256 aws:kms aws:kms:dsse"`
+	SSEKMSKeyID          string            `confx:"sseKMSKeyID" usage:"KMS key ID of aws:kms encryption, the bucket's default key is used if empty" validate:"excluded_without=ServerSideEncryption"`
+	StorageClass         string            `confx:"storageClass" usage:"Storage class of stored objects, e.g. STANDARD_IA or INTELLIGENT_TIERING"`
+	Tags                 map[string]string `confx:"tags" usage:"Tags added to every stored object"`
+	ObjectLockMode       string            `confx:"objectLockMode" usage:"Object lock mode of stored objects, GOVERNANCE or COMPLIANCE, the bucket must have object lock enabled" validate:"omitempty,oneof=GOVERNANCE COMPLIANCE"`
+	ObjectLockRetention  time.Duration     `confx:"objectLockRetention" usage:"Duration stored objects are retained by the object lock" validate:"required_with=ObjectLockMode"`
 }
 
+// SetupClient returns the storage of the config, use NewClient to access the S3 specific features
 func SetupClient(conf *Config, awsConfig *aws.Config) oss.StorageInterface {
+	return NewClient(conf, awsConfig)
+}
+
+// NewClient returns the S3 client of the config, which supports versioning, tagging and object lock
+func NewClient(conf *Config, awsConfig *aws.Config) *s3.Client {
 	return s3.New(&s3.Config{
-		AWSConfig: awsConfig,
-		Bucket:    conf.Bucket,
-		Region:    conf.Region,
-		ACL:       cmp.Or(conf.ACL, string(types.ObjectCannedACLBucketOwnerFullControl)),
-		Endpoint:  conf.Endpoint,
+		AWSConfig:            awsConfig,
+		Bucket:               conf.Bucket,
+		Region:               conf.Region,
+		ACL:                  cmp.Or(conf.ACL, string(types.ObjectCannedACLBucketOwnerFullControl)),
+		Endpoint:             conf.Endpoint,
+		ServerSideEncryption: types.ServerSideEncryption(conf.ServerSideEncryption),
+		SSEKMSKeyID:          conf.SSEKMSKeyID,
+		StorageClass:         types.StorageClass(conf.StorageClass),
+		Tags:                 conf.Tags,
+		ObjectLockMode:       types.ObjectLockMode(conf.ObjectLockMode),
+		ObjectLockRetention:  conf.ObjectLockRetention,
 	})
 }
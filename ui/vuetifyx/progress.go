@@ -0,0 +1,107 @@
+package vuetifyx
+
+import (
+	"context"
+	"fmt"
+
+	v "github.com/qor5/x/v3/ui/vuetify"
+	h "github.com/theplant/htmlgo"
+)
+
+// ProgressBuilder renders the progress of a long running task, e.g. a background import,
+// with a bar, the processed count and a message.
+type ProgressBuilder struct {
+	label     string
+	status    string
+	color     string
+	message   string
+	processed uint
+	total     uint
+	running   bool
+	children  []h.HTMLComponent
+}
+
+func Progress(children ...h.HTMLComponent) (r *ProgressBuilder) {
+	r = &ProgressBuilder{
+		color: "primary",
+	}
+	r.Children(children...)
+	return
+}
+
+func (b *ProgressBuilder) Label(v string) (r *ProgressBuilder) {
+	b.label = v
+	return b
+}
+
+// Status is shown next to the label, e.g. Running or Failed.
+func (b *ProgressBuilder) Status(v string) (r *ProgressBuilder) {
+	b.status = v
+	return b
+}
+
+func (b *ProgressBuilder) Color(v string) (r *ProgressBuilder) {
+	b.color = v
+	return b
+}
+
+// Message is shown under the bar, e.g. the error of a failed task.
+func (b *ProgressBuilder) Message(v string) (r *ProgressBuilder) {
+	b.message = v
+	return b
+}
+
+// Value sets the processed count out of total, the total is 0 if unknown.
+func (b *ProgressBuilder) Value(processed, total uint) (r *ProgressBuilder) {
+	b.processed = processed
+	b.total = total
+	return b
+}
+
+// Running makes the bar indeterminate while the total is unknown.
+func (b *ProgressBuilder) Running(v bool) (r *ProgressBuilder) {
+	b.running = v
+	return b
+}
+
+// Children are rendered under the progress, e.g. download links.
+func (b *ProgressBuilder) Children(comps ...h.HTMLComponent) (r *ProgressBuilder) {
+	b.children = comps
+	return b
+}
+
+func (b *ProgressBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	var percent float64
+	count := fmt.Sprint(b.processed)
+	if b.total > 0 {
+		percent = float64(b.processed) * 100 / float64(b.total)
+		if percent > 100 {
+			percent = 100
+		}
+		count = fmt.Sprintf("%d / %d (%.0f%%)", b.processed, b.total, percent)
+	} else if !b.running {
+		percent = 100
+	}
+
+	var message h.HTMLComponent
+	if b.message != "" {
+		message = h.Div(h.Text(b.message)).Class("text-caption text-" + b.color + " mt-1")
+	}
+
+	return h.Div(
+		h.Div(
+			h.Span(b.label).Class("text-subtitle-2"),
+			h.Span(b.status).Class("text-caption text-"+b.color),
+		).Class("d-flex justify-space-between mb-1"),
+		v.VProgressLinear().
+			ModelValue(percent).
+			Indeterminate(b.running && b.total == 0).
+			Color(b.color).
+			Height(8).
+			Rounded(true),
+		h.Div(h.Text(count)).Class("text-caption text-grey mt-1"),
+		message,
+		h.Div(b.children...).Class("mt-2"),
+	).Class("vx-progress").
+		MarshalHTML(ctx)
+}